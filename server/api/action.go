@@ -0,0 +1,148 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils"
+)
+
+func (h *Handler) actionShift(w http.ResponseWriter, r *http.Request) {
+	request, ok := h.postActionRequest(w, r)
+	if !ok {
+		return
+	}
+	action, err := sl.ShiftActionFromContext(request.Context)
+	if err != nil {
+		h.badRequest(w, err)
+		return
+	}
+
+	api := sl.FromContext(r.Context())
+	rotation, err := api.LoadRotation(action.RotationID)
+	if err != nil {
+		h.actionResponse(w, "", err)
+		return
+	}
+	ref := rotation.ShiftRef(action.ShiftNumber)
+
+	switch action.Action {
+	case sl.ShiftActionConfirm:
+		_, err = api.ConfirmShift(rotation, action.ShiftNumber, time.Now())
+		h.actionResponse(w, fmt.Sprintf("You confirmed %s.", ref), err)
+
+	case sl.ShiftActionJoin:
+		_, _, err = api.JoinShift("", rotation, action.ShiftNumber, "")
+		h.actionResponse(w, fmt.Sprintf("You joined %s.", ref), err)
+
+	case sl.ShiftActionLeave:
+		_, _, err = api.LeaveShift("", rotation, action.ShiftNumber)
+		h.actionResponse(w, fmt.Sprintf("You left %s.", ref), err)
+
+	case sl.ShiftActionUnavailable:
+		_, err = api.DeclineShift(rotation, action.ShiftNumber)
+		h.actionResponse(w, fmt.Sprintf("You are marked unavailable for %s, and left it.", ref), err)
+
+	case sl.ShiftActionSwap:
+		// The post stays as is, so that the user can ask someone else if
+		// the swap is declined.
+		selected, _ := request.Context[utils.ContextSelectedOption].(string)
+		response := model.PostActionIntegrationResponse{}
+		mattermostUser, err := api.GetMattermostUser(selected)
+		if err == nil {
+			_, err = api.RequestSwap(rotation, action.ShiftNumber, mattermostUser.Username, sl.NoShift)
+		}
+		if err != nil {
+			response.EphemeralText = "Error: " + err.Error()
+		} else {
+			response.EphemeralText = fmt.Sprintf("Asked @%s to swap %s, waiting for them to accept. It can be withdrawn from the message sent to you.", mattermostUser.Username, ref)
+		}
+		h.writeActionResponse(w, &response)
+
+	default:
+		h.badRequest(w, errors.Errorf("unknown shift action %q", action.Action))
+	}
+}
+
+func (h *Handler) actionSwap(w http.ResponseWriter, r *http.Request) {
+	request, ok := h.postActionRequest(w, r)
+	if !ok {
+		return
+	}
+	swapID, action, err := sl.SwapFromContext(request.Context)
+	if err != nil {
+		h.badRequest(w, err)
+		return
+	}
+
+	api := sl.FromContext(r.Context())
+	swap, err := api.LoadSwap(swapID)
+	if err != nil {
+		h.actionResponse(w, "", err)
+		return
+	}
+	rotation, err := api.LoadRotation(swap.RotationID)
+	if err != nil {
+		h.actionResponse(w, "", err)
+		return
+	}
+
+	switch action {
+	case sl.SwapActionAccept:
+		err = api.AcceptSwap(rotation, swap)
+		h.actionResponse(w, "Accepted the swap of "+rotation.ShiftRef(swap.ShiftNumber)+".", err)
+	case sl.SwapActionDecline:
+		err = api.DeclineSwap(rotation, swap)
+		h.actionResponse(w, "Declined the swap of "+rotation.ShiftRef(swap.ShiftNumber)+".", err)
+	case sl.SwapActionWithdraw:
+		err = api.WithdrawSwap(rotation, swap)
+		h.actionResponse(w, "Withdrew the swap of "+rotation.ShiftRef(swap.ShiftNumber)+".", err)
+	default:
+		h.badRequest(w, errors.Errorf("unknown swap action %q", action))
+	}
+}
+
+// postActionRequest decodes the request, and verifies that its context was
+// signed by the plugin. If not, it writes the error response and returns false.
+func (h *Handler) postActionRequest(w http.ResponseWriter, r *http.Request) (*model.PostActionIntegrationRequest, bool) {
+	request := model.PostActionIntegrationRequestFromJson(r.Body)
+	if request == nil {
+		h.badRequest(w, errors.New("invalid post action request"))
+		return nil, false
+	}
+	conf := config.FromContext(r.Context())
+	err := utils.VerifyContext(conf.ActionSigningSecret, request.Context)
+	if err != nil {
+		h.forbidden(w, err)
+		return nil, false
+	}
+	return request, true
+}
+
+// actionResponse replaces the post's actions with the outcome, or, on error,
+// leaves the post as is and shows the error to the user.
+func (h *Handler) actionResponse(w http.ResponseWriter, outcome string, err error) {
+	response := model.PostActionIntegrationResponse{}
+	if err != nil {
+		response.EphemeralText = "Error: " + err.Error()
+	} else {
+		response.Update = &model.Post{Message: outcome}
+		response.Update.AddProp("attachments", []*model.SlackAttachment{})
+	}
+	h.writeActionResponse(w, &response)
+}
+
+func (h *Handler) writeActionResponse(w http.ResponseWriter, response *model.PostActionIntegrationResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+)
+
+func (h *Handler) apiExport(w http.ResponseWriter, r *http.Request) {
+	api := sl.FromContext(r.Context())
+	backup, err := api.ExportBackup(time.Now())
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, backup)
+}
+
+// apiImport imports the backup in the body, in the "mode" query parameter
+// mode, merge by default.
+func (h *Handler) apiImport(w http.ResponseWriter, r *http.Request) {
+	backup := &sl.Backup{}
+	if !h.decodeJSON(w, r, backup) {
+		return
+	}
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = sl.ImportMerge
+	}
+
+	api := sl.FromContext(r.Context())
+	err := api.ImportBackup(backup, mode)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, struct {
+		Rotations int `json:"rotations"`
+		Shifts    int `json:"shifts"`
+		Users     int `json:"users"`
+	}{len(backup.Rotations), len(backup.Shifts), len(backup.Users)})
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+// rotationRequest is the body of the requests that create or update a
+// rotation. Start, Period, TimeZone, HandoffTime and Type can only be set at
+// creation, as they move the stored shifts.
+type rotationRequest struct {
+	Name        string `json:"name"`
+	Start       string `json:"start"`
+	Period      string `json:"period"`
+	Type        string `json:"type"`
+	TimeZone    string `json:"timezone"`
+	HandoffTime string `json:"handoff"`
+	Size        *int   `json:"size"`
+	Grace       *int   `json:"grace"`
+	Weighting   string `json:"weighting"`
+}
+
+// apply sets the fields that can be updated, validating them the same way the
+// commands do.
+func (req rotationRequest) apply(rotation *sl.Rotation) error {
+	if req.Period != "" {
+		period := sl.Period{}
+		err := period.Set(req.Period)
+		if err != nil {
+			return err
+		}
+		rotation.Period = period.String()
+	}
+	if req.Weighting != "" {
+		weighting := sl.Weighting{}
+		err := weighting.Set(req.Weighting)
+		if err != nil {
+			return err
+		}
+		rotation.Weighting = weighting.String()
+	}
+	if req.Size != nil {
+		if *req.Size < 0 {
+			return errors.Errorf("size must not be negative, got %v", *req.Size)
+		}
+		rotation.Size = *req.Size
+	}
+	if req.Grace != nil {
+		if *req.Grace < 0 {
+			return errors.Errorf("grace must not be negative, got %v", *req.Grace)
+		}
+		rotation.Grace = *req.Grace
+	}
+	return nil
+}
+
+func (h *Handler) apiListRotations(w http.ResponseWriter, r *http.Request) {
+	api := sl.FromContext(r.Context())
+	known, err := api.LoadKnownRotations()
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	ids := []string{}
+	for id := range known {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	rotations := []*store.Rotation{}
+	for _, id := range ids {
+		rotation, err := api.LoadRotation(id)
+		if err != nil {
+			h.apiError(w, err)
+			return
+		}
+		rotations = append(rotations, rotation.Rotation)
+	}
+	h.writeJSON(w, http.StatusOK, rotations)
+}
+
+func (h *Handler) apiAddRotation(w http.ResponseWriter, r *http.Request) {
+	req := rotationRequest{}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		h.badRequest(w, errors.New("name is required"))
+		return
+	}
+
+	api := sl.FromContext(r.Context())
+	rotation, err := api.MakeRotation(req.Name)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	rotation.Start = req.Start
+	rotation.Type = req.Type
+	rotation.TimeZone = req.TimeZone
+	rotation.HandoffTime = req.HandoffTime
+	rotation.Grace = 1
+	err = req.apply(rotation)
+	if err != nil {
+		h.badRequest(w, err)
+		return
+	}
+
+	err = api.AddRotation(rotation)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, rotation.Rotation)
+}
+
+func (h *Handler) apiGetRotation(w http.ResponseWriter, r *http.Request) {
+	rotation, ok := h.loadRotation(w, r)
+	if !ok {
+		return
+	}
+	h.writeJSON(w, http.StatusOK, rotation.Rotation)
+}
+
+func (h *Handler) apiUpdateRotation(w http.ResponseWriter, r *http.Request) {
+	req := rotationRequest{}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Name != "" || req.Start != "" || req.Period != "" || req.Type != "" || req.TimeZone != "" || req.HandoffTime != "" {
+		h.badRequest(w, errors.New("name, start, period, type, timezone and handoff can not be modified"))
+		return
+	}
+	rotation, ok := h.loadRotation(w, r)
+	if !ok {
+		return
+	}
+
+	api := sl.FromContext(r.Context())
+	err := api.UpdateRotation(rotation, req.apply)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, rotation.Rotation)
+}
+
+func (h *Handler) apiArchiveRotation(w http.ResponseWriter, r *http.Request) {
+	rotation, ok := h.loadRotation(w, r)
+	if !ok {
+		return
+	}
+
+	api := sl.FromContext(r.Context())
+	err := api.ArchiveRotation(rotation)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, rotation.Rotation)
+}
+
+// loadRotation loads the rotation in the request path. If it fails, it writes
+// the error response and returns false.
+func (h *Handler) loadRotation(w http.ResponseWriter, r *http.Request) (*sl.Rotation, bool) {
+	api := sl.FromContext(r.Context())
+	rotation, err := api.LoadRotation(mux.Vars(r)["rotationID"])
+	if err != nil {
+		h.apiError(w, err)
+		return nil, false
+	}
+	return rotation, true
+}
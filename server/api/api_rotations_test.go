@@ -0,0 +1,98 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+func TestAPIAddRotation(t *testing.T) {
+	for name, tc := range map[string]struct {
+		req            map[string]interface{}
+		expectedStatus int
+	}{
+		"valid":             {map[string]interface{}{"name": "test", "start": "2020-01-06", "period": "1w", "size": 2}, http.StatusCreated},
+		"no name":           {map[string]interface{}{"start": "2020-01-06", "period": "1w"}, http.StatusBadRequest},
+		"invalid period":    {map[string]interface{}{"name": "test", "start": "2020-01-06", "period": "1y"}, http.StatusBadRequest},
+		"negative size":     {map[string]interface{}{"name": "test", "start": "2020-01-06", "period": "1w", "size": -1}, http.StatusBadRequest},
+		"negative grace":    {map[string]interface{}{"name": "test", "start": "2020-01-06", "period": "1w", "grace": -1}, http.StatusBadRequest},
+		"invalid weighting": {map[string]interface{}{"name": "test", "start": "2020-01-06", "period": "1w", "weighting": "bogus"}, http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			s := newTestServer(ctrl, "admin", "owner")
+
+			rotation := &store.Rotation{}
+			require.Equal(t, tc.expectedStatus, s.as(t, "owner", "POST", "/rotations", tc.req, rotation))
+			if tc.expectedStatus != http.StatusCreated {
+				return
+			}
+			require.NotEmpty(t, rotation.RotationID)
+			require.Equal(t, "test", rotation.Name)
+			require.Equal(t, 2, rotation.Size)
+			require.Equal(t, 1, rotation.Grace)
+			require.Equal(t, store.IDMap{"owner": "owner"}, rotation.Owners)
+		})
+	}
+}
+
+func TestAPIRotations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newTestServer(ctrl, "admin", "owner", "u1")
+
+	created := &store.Rotation{}
+	require.Equal(t, http.StatusCreated, s.as(t, "owner", "POST", "/rotations",
+		map[string]interface{}{"name": "test", "start": "2020-01-06", "period": "1w"}, created))
+	path := "/rotations/" + created.RotationID
+
+	rotations := []*store.Rotation{}
+	require.Equal(t, http.StatusOK, s.as(t, "u1", "GET", "/rotations", nil, &rotations))
+	require.Len(t, rotations, 1)
+	require.Equal(t, created.RotationID, rotations[0].RotationID)
+
+	loaded := &store.Rotation{}
+	require.Equal(t, http.StatusOK, s.as(t, "u1", "GET", path, nil, loaded))
+	require.Equal(t, "test", loaded.Name)
+	require.Equal(t, http.StatusNotFound, s.as(t, "u1", "GET", "/rotations/unknown", nil, nil))
+
+	// only the owners and the admins can update it, and only some fields
+	require.Equal(t, http.StatusForbidden, s.as(t, "u1", "PATCH", path, map[string]interface{}{"size": 3}, nil))
+	for _, req := range []map[string]interface{}{
+		{"name": "other"},
+		{"start": "2020-02-03"},
+		{"type": "queue"},
+		{"timezone": "Europe/Paris"},
+		{"handoff": "09:00"},
+		{"size": -1},
+		{"grace": -2},
+		{"period": "bogus"},
+		{"period": "2w"},
+	} {
+		require.Equal(t, http.StatusBadRequest, s.as(t, "owner", "PATCH", path, req, nil), req)
+	}
+	updated := &store.Rotation{}
+	require.Equal(t, http.StatusOK, s.as(t, "owner", "PATCH", path, map[string]interface{}{"size": 3, "grace": 2}, updated))
+	require.Equal(t, 3, updated.Size)
+	require.Equal(t, 2, updated.Grace)
+	require.Equal(t, http.StatusOK, s.as(t, "admin", "PATCH", path, map[string]interface{}{"weighting": "linear"}, updated))
+	require.Equal(t, "linear", updated.Weighting)
+	require.Equal(t, "1w", updated.Period)
+	require.Equal(t, 3, updated.Size)
+
+	require.Equal(t, http.StatusForbidden, s.as(t, "u1", "POST", path+"/archive", nil, nil))
+	archived := &store.Rotation{}
+	require.Equal(t, http.StatusOK, s.as(t, "owner", "POST", path+"/archive", nil, archived))
+	require.True(t, archived.IsArchived)
+	rotations = []*store.Rotation{}
+	require.Equal(t, http.StatusOK, s.as(t, "u1", "GET", "/rotations", nil, &rotations))
+	require.Empty(t, rotations)
+}
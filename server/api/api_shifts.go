@@ -0,0 +1,185 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+// shiftResponse is a shift with its users' usernames.
+type shiftResponse struct {
+	*sl.Shift
+	Usernames store.IDMap
+}
+
+// shiftUsersRequest is the body of the join and leave requests. Without
+// users, it applies to the acting user.
+type shiftUsersRequest struct {
+	Users string `json:"users"`
+	Role  string `json:"role"`
+}
+
+// maxListShifts is the most shifts apiListShifts returns at once.
+const maxListShifts = 100
+
+// apiListShifts lists the shifts starting from the "shift" query parameter,
+// the current shift by default, "number" of them, 3 by default and at most
+// maxListShifts.
+func (h *Handler) apiListShifts(w http.ResponseWriter, r *http.Request) {
+	rotation, ok := h.loadRotation(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	shiftNumber, _ := rotation.ShiftNumberForTime(time.Now())
+	if shiftNumber < 0 {
+		shiftNumber = 0
+	}
+	numShifts := 3
+	var err error
+	if query.Get("shift") != "" {
+		shiftNumber, err = strconv.Atoi(query.Get("shift"))
+		if err != nil {
+			h.badRequest(w, errors.WithMessage(err, "invalid shift"))
+			return
+		}
+	}
+	if query.Get("number") != "" {
+		numShifts, err = strconv.Atoi(query.Get("number"))
+		if err != nil {
+			h.badRequest(w, errors.WithMessage(err, "invalid number"))
+			return
+		}
+		if numShifts < 1 {
+			numShifts = 1
+		}
+		if numShifts > maxListShifts {
+			numShifts = maxListShifts
+		}
+	}
+
+	api := sl.FromContext(r.Context())
+	shifts, err := api.ListShifts(rotation, shiftNumber, numShifts)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	resp, err := shiftResponses(api, shifts...)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) apiGetShift(w http.ResponseWriter, r *http.Request) {
+	rotation, shiftNumber, ok := h.loadRotationShiftNumber(w, r)
+	if !ok {
+		return
+	}
+
+	api := sl.FromContext(r.Context())
+	shifts, err := api.ListShifts(rotation, shiftNumber, 1)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	if len(shifts) == 0 {
+		h.notFound(w, errors.Errorf("%s is not found", rotation.ShiftRef(shiftNumber)))
+		return
+	}
+	h.writeShift(w, api, shifts[0])
+}
+
+func (h *Handler) apiJoinShift(w http.ResponseWriter, r *http.Request) {
+	req := shiftUsersRequest{}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	rotation, shiftNumber, ok := h.loadRotationShiftNumber(w, r)
+	if !ok {
+		return
+	}
+
+	api := sl.FromContext(r.Context())
+	shift, _, err := api.JoinShift(req.Users, rotation, shiftNumber, req.Role)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	h.writeShift(w, api, shift)
+}
+
+func (h *Handler) apiLeaveShift(w http.ResponseWriter, r *http.Request) {
+	req := shiftUsersRequest{}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	rotation, shiftNumber, ok := h.loadRotationShiftNumber(w, r)
+	if !ok {
+		return
+	}
+
+	api := sl.FromContext(r.Context())
+	shift, _, err := api.LeaveShift(req.Users, rotation, shiftNumber)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	h.writeShift(w, api, shift)
+}
+
+func (h *Handler) writeShift(w http.ResponseWriter, api sl.SolarLottery, shift *sl.Shift) {
+	resp, err := shiftResponses(api, shift)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, resp[0])
+}
+
+func (h *Handler) loadRotationShiftNumber(w http.ResponseWriter, r *http.Request) (*sl.Rotation, int, bool) {
+	rotation, ok := h.loadRotation(w, r)
+	if !ok {
+		return nil, 0, false
+	}
+	shiftNumber, err := strconv.Atoi(mux.Vars(r)["shiftNumber"])
+	if err != nil {
+		h.badRequest(w, errors.WithMessage(err, "invalid shift number"))
+		return nil, 0, false
+	}
+	return rotation, shiftNumber, true
+}
+
+func shiftResponses(api sl.SolarLottery, shifts ...*sl.Shift) ([]*shiftResponse, error) {
+	resp := []*shiftResponse{}
+	for _, shift := range shifts {
+		users, err := api.LoadStoredUsers(shift.MattermostUserIDs)
+		if err != nil {
+			return nil, err
+		}
+		err = api.ExpandUserMap(users)
+		if err != nil {
+			return nil, err
+		}
+		usernames := store.IDMap{}
+		for id, user := range users {
+			usernames[id] = user.MattermostUsername()
+		}
+		resp = append(resp, &shiftResponse{
+			Shift:     shift,
+			Usernames: usernames,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+func TestAPIShifts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newTestServer(ctrl, "admin", "owner", "u1", "u2")
+
+	created := &store.Rotation{}
+	require.Equal(t, http.StatusCreated, s.as(t, "owner", "POST", "/rotations",
+		map[string]interface{}{"name": "test", "start": "2020-01-06", "period": "1w", "size": 2}, created))
+	path := "/rotations/" + created.RotationID
+	owner := sl.New(s.config, "owner")
+	rotation, err := owner.LoadRotation(created.RotationID)
+	require.NoError(t, err)
+	_, err = owner.JoinRotation("@u1,@u2", rotation, time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	for _, shiftNumber := range []int{1, 150} {
+		_, err = owner.OpenShift(rotation, shiftNumber)
+		require.NoError(t, err)
+	}
+
+	list := func(query string) []*shiftResponse {
+		shifts := []*shiftResponse{}
+		require.Equal(t, http.StatusOK, s.as(t, "u1", "GET", path+"/shifts?"+query, nil, &shifts))
+		return shifts
+	}
+	shifts := list("shift=0&number=5")
+	require.Len(t, shifts, 1)
+	require.Equal(t, 1, shifts[0].ShiftNumber)
+	require.Equal(t, store.ShiftStatusOpen, shifts[0].Status)
+	require.Len(t, list("shift=1&number=-3"), 1)
+	require.Len(t, list("shift=0&number=1000"), 1)
+	require.Len(t, list("shift=100&number=1000"), 1)
+	require.Equal(t, http.StatusBadRequest, s.as(t, "u1", "GET", path+"/shifts?number=many", nil, nil))
+
+	shift := &shiftResponse{}
+	require.Equal(t, http.StatusOK, s.as(t, "u1", "GET", path+"/shifts/1", nil, shift))
+	require.Equal(t, "2020-01-13", shift.Start)
+	require.Empty(t, shift.MattermostUserIDs)
+	require.Equal(t, http.StatusNotFound, s.as(t, "u1", "GET", path+"/shifts/2", nil, nil))
+
+	// users join themselves, the owners can join others
+	shift = &shiftResponse{}
+	require.Equal(t, http.StatusOK, s.as(t, "u1", "POST", path+"/shifts/1/join", map[string]interface{}{}, shift))
+	require.Equal(t, store.IDMap{"u1": "u1"}, shift.Usernames)
+	require.Equal(t, http.StatusForbidden, s.as(t, "u1", "POST", path+"/shifts/1/join", map[string]interface{}{"users": "@u2"}, nil))
+	shift = &shiftResponse{}
+	require.Equal(t, http.StatusOK, s.as(t, "owner", "POST", path+"/shifts/1/join", map[string]interface{}{"users": "@u2"}, shift))
+	require.Equal(t, store.IDMap{"u1": "u1", "u2": "u2"}, shift.Usernames)
+	require.Equal(t, http.StatusNotFound, s.as(t, "u1", "POST", path+"/shifts/2/join", map[string]interface{}{}, nil))
+
+	// and so leave
+	require.Equal(t, http.StatusForbidden, s.as(t, "u1", "POST", path+"/shifts/1/leave", map[string]interface{}{"users": "@u2"}, nil))
+	shift = &shiftResponse{}
+	require.Equal(t, http.StatusOK, s.as(t, "u2", "POST", path+"/shifts/1/leave", map[string]interface{}{}, shift))
+	require.Equal(t, store.IDMap{"u1": "u1"}, shift.Usernames)
+	require.Equal(t, http.StatusNotFound, s.as(t, "u2", "POST", path+"/shifts/2/leave", map[string]interface{}{}, nil))
+	require.Equal(t, http.StatusBadRequest, s.as(t, "u2", "POST", path+"/shifts/1/leave", "not an object", nil))
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+// userResponse is the stored user with their username, without the rest of
+// their Mattermost profile.
+type userResponse struct {
+	*store.User
+	Username string
+}
+
+// usersRequest is the body of the requests that update users. Without users,
+// they apply to the acting user.
+type usersRequest struct {
+	Users string `json:"users"`
+	Skill string `json:"skill"`
+	Level string `json:"level"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+func (h *Handler) apiListSkills(w http.ResponseWriter, r *http.Request) {
+	api := sl.FromContext(r.Context())
+	skills, err := api.ListSkills()
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	names := []string{}
+	for skill := range skills {
+		names = append(names, skill)
+	}
+	sort.Strings(names)
+	h.writeJSON(w, http.StatusOK, names)
+}
+
+// apiGetUsers returns the users in the "users" query parameter, the acting
+// user by default.
+func (h *Handler) apiGetUsers(w http.ResponseWriter, r *http.Request) {
+	api := sl.FromContext(r.Context())
+	h.writeUsers(w, api, r.URL.Query().Get("users"), nil)
+}
+
+func (h *Handler) apiQualifyUsers(w http.ResponseWriter, r *http.Request) {
+	req := usersRequest{}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Skill == "" {
+		h.badRequest(w, errors.New("skill is required"))
+		return
+	}
+	level := sl.Level(0)
+	err := level.Set(req.Level)
+	if err != nil {
+		h.badRequest(w, err)
+		return
+	}
+
+	api := sl.FromContext(r.Context())
+	err = api.Qualify(req.Users, req.Skill, level)
+	h.writeUsers(w, api, req.Users, err)
+}
+
+func (h *Handler) apiDisqualifyUsers(w http.ResponseWriter, r *http.Request) {
+	req := usersRequest{}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Skill == "" {
+		h.badRequest(w, errors.New("skill is required"))
+		return
+	}
+
+	api := sl.FromContext(r.Context())
+	err := api.Disqualify(req.Users, req.Skill)
+	h.writeUsers(w, api, req.Users, err)
+}
+
+// apiAddUnavailable marks the users unavailable from the start date through
+// the end date, inclusive.
+func (h *Handler) apiAddUnavailable(w http.ResponseWriter, r *http.Request) {
+	req := usersRequest{}
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	startTime, endTime, err := sl.ParseDatePair(req.Start, req.End)
+	if err != nil {
+		h.badRequest(w, err)
+		return
+	}
+	endTime = endTime.Add(24 * time.Hour) // start of next day
+
+	api := sl.FromContext(r.Context())
+	err = api.AddEvent(req.Users, sl.NewPersonalEvent(startTime, endTime))
+	h.writeUsers(w, api, req.Users, err)
+}
+
+// writeUsers responds with the updated users, or the error.
+func (h *Handler) writeUsers(w http.ResponseWriter, api sl.SolarLottery, mattermostUsernames string, err error) {
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	users, err := api.LoadMattermostUsers(mattermostUsernames)
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	resp := []*userResponse{}
+	for _, user := range users {
+		resp = append(resp, &userResponse{
+			User:     user.User,
+			Username: user.MattermostUsername(),
+		})
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].Username < resp[j].Username })
+	h.writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+func TestAPIQualifyUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newTestServer(ctrl, "admin", "u1", "u2")
+
+	users := []*userResponse{}
+	require.Equal(t, http.StatusOK, s.as(t, "admin", "POST", "/users/qualify",
+		map[string]interface{}{"users": "@u1", "skill": "webapp", "level": "2"}, &users))
+	require.Len(t, users, 1)
+	require.Equal(t, "u1", users[0].Username)
+	require.Equal(t, store.IntMap{"webapp": 2}, users[0].SkillLevels)
+
+	// only the admins and the rotation owners can qualify users, themselves
+	// included
+	require.Equal(t, http.StatusForbidden, s.as(t, "u1", "POST", "/users/qualify",
+		map[string]interface{}{"skill": "webapp", "level": "4"}, nil))
+	require.Equal(t, http.StatusForbidden, s.as(t, "u1", "POST", "/users/qualify",
+		map[string]interface{}{"users": "@u2", "skill": "webapp", "level": "2"}, nil))
+	users = []*userResponse{}
+	require.Equal(t, http.StatusOK, s.as(t, "admin", "POST", "/users/qualify",
+		map[string]interface{}{"users": "@u1,@u2", "skill": "server", "level": "expert"}, &users))
+	require.Len(t, users, 2)
+	require.Equal(t, "u1", users[0].Username)
+	require.Equal(t, store.IntMap{"webapp": 2, "server": 4}, users[0].SkillLevels)
+	require.Equal(t, "u2", users[1].Username)
+	require.Equal(t, store.IntMap{"server": 4}, users[1].SkillLevels)
+
+	require.Equal(t, http.StatusBadRequest, s.as(t, "u1", "POST", "/users/qualify",
+		map[string]interface{}{"level": "2"}, nil))
+	require.Equal(t, http.StatusBadRequest, s.as(t, "u1", "POST", "/users/qualify",
+		map[string]interface{}{"skill": "webapp", "level": "guru"}, nil))
+
+	users = []*userResponse{}
+	require.Equal(t, http.StatusOK, s.as(t, "u1", "POST", "/users/disqualify",
+		map[string]interface{}{"skill": "webapp"}, &users))
+	require.Equal(t, store.IntMap{"server": 4}, users[0].SkillLevels)
+
+	skills := []string{}
+	require.Equal(t, http.StatusOK, s.as(t, "u1", "GET", "/skills", nil, &skills))
+	require.Equal(t, []string{"server", "webapp"}, skills)
+}
+
+func TestAPIAddUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newTestServer(ctrl, "admin", "u1", "u2")
+
+	users := []*userResponse{}
+	require.Equal(t, http.StatusOK, s.as(t, "u1", "POST", "/users/unavailable",
+		map[string]interface{}{"start": "2020-01-06", "end": "2020-01-10"}, &users))
+	require.Len(t, users, 1)
+	require.Equal(t, []store.Event{{
+		Type:  store.EventTypePersonal,
+		Start: "2020-01-06",
+		End:   "2020-01-11",
+	}}, users[0].Events)
+
+	require.Equal(t, http.StatusForbidden, s.as(t, "u1", "POST", "/users/unavailable",
+		map[string]interface{}{"users": "@u2", "start": "2020-01-06", "end": "2020-01-10"}, nil))
+	require.Equal(t, http.StatusBadRequest, s.as(t, "u1", "POST", "/users/unavailable",
+		map[string]interface{}{"start": "2020-01-10", "end": "2020-01-06"}, nil))
+	require.Equal(t, http.StatusBadRequest, s.as(t, "u1", "POST", "/users/unavailable",
+		map[string]interface{}{"start": "tomorrow", "end": "2020-01-06"}, nil))
+
+	users = []*userResponse{}
+	require.Equal(t, http.StatusOK, s.as(t, "u2", "GET", "/users?users=@u1,@u2", nil, &users))
+	require.Len(t, users, 2)
+	require.Len(t, users[0].Events, 1)
+	require.Empty(t, users[1].Events)
+}
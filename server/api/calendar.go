@@ -0,0 +1,40 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/ical"
+)
+
+// The calendar feeds are requested by calendar apps, that have no Mattermost
+// session. They are authenticated by the "token" query parameter instead, see
+// sl.Calendars.
+
+func (h *Handler) calendarRotation(w http.ResponseWriter, r *http.Request) {
+	api := sl.FromContext(r.Context())
+	data, err := api.RotationCalendar(r.URL.Query().Get("token"), mux.Vars(r)["rotationID"], time.Now())
+	h.writeCalendar(w, data, err)
+}
+
+func (h *Handler) calendarUser(w http.ResponseWriter, r *http.Request) {
+	api := sl.FromContext(r.Context())
+	data, err := api.UserCalendar(r.URL.Query().Get("token"), time.Now())
+	h.writeCalendar(w, data, err)
+}
+
+func (h *Handler) writeCalendar(w http.ResponseWriter, data []byte, err error) {
+	if err != nil {
+		h.apiError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(data)
+}
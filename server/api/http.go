@@ -8,8 +8,11 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 )
 
 // Handler is an http.Handler for all plugin HTTP endpoints
@@ -25,12 +28,83 @@ func NewHTTPHandler() *Handler {
 	}
 
 	apiRouter := h.Router.PathPrefix(config.PathAPI).Subrouter()
+	apiRouter.Use(h.requireUser)
 	apiRouter.HandleFunc("/authorized", h.apiGetAuthorized).Methods("GET")
 
+	apiRouter.HandleFunc("/admin/export", h.apiExport).Methods("GET")
+	apiRouter.HandleFunc("/admin/import", h.apiImport).Methods("POST")
+
+	apiRouter.HandleFunc("/rotations", h.apiListRotations).Methods("GET")
+	apiRouter.HandleFunc("/rotations", h.apiAddRotation).Methods("POST")
+	apiRouter.HandleFunc("/rotations/{rotationID}", h.apiGetRotation).Methods("GET")
+	apiRouter.HandleFunc("/rotations/{rotationID}", h.apiUpdateRotation).Methods("PATCH")
+	apiRouter.HandleFunc("/rotations/{rotationID}/archive", h.apiArchiveRotation).Methods("POST")
+
+	apiRouter.HandleFunc("/rotations/{rotationID}/shifts", h.apiListShifts).Methods("GET")
+	apiRouter.HandleFunc("/rotations/{rotationID}/shifts/{shiftNumber:[0-9]+}", h.apiGetShift).Methods("GET")
+	apiRouter.HandleFunc("/rotations/{rotationID}/shifts/{shiftNumber:[0-9]+}/join", h.apiJoinShift).Methods("POST")
+	apiRouter.HandleFunc("/rotations/{rotationID}/shifts/{shiftNumber:[0-9]+}/leave", h.apiLeaveShift).Methods("POST")
+
+	apiRouter.HandleFunc("/skills", h.apiListSkills).Methods("GET")
+	apiRouter.HandleFunc("/users", h.apiGetUsers).Methods("GET")
+	apiRouter.HandleFunc("/users/qualify", h.apiQualifyUsers).Methods("POST")
+	apiRouter.HandleFunc("/users/disqualify", h.apiDisqualifyUsers).Methods("POST")
+	apiRouter.HandleFunc("/users/unavailable", h.apiAddUnavailable).Methods("POST")
+
+	calendarRouter := h.Router.PathPrefix(config.PathCalendar).Subrouter()
+	calendarRouter.HandleFunc(sl.PathCalendarRotation, h.calendarRotation).Methods("GET")
+	calendarRouter.HandleFunc(sl.PathCalendarUser, h.calendarUser).Methods("GET")
+
+	actionRouter := h.Router.PathPrefix(config.PathPostAction).Subrouter()
+	actionRouter.HandleFunc(sl.PathShift, h.actionShift).Methods("POST")
+	actionRouter.HandleFunc(sl.PathSwap, h.actionSwap).Methods("POST")
+
 	h.Router.Handle("{anything:.*}", http.NotFoundHandler())
 	return h
 }
 
+// requireUser rejects the API requests that were not made by a logged in
+// user. Mattermost sets the header for the authenticated requests.
+func (h *Handler) requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Mattermost-User-ID") == "" {
+			h.jsonError(w, http.StatusUnauthorized, "Not authorized.", errors.New("not logged in"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (h *Handler) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		h.badRequest(w, errors.WithMessage(err, "invalid JSON body"))
+		return false
+	}
+	return true
+}
+
+// apiError writes the error response with the status code that matches err.
+func (h *Handler) apiError(w http.ResponseWriter, err error) {
+	cause := errors.Cause(err)
+	switch {
+	case cause == sl.ErrNotAuthorized:
+		h.forbidden(w, err)
+	case cause == store.ErrNotFound:
+		h.notFound(w, err)
+	case cause == sl.ErrAlreadyExists || store.IsConflict(err):
+		h.jsonError(w, http.StatusConflict, "Conflict.", err)
+	default:
+		h.badRequest(w, err)
+	}
+}
+
 func (h *Handler) jsonError(w http.ResponseWriter, statusCode int, summary string, err error) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -53,6 +127,10 @@ func (h *Handler) badRequest(w http.ResponseWriter, err error) {
 	h.jsonError(w, http.StatusBadRequest, "Invalid request.", err)
 }
 
+func (h *Handler) forbidden(w http.ResponseWriter, err error) {
+	h.jsonError(w, http.StatusForbidden, "Forbidden.", err)
+}
+
 func (h *Handler) notFound(w http.ResponseWriter, err error) {
 	h.jsonError(w, http.StatusNotFound, "Not found.", err)
 }
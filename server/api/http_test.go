@@ -0,0 +1,169 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot/mock_bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+func TestRequireUser(t *testing.T) {
+	h := NewHTTPHandler()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/authorized", nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/v1/authorized", nil)
+	r.Header.Set("Mattermost-User-ID", "user-ID")
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAPIError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err            error
+		expectedStatus int
+	}{
+		"not authorized": {errors.WithMessage(sl.ErrNotAuthorized, "someone"), http.StatusForbidden},
+		"not found":      {store.ErrNotFound, http.StatusNotFound},
+		"already exists": {sl.ErrAlreadyExists, http.StatusConflict},
+		"conflict":       {&store.ConflictError{Type: "rotation", ID: "test"}, http.StatusConflict},
+		"other":          {errors.New("invalid"), http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&Handler{}).apiError(w, tc.err)
+			require.Equal(t, tc.expectedStatus, w.Code)
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+// testPluginAPI is a fake sl.PluginAPI with a fixed set of users, named after
+// their IDs.
+type testPluginAPI struct {
+	users  map[string]*model.User
+	admins map[string]bool
+}
+
+var _ sl.PluginAPI = (*testPluginAPI)(nil)
+
+func (api *testPluginAPI) GetMattermostUser(mattermostUserID string) (*model.User, error) {
+	user := api.users[mattermostUserID]
+	if user == nil {
+		return nil, store.ErrNotFound
+	}
+	return user, nil
+}
+
+func (api *testPluginAPI) GetMattermostUserByUsername(mattermostUsername string) (*model.User, error) {
+	return api.GetMattermostUser(mattermostUsername)
+}
+
+func (api *testPluginAPI) IsPluginAdmin(mattermostUserID string) (bool, error) {
+	return api.admins[mattermostUserID], nil
+}
+
+func (api *testPluginAPI) GetFile(fileID string) (*model.FileInfo, []byte, error) {
+	return nil, nil, errors.New("not supported")
+}
+
+func (api *testPluginAPI) UpdateStoredConfig(f func(*config.Config)) {}
+
+func (api *testPluginAPI) Clean() error {
+	return nil
+}
+
+// testServer serves the API requests as the plugin does, on an in-memory
+// store shared by the consecutive requests.
+type testServer struct {
+	handler *Handler
+	config  sl.Config
+}
+
+func newTestServer(ctrl *gomock.Controller, admin string, usernames ...string) *testServer {
+	api := &testPluginAPI{
+		users:  map[string]*model.User{},
+		admins: map[string]bool{admin: true},
+	}
+	for _, name := range append([]string{admin}, usernames...) {
+		api.users[name] = &model.User{Id: name, Username: name}
+	}
+	poster := mock_bot.NewMockPoster(ctrl)
+	poster.EXPECT().DM(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	poster.EXPECT().DMWithAttachments(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	logger := &bot.NilLogger{}
+	kv := kvstore.NewMemStore()
+	s := store.NewStore(kv, logger)
+	return &testServer{
+		handler: NewHTTPHandler(),
+		config: sl.Config{
+			Config: &config.Config{
+				PluginID:      "test-plugin",
+				PluginVersion: "test",
+				BotUserID:     "bot",
+			},
+			Dependencies: &sl.Dependencies{
+				Autofillers: map[string]sl.Autofiller{
+					"":                solarlottery.New(logger), // default
+					solarlottery.Type: solarlottery.New(logger),
+				},
+				AuditStore:    s,
+				CalendarStore: s,
+				LockStore:     kvstore.NewHashedKeyStore(kv, store.LockKeyPrefix),
+				RotationStore: s,
+				ShiftStore:    s,
+				SkillsStore:   s,
+				SwapStore:     s,
+				UserStore:     s,
+				Logger:        logger,
+				Poster:        poster,
+				PluginAPI:     api,
+			},
+		},
+	}
+}
+
+// as makes a request by the user, with body encoded as JSON, and decodes the
+// response into resp, if not nil.
+func (s *testServer) as(t *testing.T, mattermostUserID, method, path string, body, resp interface{}) int {
+	var r *http.Request
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		r = httptest.NewRequest(method, "/api/v1"+path, bytes.NewReader(data))
+	} else {
+		r = httptest.NewRequest(method, "/api/v1"+path, nil)
+	}
+	r.Header.Set("Mattermost-User-ID", mattermostUserID)
+	r = r.WithContext(sl.Context(r.Context(), sl.New(s.config, mattermostUserID)))
+
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, r)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"), w.Body.String())
+	if resp != nil && w.Code < 300 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), resp), w.Body.String())
+	}
+	return w.Code
+}
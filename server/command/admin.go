@@ -0,0 +1,12 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+func (c *Command) admin(parameters []string) (string, error) {
+	subcommands := map[string]func([]string) (string, error){
+		commandExport: c.adminExport,
+		commandImport: c.adminImport,
+	}
+	return c.handleCommand(subcommands, parameters)
+}
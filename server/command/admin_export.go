@@ -0,0 +1,25 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func (c *Command) adminExport(parameters []string) (string, error) {
+	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+	err := fs.Parse(parameters)
+	if err != nil {
+		return c.flagUsage(fs), err
+	}
+
+	backup, err := c.SL.DMBackup(time.Now())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Exported %s, sent you the backup file in a direct message.", backup.Markdown()), nil
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+)
+
+func (c *Command) adminImport(parameters []string) (string, error) {
+	var fileID, mode string
+	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+	fs.StringVar(&fileID, flagFile, "", "ID or link of an uploaded backup file")
+	fs.StringVar(&mode, flagMode, sl.ImportMerge, fmt.Sprintf("%s keeps the data that is not in the backup, %s deletes it", sl.ImportMerge, sl.ImportReplace))
+	err := fs.Parse(parameters)
+	if err != nil {
+		return c.flagUsage(fs), err
+	}
+	if fileID == "" {
+		return c.flagUsage(fs), errors.Errorf("requires `%s` to be specified", flagFile)
+	}
+
+	data, err := c.SL.LoadUploadedFile(parseFileID(fileID))
+	if err != nil {
+		return "", err
+	}
+	backup := &sl.Backup{}
+	err = json.Unmarshal(data, backup)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to parse backup")
+	}
+	err = c.SL.ImportBackup(backup, mode)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Imported (%s) %s.", mode, backup.Markdown()), nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+	"time"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+)
+
+func (c *Command) audit(parameters []string) (string, error) {
+	var rotationID, rotationName, username, since string
+	var limit int
+	fs := newRotationFlagSet(&rotationID, &rotationName)
+	fs.StringVar(&username, flagUser, "", "only the changes by, or for the user. Without a rotation, the user's own log, yours by default.")
+	fs.StringVar(&since, flagSince, "", fmt.Sprintf("only the changes since the date, e.g. %s.", sl.DateFormat))
+	fs.IntVarP(&limit, flagNumber, flagPNumber, 20, "maximum number of changes to list.")
+	err := fs.Parse(parameters)
+	if err != nil {
+		return c.flagUsage(fs), err
+	}
+
+	var sinceTime time.Time
+	if since != "" {
+		sinceTime, err = time.Parse(sl.DateFormat, since)
+		if err != nil {
+			return c.flagUsage(fs), err
+		}
+	}
+
+	var rotation *sl.Rotation
+	if rotationID != "" || rotationName != "" {
+		rotationID, err = c.parseRotationFlags(rotationID, rotationName)
+		if err != nil {
+			return "", err
+		}
+		rotation, err = c.SL.LoadRotation(rotationID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	entries, err := c.SL.ListAudit(rotation, username, sinceTime, limit)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "No changes found.", nil
+	}
+	out := fmt.Sprintf("Most recent %v changes, newest first:\n", len(entries))
+	for _, entry := range entries {
+		out += entry.MarkdownBullets()
+	}
+	return out, nil
+}
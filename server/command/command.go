@@ -5,6 +5,7 @@ package command
 
 import (
 	"fmt"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -16,36 +17,52 @@ import (
 
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils"
 )
 
 const (
 	commandAdd         = "add"
+	commandAdmin       = "admin"
 	commandArchive     = "archive"
+	commandAudit       = "audit"
 	commandAutopilot   = "autopilot"
+	commandCalendar    = "calendar"
+	commandConfirm     = "confirm"
 	commandDebugDelete = "debug-delete"
 	commandDelete      = "delete"
 	commandDisqualify  = "disqualify"
+	commandExport      = "export"
 	commandFill        = "fill"
 	commandFinish      = "finish"
 	commandForecast    = "forecast"
 	commandGuess       = "guess"
+	commandImport      = "import"
+	commandImportICS   = "import-ics"
 	commandInfo        = "info"
 	commandJoin        = "join"
 	commandLeave       = "leave"
 	commandList        = "list"
 	commandLog         = "log"
+	commandMove        = "move"
 	commandNeed        = "need"
 	commandOpen        = "open"
+	commandOwner       = "owner"
 	commandQualify     = "qualify"
+	commandQueue       = "queue"
+	commandReindex     = "reindex"
+	commandReset       = "reset"
+	commandRole        = "role"
 	commandRotation    = "rotation"
 	commandShift       = "shift"
 	commandShow        = "show"
 	commandSkill       = "skill"
 	commandStart       = "start"
+	commandSwap        = "swap"
 	commandUnavailable = "unavailable"
 	commandUpdate      = "update"
 	commandUser        = "user"
+	commandWindow      = "window"
 )
 
 const (
@@ -60,30 +77,53 @@ const (
 )
 
 const (
-	flagClear      = "clear"
-	flagDebugRun   = "debug-run"
-	flagDeleteNeed = "delete-need"
-	flagEnd        = "end"
-	flagFill       = "fill"
-	flagFillDays   = "fill-before"
-	flagGrace      = "grace"
-	flagJSON       = "json"
-	flagLevel      = "level"
-	flagMax        = "max"
-	flagMin        = "min"
-	flagNotifyDays = "notify"
-	flagNumber     = "number"
-	flagOff        = "off"
-	flagPeriod     = "period"
-	flagRotation   = "rotation"
-	flagRotationID = "rotation-id"
-	flagSampleSize = "sample"
-	flagShift      = "shift"
-	flagSize       = "size"
-	flagSkill      = "skill"
-	flagStart      = "start"
-	flagType       = "type"
-	flagUsers      = "users"
+	flagAckDays      = "ack-before"
+	flagClear        = "clear"
+	flagCount        = "count"
+	flagDebugRun     = "debug-run"
+	flagDelete       = "delete"
+	flagDeleteNeed   = "delete-need"
+	flagDeleteRole   = "delete-role"
+	flagDeleteWindow = "delete-window"
+	flagDryRun       = "dry-run"
+	flagEnd          = "end"
+	flagEscalateDays = "escalate-before"
+	flagFile         = "file"
+	flagFill         = "fill"
+	flagFillDays     = "fill-before"
+	flagGrace        = "grace"
+	flagHandoff      = "handoff"
+	flagJSON         = "json"
+	flagLevel        = "level"
+	flagMax          = "max"
+	flagMin          = "min"
+	flagMode         = "mode"
+	flagNotifyDays   = "notify"
+	flagNumber       = "number"
+	flagOff          = "off"
+	flagPeriod       = "period"
+	flagPosition     = "position"
+	flagRegenerate   = "regenerate"
+	flagRegion       = "region"
+	flagRevoke       = "revoke"
+	flagRole         = "role"
+	flagRotation     = "rotation"
+	flagRotationID   = "rotation-id"
+	flagSampleSize   = "sample"
+	flagSeed         = "seed"
+	flagSince        = "since"
+	flagShift        = "shift"
+	flagSize         = "size"
+	flagSkill        = "skill"
+	flagStart        = "start"
+	flagTheirShift   = "their-shift"
+	flagTimeZone     = "timezone"
+	flagType         = "type"
+	flagUser         = "user"
+	flagUsers        = "users"
+	flagWeighting    = "weighting"
+	flagWindow       = "window"
+	flagWith         = "with"
 )
 
 // Command handles commands
@@ -108,7 +148,7 @@ func Register(registerFunc RegisterFunc) {
 		Description:      "team rotation scheduler",
 		AutoComplete:     true,
 		AutoCompleteDesc: "Schedule team rotations",
-		AutoCompleteHint: fmt.Sprintf("Usage: `/%s info|rotation|shift|skill|user`.",
+		AutoCompleteHint: fmt.Sprintf("Usage: `/%s admin|audit|info|rotation|shift|skill|user`.",
 			config.CommandTrigger),
 	})
 }
@@ -116,6 +156,8 @@ func Register(registerFunc RegisterFunc) {
 // Handle should be called by the plugin when a command invocation is received from the Mattermost server.
 func (c *Command) Handle() (out string, err error) {
 	subcommands := map[string]func([]string) (string, error){
+		commandAdmin:    c.admin,
+		commandAudit:    c.audit,
 		commandInfo:     c.info,
 		commandRotation: c.rotation,
 		commandShift:    c.shift,
@@ -130,6 +172,12 @@ func (c *Command) Handle() (out string, err error) {
 		prefix := utils.CodeBlock(c.Args.Command) + "\n"
 		if err != nil {
 			prefix += "Command failed. Error: **" + err.Error() + "**\n"
+			if store.IsConflict(err) {
+				prefix += "Someone else was updating the same data at the same time, please try again.\n"
+			}
+			if errors.Cause(err) == solarlottery.ErrNotAuthorized {
+				prefix += "Ask an owner of the rotation, or a plugin admin, to do it for you.\n"
+			}
 		}
 		out = prefix + out
 	}()
@@ -192,6 +240,22 @@ func (c *Command) subUsage(subcommands map[string]func([]string) (string, error)
 		usage, c.subcommand)
 }
 
+// parseFileID returns the ID of a file given by its ID, or by a link to it,
+// e.g. https://mattermost.example.com/api/v4/files/<file ID>?download=1.
+func parseFileID(fileIDOrLink string) string {
+	u, err := url.Parse(fileIDOrLink)
+	if err != nil {
+		return fileIDOrLink
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, part := range parts {
+		if part == "files" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return fileIDOrLink
+}
+
 func (c *Command) debugClean(parameters []string) (string, error) {
 	return "Cleaned the KV store", c.SL.Clean()
 }
@@ -19,12 +19,16 @@ func (c *Command) info(parameters []string) (string, error) {
 		c.Config.BuildDate)
 
 	resp += `
+- [x] admin
+	- [x] export: DM yourself a JSON backup of all the plugin data.
+	- [x] import --file [--mode merge|replace]
+- [x] audit: list the recent changes to a rotation, or by and for a user.
 - [x] info: display this.
 
 - [x] rotation
 	- [x] add
 	- [x] archive
-	- [ ] autopilot
+	- [x] autopilot
 	- [x] debug-delete
 	- [x] forecast
 	- [x] guess
@@ -32,10 +36,15 @@ func (c *Command) info(parameters []string) (string, error) {
 	- [x] leave
 	- [x] list
 	- [x] need (add/delete)
+	- [x] owner (add/delete)
+	- [x] queue (show/move/swap/reset)
+	- [x] role (add/delete)
 	- [x] show
 	- [x] update
+	- [x] window (add/delete)
 
 - [ ] shift
+	- [x] confirm: acknowledge being in the shift.
 	- [x] open
 	- [x] debug-delete
 	- [x] fill: evaluates shift readiness, autofills.
@@ -43,8 +52,10 @@ func (c *Command) info(parameters []string) (string, error) {
 	- [x] join: add user(s) to shift.
 	- [x] leave: remove user(s) from shift.
 	- [x] list
+	- [x] reindex: rebuild the index of a rotation's shifts, if it is out of date.
 	- [ ] show
 	- [x] start: starts a shift.
+	- [x] swap: ask another user to take over the shift, or trade shifts.
 
 - [x] skill
 	- [x] add
@@ -52,9 +63,11 @@ func (c *Command) info(parameters []string) (string, error) {
 	- [x] delete
 
 - [x] user: manage my profile.
+	- [x] calendar [--rotation] [--regenerate|--revoke]
 	- [x] forecast
 	- [x] show [--users] 
 	- [x] unavailable: --from --to [--clear] [--type=unavailable]
+	- [x] import-ics: --file [--users] [--dry-run]
 	- [x] qualify --skill --level --users
 	- [x] disqualify --skill --users
 `
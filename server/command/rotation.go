@@ -20,8 +20,12 @@ func (c *Command) rotation(parameters []string) (string, error) {
 		commandLeave:       c.leaveRotation,
 		commandList:        c.listRotations,
 		commandNeed:        c.rotationNeed,
+		commandOwner:       c.rotationOwner,
+		commandQueue:       c.rotationQueue,
+		commandRole:        c.rotationRole,
 		commandShow:        c.showRotation,
 		commandUpdate:      c.updateRotation,
+		commandWindow:      c.rotationWindow,
 	}
 
 	return c.handleCommand(subcommands, parameters)
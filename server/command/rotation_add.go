@@ -12,19 +12,26 @@ import (
 	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
 )
 
-func withRotationAddFlags(fs *pflag.FlagSet, start *string, period *sl.Period) {
+func withRotationAddFlags(fs *pflag.FlagSet, start *string, period *sl.Period, rotationType *string, timeZone *string, handoffTime *string) {
 	fs.StringVarP(start, flagStart, flagPStart, "",
 		fmt.Sprintf("rotation start date formatted as %s. It must be provided at creation and **can not be modified** later.", sl.DateFormat))
-	fs.Var(period, flagPeriod, "rotation period 1w, 2w, or 1m")
+	fs.StringVar(timeZone, flagTimeZone, "",
+		"IANA time zone of the rotation, e.g. `America/New_York`. Defaults to UTC, **can not be modified** later.")
+	fs.StringVar(handoffTime, flagHandoff, "",
+		fmt.Sprintf("time of day when shifts hand off, formatted as %s, e.g. `10:00`. Defaults to midnight, **can not be modified** later.", sl.HandoffTimeFormat))
+	fs.Var(period, flagPeriod, "rotation period: a number of days, business days, weeks or months, e.g. 1d, 3d, 1bd, 1w, 2w, or 1m")
+	fs.StringVar(rotationType, flagType, "",
+		"rotation type: `solar-lottery` (default) picks users randomly, weighted by how long ago they served; `queue` takes users in order; `exact` searches for an assignment that satisfies all needs, preferring users who served longest ago. It **can not be modified** later.")
 }
 
 func (c *Command) addRotation(parameters []string) (string, error) {
-	var rotationName, start string
+	var rotationName, start, rotationType, timeZone, handoffTime string
 	var period sl.Period
 	var size, grace int
+	var weighting sl.Weighting
 	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
-	withRotationAddFlags(fs, &start, &period)
-	withRotationUpdateFlags(fs, &size, &grace)
+	withRotationAddFlags(fs, &start, &period, &rotationType, &timeZone, &handoffTime)
+	withRotationUpdateFlags(fs, &size, &grace, &weighting)
 	fs.StringVarP(&rotationName, flagRotation, flagPRotation, "", "specify rotation name")
 	err := fs.Parse(parameters)
 	if err != nil {
@@ -40,8 +47,12 @@ func (c *Command) addRotation(parameters []string) (string, error) {
 	}
 	rotation.Period = period.String()
 	rotation.Start = start
+	rotation.TimeZone = timeZone
+	rotation.HandoffTime = handoffTime
+	rotation.Type = rotationType
 	rotation.Size = size
 	rotation.Grace = grace
+	rotation.Weighting = weighting.String()
 
 	err = c.SL.AddRotation(rotation)
 	if err != nil {
@@ -6,15 +6,18 @@ package command
 import (
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
 
 	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 )
 
-func withRotationAutopilotFlags(fs *pflag.FlagSet, off *bool, autostart *bool, autofill *bool, autofillPriorDays *int, notifyPriorDays *int, debugRunTime *string) {
+func withRotationAutopilotFlags(fs *pflag.FlagSet, off *bool, autostart *bool, autofill *bool, autofillPriorDays *int, notifyPriorDays *int, ackPriorDays *int, escalatePriorDays *int, debugRunTime *string) {
 	fs.StringVar(debugRunTime, flagDebugRun, "", "run autopilot mocking the specified time")
 	fs.IntVar(notifyPriorDays, flagNotifyDays, 7, "notify shift users this many days prior to transition date")
+	fs.IntVar(ackPriorDays, flagAckDays, 0, "remind shift users daily to acknowledge the shift, starting this many days prior to start date. 0 (default) to not track acknowledgements")
+	fs.IntVar(escalatePriorDays, flagEscalateDays, 1, "notify the plugin admins about unacknowledged shift users this many days prior to start date, at least 1 and at most "+flagAckDays)
 	fs.IntVar(autofillPriorDays, flagFillDays, 30, "autofill shifts this many days prior to start date")
 	fs.BoolVar(autostart, flagStart, true, "start and finish shifts automatically")
 	fs.BoolVar(autofill, flagFill, true, "start and finish shifts automatically")
@@ -24,14 +27,20 @@ func withRotationAutopilotFlags(fs *pflag.FlagSet, off *bool, autostart *bool, a
 func (c *Command) autopilotRotation(parameters []string) (string, error) {
 	var rotationID, rotationName string
 	var autostart, autofill, off bool
-	var autofillPriorDays, notifyPriorDays int
+	var autofillPriorDays, notifyPriorDays, ackPriorDays, escalatePriorDays int
 	var debugRunTime string
 	fs := newRotationFlagSet(&rotationID, &rotationName)
-	withRotationAutopilotFlags(fs, &off, &autostart, &autofill, &autofillPriorDays, &notifyPriorDays, &debugRunTime)
+	withRotationAutopilotFlags(fs, &off, &autostart, &autofill, &autofillPriorDays, &notifyPriorDays, &ackPriorDays, &escalatePriorDays, &debugRunTime)
 	err := fs.Parse(parameters)
 	if err != nil {
 		return c.flagUsage(fs), err
 	}
+	if ackPriorDays < 0 {
+		return c.flagUsage(fs), errors.Errorf("`%s` must not be negative", flagAckDays)
+	}
+	if ackPriorDays != 0 && (escalatePriorDays < 1 || escalatePriorDays > ackPriorDays) {
+		return c.flagUsage(fs), errors.Errorf("`%s` must be between 1 and `%s`, %v", flagEscalateDays, flagAckDays, ackPriorDays)
+	}
 
 	rotationID, err = c.parseRotationFlags(rotationID, rotationName)
 	if err != nil {
@@ -44,7 +53,7 @@ func (c *Command) autopilotRotation(parameters []string) (string, error) {
 
 	if debugRunTime != "" {
 		var now time.Time
-		now, err = time.Parse(sl.DateFormat, debugRunTime)
+		now, err = time.ParseInLocation(sl.DateFormat, debugRunTime, rotation.StartTime.Location())
 		if err != nil {
 			return c.flagUsage(fs), err
 		}
@@ -72,6 +81,10 @@ func (c *Command) autopilotRotation(parameters []string) (string, error) {
 				Notify:      notifyPriorDays != 0,
 				NotifyPrior: time.Duration(notifyPriorDays) * sl.DayDuration,
 			}
+			if ackPriorDays != 0 {
+				rotation.Autopilot.AckPrior = time.Duration(ackPriorDays) * sl.DayDuration
+				rotation.Autopilot.EscalatePrior = time.Duration(escalatePriorDays) * sl.DayDuration
+			}
 			return nil
 		}
 	}
@@ -10,7 +10,9 @@ import (
 func (c *Command) forecastRotation(parameters []string) (string, error) {
 	var rotationID, rotationName string
 	start, numShifts, sampleSize := 0, 3, 10
+	var seed int64
 	fs := newRotationFlagSet(&rotationID, &rotationName)
+	withSeedFlag(fs, &seed)
 	fs.IntVarP(&numShifts, flagNumber, flagPNumber, numShifts, "number of shifts to forecast")
 	fs.IntVarP(&start, flagStart, flagPStart, start, "number of shifts to forecast")
 	fs.IntVar(&sampleSize, flagSampleSize, sampleSize, "number of guesses to run")
@@ -28,7 +30,7 @@ func (c *Command) forecastRotation(parameters []string) (string, error) {
 		return "", err
 	}
 
-	forecast, err := c.SL.ForecastRotation(rotation, start, numShifts, sampleSize)
+	forecast, err := c.SL.ForecastRotation(rotation, start, numShifts, sampleSize, seedFromFlag(fs, seed))
 	if err != nil {
 		return "", err
 	}
@@ -5,12 +5,30 @@ package command
 
 import (
 	"fmt"
+
+	"github.com/spf13/pflag"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
 )
 
+func withSeedFlag(fs *pflag.FlagSet, seed *int64) {
+	fs.Int64Var(seed, flagSeed, 0, "random seed, use to replay a previous guess or autopilot fill. Random if not set.")
+}
+
+// seedFromFlag returns the seed provided with --seed, or a new one.
+func seedFromFlag(fs *pflag.FlagSet, seed int64) int64 {
+	if !fs.Changed(flagSeed) {
+		return sl.NewSeed()
+	}
+	return seed
+}
+
 func (c *Command) guessRotation(parameters []string) (string, error) {
 	var rotationID, rotationName string
 	start, numShifts := 0, 3
+	var seed int64
 	fs := newRotationFlagSet(&rotationID, &rotationName)
+	withSeedFlag(fs, &seed)
 	fs.IntVarP(&numShifts, flagNumber, flagPNumber, numShifts, "number of shifts to forecast")
 	fs.IntVarP(&start, flagStart, flagPStart, start, "number of shifts to forecast")
 	err := fs.Parse(parameters)
@@ -27,12 +45,13 @@ func (c *Command) guessRotation(parameters []string) (string, error) {
 		return "", err
 	}
 
-	shifts, err := c.SL.Guess(rotation, start, numShifts)
+	seed = seedFromFlag(fs, seed)
+	shifts, err := c.SL.Guess(rotation, start, numShifts, seed)
 	if err != nil {
 		return "", err
 	}
 
-	out := fmt.Sprintf("Rotation %s %v shifts, starting %v:\n", rotation.Markdown(), numShifts, start)
+	out := fmt.Sprintf("Rotation %s %v shifts, starting %v, seed %v:\n", rotation.Markdown(), numShifts, start, seed)
 	for _, shift := range shifts {
 		if shift != nil {
 			out += shift.MarkdownBullets(rotation)
@@ -34,7 +34,7 @@ func (c *Command) joinRotation(parameters []string) (string, error) {
 
 	starting := time.Now()
 	if start != "" {
-		starting, err = time.Parse(sl.DateFormat, start)
+		starting, err = time.ParseInLocation(sl.DateFormat, start, rotation.StartTime.Location())
 		if err != nil {
 			return c.flagUsage(fs), err
 		}
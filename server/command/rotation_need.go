@@ -11,21 +11,23 @@ import (
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 )
 
-func withRotationNeedFlags(fs *pflag.FlagSet, skill *string, level *sl.Level, min, max *int, deleteNeed *bool) {
+func withRotationNeedFlags(fs *pflag.FlagSet, skill *string, level *sl.Level, min, max *int, deleteNeed *bool, window, role *string) {
 	fs.StringVarP(skill, flagSkill, flagPSkill, "", "the needed skill.")
 	fs.VarP(level, flagLevel, flagPLevel, "the needed skill level.")
 	fs.IntVar(min, flagMin, 0, "minimum number of users with at least this skill level, must be set, -1 not to enforce.")
 	fs.IntVar(max, flagMax, -1, "maximum number of users with at least this skill level. -1 for unlimited.")
 	fs.BoolVar(deleteNeed, flagDeleteNeed, false, "remove the need from rotation.")
+	fs.StringVar(window, flagWindow, "", "apply the need to a window of the rotation, instead of all of its shifts.")
+	fs.StringVar(role, flagRole, "", "apply the need to a role of the rotation, instead of the whole shift.")
 }
 
 func (c *Command) rotationNeed(parameters []string) (string, error) {
-	var rotationID, rotationName, skill string
+	var rotationID, rotationName, skill, window, role string
 	var level sl.Level
 	var deleteNeed bool
 	var min, max int
 	fs := newRotationFlagSet(&rotationID, &rotationName)
-	withRotationNeedFlags(fs, &skill, &level, &min, &max, &deleteNeed)
+	withRotationNeedFlags(fs, &skill, &level, &min, &max, &deleteNeed, &window, &role)
 	err := fs.Parse(parameters)
 	if err != nil {
 		return c.flagUsage(fs), err
@@ -34,6 +36,10 @@ func (c *Command) rotationNeed(parameters []string) (string, error) {
 		return c.flagUsage(fs),
 			errors.Errorf("requires `%s` and `%s` to be specified", flagSkill, flagLevel)
 	}
+	if window != "" && role != "" {
+		return c.flagUsage(fs),
+			errors.Errorf("only one of `%s` and `%s` can be specified", flagWindow, flagRole)
+	}
 	if !deleteNeed {
 		if min == 0 {
 			return c.flagUsage(fs),
@@ -56,6 +62,12 @@ func (c *Command) rotationNeed(parameters []string) (string, error) {
 
 	// default to delete need
 	updatef := func(rotation *sl.Rotation) error {
+		if window != "" {
+			return rotation.DeleteWindowNeed(window, skill, level)
+		}
+		if role != "" {
+			return rotation.DeleteRoleNeed(role, skill, level)
+		}
 		return rotation.DeleteNeed(skill, level)
 	}
 	if !deleteNeed {
@@ -64,7 +76,14 @@ func (c *Command) rotationNeed(parameters []string) (string, error) {
 				errors.Errorf("requires `%s` to be specified.", flagMin)
 		}
 		updatef = func(rotation *sl.Rotation) error {
-			rotation.ChangeNeed(skill, level, store.NewNeed(skill, int(level), min).WithMax(max))
+			need := store.NewNeed(skill, int(level), min).WithMax(max)
+			if window != "" {
+				return rotation.ChangeWindowNeed(window, skill, level, need)
+			}
+			if role != "" {
+				return rotation.ChangeRoleNeed(role, skill, level, need)
+			}
+			rotation.ChangeNeed(skill, level, need)
 			return nil
 		}
 	}
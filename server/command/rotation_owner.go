@@ -0,0 +1,48 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+func (c *Command) rotationOwner(parameters []string) (string, error) {
+	var rotationID, rotationName, users string
+	var deleteOwners bool
+	fs := newRotationFlagSet(&rotationID, &rotationName)
+	fs.StringVarP(&users, flagUsers, flagPUsers, "", "users to add to, or remove from the rotation owners.")
+	fs.BoolVar(&deleteOwners, flagDelete, false, "remove the users from the rotation owners.")
+	err := fs.Parse(parameters)
+	if err != nil {
+		return c.flagUsage(fs), err
+	}
+	if users == "" {
+		return c.flagUsage(fs), errors.Errorf("requires `%s` to be specified", flagUsers)
+	}
+
+	rotationID, err = c.parseRotationFlags(rotationID, rotationName)
+	if err != nil {
+		return "", err
+	}
+	rotation, err := c.SL.LoadRotation(rotationID)
+	if err != nil {
+		return "", err
+	}
+
+	if deleteOwners {
+		deleted, err := c.SL.DeleteOwners(users, rotation)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s removed from the owners of rotation %s", deleted.Markdown(), rotation.Name), nil
+	}
+
+	added, err := c.SL.AddOwners(users, rotation)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s added to the owners of rotation %s", added.Markdown(), rotation.Name), nil
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+)
+
+func (c *Command) rotationQueue(parameters []string) (string, error) {
+	subcommands := map[string]func([]string) (string, error){
+		commandMove:  c.moveRotationQueue,
+		commandReset: c.resetRotationQueue,
+		commandShow:  c.showRotationQueue,
+		commandSwap:  c.swapRotationQueue,
+	}
+
+	return c.handleCommand(subcommands, parameters)
+}
+
+func (c *Command) doRotationQueue(parameters []string,
+	initF func(fs *pflag.FlagSet),
+	doF func(*pflag.FlagSet, *sl.Rotation) error) (string, error) {
+	var rotationID, rotationName string
+	fs := newRotationFlagSet(&rotationID, &rotationName)
+	if initF != nil {
+		initF(fs)
+	}
+	err := fs.Parse(parameters)
+	if err != nil {
+		return c.flagUsage(fs), err
+	}
+
+	rotationID, err = c.parseRotationFlags(rotationID, rotationName)
+	if err != nil {
+		return "", err
+	}
+	rotation, err := c.SL.LoadRotation(rotationID)
+	if err != nil {
+		return "", err
+	}
+	err = c.SL.ExpandRotation(rotation)
+	if err != nil {
+		return "", err
+	}
+
+	if doF != nil {
+		err = doF(fs, rotation)
+		if err != nil {
+			return c.flagUsage(fs), err
+		}
+	}
+	return "Queue for " + rotation.Markdown() + ":\n" + rotation.MarkdownQueue(), nil
+}
+
+func (c *Command) showRotationQueue(parameters []string) (string, error) {
+	return c.doRotationQueue(parameters, nil, nil)
+}
+
+func (c *Command) moveRotationQueue(parameters []string) (string, error) {
+	var usernames string
+	var position int
+	return c.doRotationQueue(parameters,
+		func(fs *pflag.FlagSet) {
+			fs.StringVarP(&usernames, flagUsers, flagPUsers, "", "user to move.")
+			fs.IntVarP(&position, flagPosition, flagPNumber, 1, "new position in the queue, 1 is the next up.")
+		},
+		func(fs *pflag.FlagSet, rotation *sl.Rotation) error {
+			users, err := c.SL.LoadMattermostUsers(usernames)
+			if err != nil {
+				return err
+			}
+			if len(users) != 1 {
+				return errors.Errorf("must specify exactly one user with `--%s`", flagUsers)
+			}
+			return c.SL.UpdateRotation(rotation, func(rotation *sl.Rotation) error {
+				for id := range users {
+					return rotation.QueueMove(id, position)
+				}
+				return nil
+			})
+		})
+}
+
+func (c *Command) swapRotationQueue(parameters []string) (string, error) {
+	var usernames string
+	return c.doRotationQueue(parameters,
+		func(fs *pflag.FlagSet) {
+			fs.StringVarP(&usernames, flagUsers, flagPUsers, "", "two users to swap.")
+		},
+		func(fs *pflag.FlagSet, rotation *sl.Rotation) error {
+			users, err := c.SL.LoadMattermostUsers(usernames)
+			if err != nil {
+				return err
+			}
+			if len(users) != 2 {
+				return errors.Errorf("must specify exactly two users with `--%s`", flagUsers)
+			}
+			var ids []string
+			for id := range users {
+				ids = append(ids, id)
+			}
+			return c.SL.UpdateRotation(rotation, func(rotation *sl.Rotation) error {
+				return rotation.QueueSwap(ids[0], ids[1])
+			})
+		})
+}
+
+func (c *Command) resetRotationQueue(parameters []string) (string, error) {
+	return c.doRotationQueue(parameters, nil,
+		func(fs *pflag.FlagSet, rotation *sl.Rotation) error {
+			return c.SL.UpdateRotation(rotation, func(rotation *sl.Rotation) error {
+				rotation.QueueReset()
+				return nil
+			})
+		})
+}
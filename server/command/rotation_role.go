@@ -0,0 +1,61 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+func withRotationRoleFlags(fs *pflag.FlagSet, name *string, count *int, deleteRole *bool) {
+	fs.StringVar(name, flagRole, "", "role name, e.g. `primary`. Roles are escalation tiers, in the order they are added.")
+	fs.IntVar(count, flagCount, 1, "number of users in the role.")
+	fs.BoolVar(deleteRole, flagDeleteRole, false, "remove the role from rotation.")
+}
+
+func (c *Command) rotationRole(parameters []string) (string, error) {
+	var rotationID, rotationName, name string
+	var count int
+	var deleteRole bool
+	fs := newRotationFlagSet(&rotationID, &rotationName)
+	withRotationRoleFlags(fs, &name, &count, &deleteRole)
+	err := fs.Parse(parameters)
+	if err != nil {
+		return c.flagUsage(fs), err
+	}
+	if name == "" {
+		return c.flagUsage(fs), errors.Errorf("requires `%s` to be specified", flagRole)
+	}
+
+	rotationID, err = c.parseRotationFlags(rotationID, rotationName)
+	if err != nil {
+		return "", err
+	}
+	rotation, err := c.SL.LoadRotation(rotationID)
+	if err != nil {
+		return "", err
+	}
+
+	updatef := func(rotation *sl.Rotation) error {
+		return rotation.DeleteRole(name)
+	}
+	if !deleteRole {
+		updatef = func(rotation *sl.Rotation) error {
+			return rotation.SetRole(&store.ShiftRole{
+				Name:  name,
+				Count: count,
+			})
+		}
+	}
+
+	err = c.SL.UpdateRotation(rotation, updatef)
+	if err != nil {
+		return "", err
+	}
+
+	return "Updated rotation roles:\n" + rotation.MarkdownBullets(), nil
+}
@@ -3,6 +3,13 @@
 
 package command
 
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill/queue"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils"
+)
+
 func (c *Command) showRotation(parameters []string) (string, error) {
 	var rotationID, rotationName string
 	fs := newRotationFlagSet(&rotationID, &rotationName)
@@ -23,5 +30,10 @@ func (c *Command) showRotation(parameters []string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return rotation.MarkdownBullets(), nil
+	out := rotation.MarkdownBullets()
+	if rotation.Type == queue.Type {
+		queueOrder := strings.TrimSuffix(rotation.MarkdownQueue(), "\n")
+		out += "  - Queue:\n" + utils.Indent(queueOrder, "    ") + "\n"
+	}
+	return out, nil
 }
@@ -4,21 +4,26 @@
 package command
 
 import (
+	"fmt"
+
 	"github.com/spf13/pflag"
 
 	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
 )
 
-func withRotationUpdateFlags(fs *pflag.FlagSet, size *int, grace *int) {
+func withRotationUpdateFlags(fs *pflag.FlagSet, size *int, grace *int, weighting *sl.Weighting) {
 	fs.IntVar(size, flagSize, 0, "target number of people in each shift. 0 (default) means unlimited, based on needs")
-	fs.IntVar(grace, flagGrace, 1, "blocks for serving this many shifts after one served")
+	fs.IntVar(grace, flagGrace, 1, "blocks for serving this many shifts, or periods of a rotation with windows, after one served")
+	fs.Var(weighting, flagWeighting, fmt.Sprintf("how users are weighted for autofill: `%s` (default), `%s`, `%s`, `%s`, or `%s`",
+		sl.WeightingExponential, sl.WeightingLinear, sl.WeightingFlat, sl.WeightingCapped, sl.WeightingFairShare))
 }
 
 func (c *Command) updateRotation(parameters []string) (string, error) {
 	var rotationID, rotationName string
 	var size, grace int
+	var weighting sl.Weighting
 	fs := newRotationFlagSet(&rotationID, &rotationName)
-	withRotationUpdateFlags(fs, &size, &grace)
+	withRotationUpdateFlags(fs, &size, &grace, &weighting)
 	err := fs.Parse(parameters)
 	if err != nil {
 		return c.flagUsage(fs), err
@@ -40,6 +45,9 @@ func (c *Command) updateRotation(parameters []string) (string, error) {
 		if size != 0 {
 			rotation.Size = size
 		}
+		if weighting.String() != "" {
+			rotation.Weighting = weighting.String()
+		}
 		return nil
 	})
 	if err != nil {
@@ -0,0 +1,68 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+func withRotationWindowFlags(fs *pflag.FlagSet, name *string, start *time.Duration, size *int, region *string, deleteWindow *bool) {
+	fs.StringVar(name, flagWindow, "", "window name, e.g. `APAC`.")
+	fs.DurationVar(start, flagStart, 0, "window start, from the start of the period, e.g. `8h`. The first window must start at `0`.")
+	fs.IntVar(size, flagSize, 0, "target number of people in the window's shifts. 0 (default) uses the rotation's size")
+	fs.StringVar(region, flagRegion, "", "skill users must have to be filled into the window, e.g. `apac`.")
+	fs.BoolVar(deleteWindow, flagDeleteWindow, false, "remove the window from rotation.")
+}
+
+func (c *Command) rotationWindow(parameters []string) (string, error) {
+	var rotationID, rotationName, name, region string
+	var start time.Duration
+	var size int
+	var deleteWindow bool
+	fs := newRotationFlagSet(&rotationID, &rotationName)
+	withRotationWindowFlags(fs, &name, &start, &size, &region, &deleteWindow)
+	err := fs.Parse(parameters)
+	if err != nil {
+		return c.flagUsage(fs), err
+	}
+	if name == "" {
+		return c.flagUsage(fs), errors.Errorf("requires `%s` to be specified", flagWindow)
+	}
+
+	rotationID, err = c.parseRotationFlags(rotationID, rotationName)
+	if err != nil {
+		return "", err
+	}
+	rotation, err := c.SL.LoadRotation(rotationID)
+	if err != nil {
+		return "", err
+	}
+
+	updatef := func(rotation *sl.Rotation) error {
+		return rotation.DeleteWindow(name)
+	}
+	if !deleteWindow {
+		updatef = func(rotation *sl.Rotation) error {
+			return rotation.SetWindow(&store.ShiftWindow{
+				Name:   name,
+				Start:  start,
+				Size:   size,
+				Region: region,
+			})
+		}
+	}
+
+	err = c.SL.UpdateRotation(rotation, updatef)
+	if err != nil {
+		return "", err
+	}
+
+	return "Updated rotation windows:\n" + rotation.MarkdownBullets(), nil
+}
@@ -5,6 +5,7 @@ package command
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/pflag"
 
@@ -14,6 +15,7 @@ import (
 
 func (c *Command) shift(parameters []string) (string, error) {
 	subcommands := map[string]func([]string) (string, error){
+		commandConfirm:     c.confirmShift,
 		commandOpen:        c.openShift,
 		commandDebugDelete: c.debugDeleteShift,
 		commandFill:        c.fillShift,
@@ -22,6 +24,8 @@ func (c *Command) shift(parameters []string) (string, error) {
 		commandStart:       c.startShift,
 		commandFinish:      c.finishShift,
 		commandLeave:       c.leaveShift,
+		commandReindex:     c.reindexShifts,
+		commandSwap:        c.swapShift,
 	}
 
 	return c.handleCommand(subcommands, parameters)
@@ -99,3 +103,14 @@ func (c *Command) debugDeleteShift(parameters []string) (string, error) {
 			return fmt.Sprintf("Deleted shift #%v", shiftNumber), nil
 		})
 }
+
+func (c *Command) confirmShift(parameters []string) (string, error) {
+	return c.doShift(parameters, nil,
+		func(fs *pflag.FlagSet, rotation *sl.Rotation, shiftNumber int) (string, error) {
+			_, err := c.SL.ConfirmShift(rotation, shiftNumber, time.Now())
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Acknowledged %s", rotation.ShiftRef(shiftNumber)), nil
+		})
+}
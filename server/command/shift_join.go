@@ -12,13 +12,14 @@ import (
 )
 
 func (c *Command) joinShift(parameters []string) (string, error) {
-	var usernames string
+	var usernames, role string
 	return c.doShift(parameters,
 		func(fs *pflag.FlagSet) {
 			fs.StringVarP(&usernames, flagUsers, flagPUsers, "", "users to join the shift.")
+			fs.StringVar(&role, flagRole, "", "role of the users in the shift, e.g. `primary`.")
 		},
 		func(fs *pflag.FlagSet, rotation *sl.Rotation, shiftNumber int) (string, error) {
-			_, joined, err := c.SL.JoinShift(usernames, rotation, shiftNumber)
+			_, joined, err := c.SL.JoinShift(usernames, rotation, shiftNumber, role)
 			if err != nil {
 				return "", err
 			}
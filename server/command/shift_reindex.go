@@ -0,0 +1,42 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func (c *Command) reindexShifts(parameters []string) (string, error) {
+	var rotationID, rotationName string
+	fs := newRotationFlagSet(&rotationID, &rotationName)
+	err := fs.Parse(parameters)
+	if err != nil {
+		return c.flagUsage(fs), err
+	}
+
+	rotationID, err = c.parseRotationFlags(rotationID, rotationName)
+	if err != nil {
+		return "", err
+	}
+	rotation, err := c.SL.LoadRotation(rotationID)
+	if err != nil {
+		return "", err
+	}
+
+	shiftNumbers, err := c.SL.ReindexShifts(rotation)
+	if err != nil {
+		return "", errors.WithMessagef(err, "failed to reindex the shifts of %s", rotation.Name)
+	}
+	if len(shiftNumbers) == 0 {
+		return fmt.Sprintf("%s has no stored shifts.", rotation.Markdown()), nil
+	}
+	numbers := []string{}
+	for _, n := range shiftNumbers {
+		numbers = append(numbers, fmt.Sprintf("#%v", n))
+	}
+	return fmt.Sprintf("Indexed %v shifts of %s: %s.", len(shiftNumbers), rotation.Markdown(), strings.Join(numbers, ", ")), nil
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+)
+
+func (c *Command) swapShift(parameters []string) (string, error) {
+	var username string
+	var theirShiftNumber int
+	return c.doShift(parameters,
+		func(fs *pflag.FlagSet) {
+			fs.StringVar(&username, flagWith, "", "user to ask to take over the shift.")
+			fs.IntVar(&theirShiftNumber, flagTheirShift, sl.NoShift, "their shift to take over in exchange. Not set (default) asks them to just take over the shift.")
+		},
+		func(fs *pflag.FlagSet, rotation *sl.Rotation, shiftNumber int) (string, error) {
+			if username == "" {
+				return c.flagUsage(fs), errors.Errorf("requires `%s` to be specified", flagWith)
+			}
+			_, err := c.SL.RequestSwap(rotation, shiftNumber, username, theirShiftNumber)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Asked %s to swap %s, waiting for them to accept. It can be withdrawn from the message sent to you.", username, rotation.ShiftRef(shiftNumber)), nil
+		})
+}
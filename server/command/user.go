@@ -5,11 +5,13 @@ package command
 
 func (c *Command) user(parameters []string) (string, error) {
 	subcommands := map[string]func([]string) (string, error){
+		commandCalendar:    c.userCalendar,
 		commandDisqualify:  c.disqualifyUsers,
 		commandQualify:     c.qualifyUsers,
 		commandShow:        c.showUser,
 		commandUnavailable: c.userUnavailable,
 		commandForecast:    c.userForecast,
+		commandImportICS:   c.userImportICS,
 	}
 	return c.handleCommand(subcommands, parameters)
 }
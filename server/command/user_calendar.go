@@ -0,0 +1,57 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+)
+
+func (c *Command) userCalendar(parameters []string) (string, error) {
+	var rotationID, rotationName string
+	var regenerate, revoke bool
+	fs := newRotationFlagSet(&rotationID, &rotationName)
+	fs.BoolVar(&regenerate, flagRegenerate, false, "replace your calendar token, the feed URLs you subscribed to stop working.")
+	fs.BoolVar(&revoke, flagRevoke, false, "delete your calendar token, the feed URLs you subscribed to stop working.")
+	err := fs.Parse(parameters)
+	if err != nil {
+		return c.flagUsage(fs), err
+	}
+	if regenerate && revoke {
+		return c.flagUsage(fs), errors.Errorf("only one of `%s` and `%s` can be specified", flagRegenerate, flagRevoke)
+	}
+
+	if revoke {
+		err = c.SL.RevokeCalendarToken()
+		if err != nil {
+			return "", err
+		}
+		return "Revoked your calendar token.", nil
+	}
+
+	token, err := c.SL.GetCalendarToken(regenerate)
+	if err != nil {
+		return "", err
+	}
+	out := "Subscribe to these URLs from your calendar app, and keep them secret: anyone with them can see your schedule.\n"
+	out += fmt.Sprintf("- Your shifts and unavailability: %s\n", sl.UserCalendarURL(c.Config.PluginURL, token))
+
+	if rotationID != "" || rotationName != "" {
+		rotationID, err = c.parseRotationFlags(rotationID, rotationName)
+		if err != nil {
+			return "", err
+		}
+		rotation, err := c.SL.LoadRotation(rotationID)
+		if err != nil {
+			return "", err
+		}
+		out += fmt.Sprintf("- Rotation %s: %s\n", rotation.Markdown(), sl.RotationCalendarURL(c.Config.PluginURL, rotation.RotationID, token))
+	} else {
+		out += fmt.Sprintf("- Any rotation: %s\n", sl.RotationCalendarURL(c.Config.PluginURL, "<rotation-id>", token))
+	}
+	return out, nil
+}
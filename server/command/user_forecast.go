@@ -12,7 +12,9 @@ import (
 func (c *Command) userForecast(parameters []string) (string, error) {
 	var rotationID, rotationName, username string
 	numShifts, sampleSize := 12, 10
+	var seed int64
 	fs := newRotationFlagSet(&rotationID, &rotationName)
+	withSeedFlag(fs, &seed)
 	fs.IntVarP(&numShifts, flagNumber, flagPNumber, numShifts, "number of shifts to forecast")
 	fs.IntVar(&sampleSize, flagSampleSize, sampleSize, "number of guesses to run")
 	fs.StringVarP(&username, flagUsers, flagPUsers, "", "user to forecast (one)")
@@ -30,7 +32,7 @@ func (c *Command) userForecast(parameters []string) (string, error) {
 		return "", err
 	}
 
-	forecast, err := c.SL.ForecastUser(username, rotation, numShifts, sampleSize, time.Now())
+	forecast, err := c.SL.ForecastUser(username, rotation, numShifts, sampleSize, time.Now(), seedFromFlag(fs, seed))
 	if err != nil {
 		return "", err
 	}
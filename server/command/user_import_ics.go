@@ -0,0 +1,51 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+)
+
+func (c *Command) userImportICS(parameters []string) (string, error) {
+	var usernames, fileID string
+	var dryRun bool
+	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+	fs.StringVarP(&usernames, flagUsers, flagPUsers, "", "users to import the unavailability of, yourself by default")
+	fs.StringVar(&fileID, flagFile, "", "ID or link of an uploaded .ics file")
+	fs.BoolVar(&dryRun, flagDryRun, false, "only list the events that would be added")
+	err := fs.Parse(parameters)
+	if err != nil {
+		return c.flagUsage(fs), err
+	}
+	if fileID == "" {
+		return c.flagUsage(fs), errors.Errorf("`%s` must be specified", flagFile)
+	}
+
+	data, err := c.SL.LoadUploadedFile(parseFileID(fileID))
+	if err != nil {
+		return "", err
+	}
+
+	users, added, skipped, err := c.SL.ImportUnavailable(usernames, bytes.NewReader(data), time.Now(), dryRun)
+	if err != nil {
+		return "", err
+	}
+	out := "Added:\n" + sl.MarkdownImportedEvents(users, added)
+	if dryRun {
+		out = fmt.Sprintf("Would add, through %s:\n%s",
+			time.Now().Add(sl.ImportHorizon).Format(sl.DateFormat), sl.MarkdownImportedEvents(users, added))
+	}
+	if len(skipped) > 0 {
+		out += fmt.Sprintf("Skipped %v events with unsupported recurrence rules:\n%s",
+			len(skipped), sl.MarkdownSkippedCalendarEvents(skipped))
+	}
+	return out, nil
+}
@@ -6,10 +6,16 @@ import "github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
 // config.
 type StoredConfig struct {
 	bot.BotConfig
+
+	// ActionSigningSecret is used to sign the context of the actions in the
+	// bot's messages, so that the actions posted back can be trusted.
+	ActionSigningSecret string
 }
 
 func (c StoredConfig) ToStorableConfig(configMap map[string]interface{}) map[string]interface{} {
-	return c.BotConfig.ToStorableConfig(nil)
+	configMap = c.BotConfig.ToStorableConfig(configMap)
+	configMap["ActionSigningSecret"] = c.ActionSigningSecret
+	return configMap
 }
 
 // Config represents the the metadata handed to all request runners (command,
@@ -13,6 +13,7 @@ const (
 	CommandTrigger  = "lotto"
 
 	PathAPI        = "/api/v1"
+	PathCalendar   = "/ical"
 	PathPostAction = "/action"
 	PathRespond    = "/respond"
 )
@@ -0,0 +1,171 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package plugin
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+const (
+	// AutopilotLastRunKey stores the time of the last successful autopilot
+	// run, so that the runs missed while the server was down can be caught up.
+	AutopilotLastRunKey = "autopilot_last_run"
+
+	autopilotInterval = time.Hour
+
+	// Autopilot transitions are date-based, so when catching up it is enough
+	// to run once per day missed, up to autopilotMaxCatchUp.
+	autopilotCatchUpStep = sl.DayDuration
+	autopilotMaxCatchUp  = 30 * sl.DayDuration
+)
+
+// Clock is the time source for the autopilot scheduler, replaceable in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// autopilotScheduler periodically invokes run with the current time. If the
+// previous run was more than a day ago, it first runs for each of the missed
+// days, in order.
+type autopilotScheduler struct {
+	clock    Clock
+	interval time.Duration
+	kv       kvstore.KVStore
+	logger   bot.Logger
+	run      func(now time.Time) error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newAutopilotScheduler(clock Clock, kv kvstore.KVStore, logger bot.Logger, run func(time.Time) error) *autopilotScheduler {
+	return &autopilotScheduler{
+		clock:    clock,
+		interval: autopilotInterval,
+		kv:       kv,
+		logger:   logger,
+		run:      run,
+	}
+}
+
+func (s *autopilotScheduler) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		for {
+			err := s.tick()
+			if err != nil {
+				s.logger.Errorf("autopilot: %v", err)
+			}
+
+			select {
+			case <-s.stop:
+				return
+			case <-s.clock.After(s.interval):
+			}
+		}
+	}()
+}
+
+func (s *autopilotScheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+func (s *autopilotScheduler) tick() error {
+	now := s.clock.Now()
+	lastRun, err := s.loadLastRun()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range catchUpTimes(lastRun, now) {
+		err = s.run(t)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to run for %v", t)
+		}
+		err = kvstore.StoreJSON(s.kv, AutopilotLastRunKey, t)
+		if err != nil {
+			return errors.WithMessage(err, "failed to store last run time")
+		}
+	}
+	return nil
+}
+
+func (s *autopilotScheduler) loadLastRun() (time.Time, error) {
+	var lastRun time.Time
+	err := kvstore.LoadJSON(s.kv, AutopilotLastRunKey, &lastRun)
+	switch err {
+	case nil, kvstore.ErrNotFound:
+		return lastRun, nil
+	default:
+		return time.Time{}, errors.WithMessage(err, "failed to load last run time")
+	}
+}
+
+// catchUpTimes returns the times to run autopilot for, ending with now.
+func catchUpTimes(lastRun, now time.Time) []time.Time {
+	if lastRun.IsZero() || !lastRun.Before(now) {
+		return []time.Time{now}
+	}
+	if now.Sub(lastRun) > autopilotMaxCatchUp {
+		lastRun = now.Add(-autopilotMaxCatchUp)
+	}
+
+	var times []time.Time
+	for t := lastRun.Add(autopilotCatchUpStep); t.Before(now); t = t.Add(autopilotCatchUpStep) {
+		times = append(times, t)
+	}
+	return append(times, now)
+}
+
+// runAutopilot runs autopilot on all known rotations that have it turned on,
+// acting as the bot user. A failure in one rotation does not prevent the
+// others from running.
+func (p *Plugin) runAutopilot(now time.Time) error {
+	slconf := p.newSolarLotteryConfig()
+	api := sl.New(slconf, slconf.BotUserID)
+
+	rotationIDs, err := api.LoadKnownRotations()
+	if err != nil {
+		return err
+	}
+	for rotationID := range rotationIDs {
+		rotation, err := api.LoadRotation(rotationID)
+		if err != nil {
+			api.Errorf("autopilot: failed to load rotation %s: %v", rotationID, err)
+			continue
+		}
+		if rotation.IsArchived || !rotation.Autopilot.On {
+			continue
+		}
+
+		err = api.AutopilotRotation(rotation, now)
+		if err == sl.ErrAutopilotRunning {
+			api.Debugf("autopilot: skipped %s, already running on another server", rotation.Markdown())
+			continue
+		}
+		if err != nil {
+			api.Errorf("autopilot: failed to run for %s: %v", rotation.Markdown(), err)
+		}
+	}
+	return nil
+}
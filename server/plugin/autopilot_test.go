@@ -0,0 +1,143 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package plugin
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+type testClock struct {
+	lock  sync.Mutex
+	now   time.Time
+	after chan time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *testClock) After(time.Duration) <-chan time.Time { return c.after }
+
+func (c *testClock) set(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = now
+}
+
+func mustParseTime(t testing.TB, in string) time.Time {
+	tt, err := time.Parse(time.RFC3339, in)
+	require.NoError(t, err)
+	return tt
+}
+
+func TestAutopilotSchedulerCatchUp(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		lastRun  string
+		now      string
+		expected []string
+	}{
+		{
+			name:     "first run",
+			now:      "2020-01-10T12:00:00Z",
+			expected: []string{"2020-01-10T12:00:00Z"},
+		},
+		{
+			name:     "same day",
+			lastRun:  "2020-01-10T11:00:00Z",
+			now:      "2020-01-10T12:00:00Z",
+			expected: []string{"2020-01-10T12:00:00Z"},
+		},
+		{
+			name:    "down for 3 days",
+			lastRun: "2020-01-07T11:00:00Z",
+			now:     "2020-01-10T12:00:00Z",
+			expected: []string{
+				"2020-01-08T11:00:00Z",
+				"2020-01-09T11:00:00Z",
+				"2020-01-10T11:00:00Z",
+				"2020-01-10T12:00:00Z",
+			},
+		},
+		{
+			name:     "clock went back",
+			lastRun:  "2020-01-12T11:00:00Z",
+			now:      "2020-01-10T12:00:00Z",
+			expected: []string{"2020-01-10T12:00:00Z"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kv := kvstore.NewMemStore()
+			if tc.lastRun != "" {
+				err := kvstore.StoreJSON(kv, AutopilotLastRunKey, mustParseTime(t, tc.lastRun))
+				require.NoError(t, err)
+			}
+			clock := &testClock{now: mustParseTime(t, tc.now)}
+			var ran []string
+			s := newAutopilotScheduler(clock, kv, &bot.NilLogger{}, func(now time.Time) error {
+				ran = append(ran, now.Format(time.RFC3339))
+				return nil
+			})
+
+			err := s.tick()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ran)
+
+			var lastRun time.Time
+			err = kvstore.LoadJSON(kv, AutopilotLastRunKey, &lastRun)
+			require.NoError(t, err)
+			require.Equal(t, tc.now, lastRun.Format(time.RFC3339))
+		})
+	}
+}
+
+func TestAutopilotSchedulerCatchUpLimit(t *testing.T) {
+	kv := kvstore.NewMemStore()
+	err := kvstore.StoreJSON(kv, AutopilotLastRunKey, mustParseTime(t, "2019-01-01T00:00:00Z"))
+	require.NoError(t, err)
+	clock := &testClock{now: mustParseTime(t, "2020-01-10T12:00:00Z")}
+	var ran []time.Time
+	s := newAutopilotScheduler(clock, kv, &bot.NilLogger{}, func(now time.Time) error {
+		ran = append(ran, now)
+		return nil
+	})
+
+	err = s.tick()
+	require.NoError(t, err)
+	require.Len(t, ran, int(autopilotMaxCatchUp/autopilotCatchUpStep))
+	require.Equal(t, clock.now.Add(-autopilotMaxCatchUp+autopilotCatchUpStep), ran[0])
+}
+
+func TestAutopilotSchedulerStartStop(t *testing.T) {
+	kv := kvstore.NewMemStore()
+	clock := &testClock{
+		now:   mustParseTime(t, "2020-01-10T12:00:00Z"),
+		after: make(chan time.Time),
+	}
+	ran := make(chan time.Time, 10)
+	s := newAutopilotScheduler(clock, kv, &bot.NilLogger{}, func(now time.Time) error {
+		ran <- now
+		return nil
+	})
+
+	s.Start()
+	require.Equal(t, clock.Now(), <-ran)
+
+	clock.set(clock.Now().Add(2 * autopilotCatchUpStep))
+	clock.after <- clock.Now()
+	require.Equal(t, mustParseTime(t, "2020-01-11T12:00:00Z"), <-ran)
+	require.Equal(t, mustParseTime(t, "2020-01-12T12:00:00Z"), <-ran)
+
+	s.Stop()
+	require.Len(t, ran, 0)
+}
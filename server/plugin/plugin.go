@@ -4,7 +4,8 @@
 package plugin
 
 import (
-	"math/rand"
+	"crypto/rand"
+	"encoding/base64"
 	"net/http"
 	"net/url"
 	"os"
@@ -23,18 +24,25 @@ import (
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/command"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
 	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill/exact"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill/queue"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill/solarlottery"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
 )
 
+// migrationsLockTTL is how long the migrations lock is held before it is
+// considered abandoned.
+const migrationsLockTTL = 10 * time.Minute
+
 type Plugin struct {
 	plugin.MattermostPlugin
 	configLock *sync.RWMutex
 	config     *config.Config
 
 	httpHandler *api.Handler
+	autopilot   *autopilotScheduler
 	// notificationHandler sl.NotificationHandler
 
 	Templates map[string]*template.Template
@@ -71,7 +79,62 @@ func (p *Plugin) OnActivate() error {
 	p.httpHandler = api.NewHTTPHandler()
 	command.Register(p.API.RegisterCommand)
 
-	rand.Seed(time.Now().UnixNano())
+	conf := p.getConfig()
+	if conf.ActionSigningSecret == "" {
+		secret := make([]byte, 32)
+		_, err = rand.Read(secret)
+		if err != nil {
+			return errors.Wrap(err, "failed to generate action signing secret")
+		}
+		p.UpdateStoredConfig(func(c *config.Config) {
+			c.ActionSigningSecret = base64.RawURLEncoding.EncodeToString(secret)
+		})
+	}
+	err = p.migrate(conf)
+	if err != nil {
+		return err
+	}
+
+	p.autopilot = newAutopilotScheduler(realClock{},
+		kvstore.NewPluginStore(p.API),
+		bot.NewBot(p.API, conf.BotUserID).WithConfig(conf.BotConfig),
+		p.runAutopilot)
+	p.autopilot.Start()
+	return nil
+}
+
+// migrate upgrades the data stored by older versions of the plugin. Only one
+// server in the cluster migrates, the others go on without waiting for it.
+func (p *Plugin) migrate(conf *config.Config) error {
+	bot := bot.NewBot(p.API, conf.BotUserID).WithConfig(conf.BotConfig)
+	lockStore := kvstore.NewHashedKeyStore(kvstore.NewPluginStore(p.API), store.LockKeyPrefix)
+	mutex := kvstore.NewMutex(lockStore, "migrations", migrationsLockTTL)
+	locked, err := mutex.TryLock()
+	if err != nil {
+		return errors.WithMessage(err, "failed to lock migrations")
+	}
+	if !locked {
+		bot.Infof("store: Migrations are being applied by another server")
+		return nil
+	}
+	defer func() {
+		unlockErr := mutex.Unlock()
+		if unlockErr != nil {
+			bot.Errorf("failed to unlock migrations: %v", unlockErr)
+		}
+	}()
+
+	_, err = store.NewPluginStore(p.API, bot).Migrate(conf.PluginVersion)
+	if err != nil {
+		return errors.WithMessage(err, "failed to migrate stored data")
+	}
+	return nil
+}
+
+func (p *Plugin) OnDeactivate() error {
+	if p.autopilot != nil {
+		p.autopilot.Stop()
+	}
 	return nil
 }
 
@@ -156,7 +219,7 @@ func (p *Plugin) updateConfig(f func(*config.Config)) config.Config {
 func (p *Plugin) newSolarLotteryConfig() sl.Config {
 	conf := p.getConfig()
 	bot := bot.NewBot(p.API, conf.BotUserID).WithConfig(conf.BotConfig)
-	store := store.NewPluginStore(p.API, bot)
+	pluginStore := store.NewPluginStore(p.API, bot)
 
 	return sl.Config{
 		Config: conf,
@@ -165,11 +228,16 @@ func (p *Plugin) newSolarLotteryConfig() sl.Config {
 				"":                solarlottery.New(bot), // default
 				solarlottery.Type: solarlottery.New(bot),
 				queue.Type:        queue.New(bot),
+				exact.Type:        exact.New(bot),
 			},
-			RotationStore: store,
-			SkillsStore:   store,
-			UserStore:     store,
-			ShiftStore:    store,
+			AuditStore:    pluginStore,
+			CalendarStore: pluginStore,
+			LockStore:     kvstore.NewHashedKeyStore(kvstore.NewPluginStore(p.API), store.LockKeyPrefix),
+			RotationStore: pluginStore,
+			SkillsStore:   pluginStore,
+			SwapStore:     pluginStore,
+			UserStore:     pluginStore,
+			ShiftStore:    pluginStore,
 			Logger:        bot,
 			Poster:        bot,
 			PluginAPI:     p,
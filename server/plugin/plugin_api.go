@@ -56,6 +56,18 @@ func (p *Plugin) GetMattermostUser(mattermostUserID string) (*model.User, error)
 	return mmuser, nil
 }
 
+func (p *Plugin) GetFile(fileID string) (*model.FileInfo, []byte, error) {
+	info, appErr := p.API.GetFileInfo(fileID)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	data, appErr := p.API.GetFile(fileID)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	return info, data, nil
+}
+
 func (p *Plugin) Clean() error {
 	appErr := p.API.KVDeleteAll()
 	if appErr != nil {
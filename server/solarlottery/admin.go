@@ -0,0 +1,51 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+type Admin interface {
+	UpdateLogSettings(level string, verbose bool) error
+}
+
+// Clean wipes all plugin data from the KV store. Only plugin admins may do it.
+func (sl *solarLottery) Clean() error {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withActingUserAdmin,
+	)
+	if err != nil {
+		return err
+	}
+	return sl.PluginAPI.Clean()
+}
+
+// UpdateLogSettings sets the level and verbosity of the log messages that are
+// sent to the plugin admins. Only plugin admins may do it.
+func (sl *solarLottery) UpdateLogSettings(level string, verbose bool) error {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withActingUserAdmin,
+	)
+	if err != nil {
+		return err
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":       "sl.UpdateLogSettings",
+		"ActingUsername": sl.actingUser.MattermostUsername(),
+		"Level":          level,
+		"Verbose":        verbose,
+	})
+
+	sl.PluginAPI.UpdateStoredConfig(func(conf *config.Config) {
+		conf.StoredConfig.BotConfig.AdminLogLevel = level
+		conf.StoredConfig.BotConfig.AdminLogVerbose = verbose
+	})
+
+	logger.Infof("%s updated the log settings.", sl.actingUser.Markdown())
+	return nil
+}
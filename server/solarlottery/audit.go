@@ -0,0 +1,334 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/fields"
+)
+
+// Audit actions, named after the commands that perform them.
+const (
+	AuditAdminImport         = "admin import"
+	AuditCalendarRevoke      = "user calendar revoke"
+	AuditCalendarToken       = "user calendar"
+	AuditRotationAdd         = "rotation add"
+	AuditRotationArchive     = "rotation archive"
+	AuditRotationDebugDelete = "rotation debug-delete"
+	AuditRotationJoin        = "rotation join"
+	AuditRotationLeave       = "rotation leave"
+	AuditRotationOwnerAdd    = "rotation owner add"
+	AuditRotationOwnerDelete = "rotation owner delete"
+	AuditRotationUpdate      = "rotation update"
+	AuditShiftConfirm        = "shift confirm"
+	AuditShiftDebugDelete    = "shift debug-delete"
+	AuditShiftFinish         = "shift finish"
+	AuditShiftJoin           = "shift join"
+	AuditShiftLeave          = "shift leave"
+	AuditShiftOpen           = "shift open"
+	AuditShiftStart          = "shift start"
+	AuditSkillAdd            = "skill add"
+	AuditSkillDelete         = "skill delete"
+	AuditUserDeleteEvents    = "user delete-events"
+	AuditUserDisqualify      = "user disqualify"
+	AuditUserImport          = "user import-ics"
+	AuditUserQualify         = "user qualify"
+	AuditUserUnavailable     = "user unavailable"
+)
+
+type Audit interface {
+	ListAudit(rotation *Rotation, mattermostUsername string, since time.Time, limit int) ([]*AuditEntry, error)
+}
+
+type AuditEntry struct {
+	*store.AuditEntry
+}
+
+// ListAudit returns the most recent audit entries, newest first. With a
+// rotation, it lists the rotation's log, optionally only the entries by or
+// for the user. Otherwise it lists the user's log, the acting user's by
+// default.
+func (sl *solarLottery) ListAudit(rotation *Rotation, mattermostUsername string, since time.Time, limit int) ([]*AuditEntry, error) {
+	var err error
+	var logKey, filterUserID string
+	if rotation != nil {
+		err = sl.Filter(
+			withActingUserExpanded,
+			withRotationManager(rotation),
+		)
+		if err != nil {
+			return nil, err
+		}
+		logKey = store.RotationAuditLogKey(rotation.RotationID)
+		if mattermostUsername != "" {
+			err = sl.Filter(withMattermostUsersExpanded(mattermostUsername))
+			if err != nil {
+				return nil, err
+			}
+			for id := range sl.users {
+				filterUserID = id
+			}
+		}
+	} else {
+		err = sl.Filter(
+			withActingUserExpanded,
+			withMattermostUsersExpanded(mattermostUsername),
+			withSelfOrAdmin,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(sl.users) != 1 {
+			return nil, errors.New("the audit log can only be listed for one user at a time")
+		}
+		for id := range sl.users {
+			logKey = store.UserAuditLogKey(id)
+		}
+	}
+
+	log, err := sl.AuditStore.LoadAuditLog(logKey)
+	if err != nil {
+		return nil, err
+	}
+	entries := []*AuditEntry{}
+	for page := log.Pages - 1; page >= 0; page-- {
+		auditPage, err := sl.AuditStore.LoadAuditPage(logKey, page)
+		if err == store.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := len(auditPage.Entries) - 1; i >= 0; i-- {
+			entry := auditPage.Entries[i]
+			if entry.Time.Before(since) || (limit > 0 && len(entries) >= limit) {
+				return entries, nil
+			}
+			if filterUserID != "" && entry.ActingMattermostUserID != filterUserID && entry.MattermostUsers[filterUserID] == "" {
+				continue
+			}
+			entries = append(entries, &AuditEntry{entry})
+		}
+	}
+	return entries, nil
+}
+
+// audit records a state-changing operation in the logs of the rotation, of
+// the acting user, and of the users it was applied to. Failing to record it is
+// logged, but does not fail the operation that has already been done.
+func (sl *solarLottery) audit(action, rotationID string, shiftNumber int, users UserMap, before, after fields.Fields) {
+	entry := &store.AuditEntry{
+		Time:                   time.Now(),
+		ActingMattermostUserID: sl.actingMattermostUserID,
+		Action:                 action,
+		RotationID:             rotationID,
+		ShiftNumber:            shiftNumber,
+		Changes:                auditChanges(before, after),
+	}
+	if sl.actingUser != nil {
+		entry.ActingUsername = sl.actingUser.MattermostUsername()
+	}
+	logKeys := []string{store.UserAuditLogKey(sl.actingMattermostUserID)}
+	if rotationID != "" {
+		logKeys = append(logKeys, store.RotationAuditLogKey(rotationID))
+	}
+	if len(users) > 0 {
+		entry.MattermostUsers = store.IDMap{}
+		for id, user := range users {
+			entry.MattermostUsers[id] = id
+			if user != nil && user.User != nil {
+				entry.MattermostUsers[id] = user.MattermostUsername()
+			}
+			if id != sl.actingMattermostUserID {
+				logKeys = append(logKeys, store.UserAuditLogKey(id))
+			}
+		}
+	}
+
+	for _, logKey := range logKeys {
+		err := sl.AuditStore.AppendAuditEntry(logKey, entry)
+		if err != nil {
+			sl.Errorf("failed to record %s in audit log %s: %v", action, logKey, err)
+		}
+	}
+}
+
+// auditUsers records an operation applied to each of the users, with the
+// changes to each of them.
+func (sl *solarLottery) auditUsers(action string, users UserMap, before map[string]fields.Fields) {
+	for id, user := range users {
+		sl.audit(action, "", NoShift, UserMap{id: user}, before[id], userFields(user))
+	}
+}
+
+func auditChanges(before, after fields.Fields) []store.AuditChange {
+	changed, added, updated, deleted := fields.Diff(before, after)
+	if !changed {
+		return nil
+	}
+	names := append(append(append([]string{}, added...), updated...), deleted...)
+	sort.Strings(names)
+	changes := []store.AuditChange{}
+	for _, name := range names {
+		change := store.AuditChange{Field: name}
+		if before[name] != nil {
+			change.Before = before[name].Strings()
+		}
+		if after[name] != nil {
+			change.After = after[name].Strings()
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func (entry *AuditEntry) Markdown() string {
+	out := fmt.Sprintf("%s @%s **%s**", entry.Time.Format("2006-01-02 15:04 MST"), entry.ActingUsername, entry.Action)
+	if entry.RotationID != "" {
+		out += fmt.Sprintf(" rotation `%s`", entry.RotationID)
+	}
+	if entry.ShiftNumber != NoShift {
+		out += fmt.Sprintf(" shift #%v", entry.ShiftNumber)
+	}
+	if len(entry.MattermostUsers) > 0 {
+		names := []string{}
+		for _, username := range entry.MattermostUsers {
+			names = append(names, "@"+username)
+		}
+		sort.Strings(names)
+		out += ": " + strings.Join(names, ", ")
+	}
+	return out
+}
+
+func (entry *AuditEntry) MarkdownBullets() string {
+	out := "- " + entry.Markdown() + "\n"
+	for _, change := range entry.Changes {
+		out += fmt.Sprintf("  - %s: `%s` → `%s`\n", change.Field,
+			strings.Join(change.Before, ", "), strings.Join(change.After, ", "))
+	}
+	return out
+}
+
+func rotationFields(rotation *Rotation) fields.Fields {
+	f := fields.Fields{}
+	if rotation == nil || rotation.Rotation == nil {
+		return f
+	}
+	r := rotation.Rotation
+	setString(f, "Name", r.Name)
+	setString(f, "Type", r.Type)
+	setString(f, "Start", r.Start)
+	setString(f, "Period", r.Period)
+	setString(f, "Size", fmt.Sprint(r.Size))
+	setString(f, "Grace", fmt.Sprint(r.Grace))
+	setString(f, "TimeZone", r.TimeZone)
+	setString(f, "HandoffTime", r.HandoffTime)
+	setString(f, "Weighting", r.Weighting)
+	setString(f, "Archived", fmt.Sprint(r.IsArchived))
+	setString(f, "Autopilot", fmt.Sprintf("%+v", r.Autopilot))
+	setStrings(f, "Users", idsStrings(r.MattermostUserIDs))
+	setStrings(f, "Owners", idsStrings(r.Owners))
+	needs := []string{}
+	for _, need := range r.Needs {
+		needs = append(needs, need.String())
+	}
+	setStrings(f, "Needs", needs)
+	windows := []string{}
+	for _, window := range r.Windows {
+		windows = append(windows, fmt.Sprintf("%+v", *window))
+	}
+	setStrings(f, "Windows", windows)
+	roles := []string{}
+	for _, role := range r.Roles {
+		roles = append(roles, fmt.Sprintf("%+v", *role))
+	}
+	setStrings(f, "Roles", roles)
+	return f
+}
+
+func shiftFields(shift *Shift) fields.Fields {
+	f := fields.Fields{}
+	if shift == nil || shift.Shift == nil {
+		return f
+	}
+	setString(f, "Status", shift.Status)
+	setString(f, "Start", shift.Start)
+	setString(f, "End", shift.End)
+	setStrings(f, "Users", idsStrings(shift.MattermostUserIDs))
+	roles := []string{}
+	for id, role := range shift.Roles {
+		roles = append(roles, id+":"+role)
+	}
+	sort.Strings(roles)
+	setStrings(f, "Roles", roles)
+	acknowledged := []string{}
+	for id := range shift.Acknowledged {
+		acknowledged = append(acknowledged, id)
+	}
+	sort.Strings(acknowledged)
+	setStrings(f, "Acknowledged", acknowledged)
+	return f
+}
+
+func userFields(user *User) fields.Fields {
+	f := fields.Fields{}
+	if user == nil || user.User == nil {
+		return f
+	}
+	setString(f, "Status", user.Status)
+	skills := []string{}
+	for skill, level := range user.SkillLevels {
+		skills = append(skills, fmt.Sprintf("%s-%v", skill, level))
+	}
+	sort.Strings(skills)
+	setStrings(f, "Skills", skills)
+	events := []string{}
+	for _, event := range user.Events {
+		events = append(events, fmt.Sprintf("%s %s - %s", event.Type, event.Start, event.End))
+	}
+	setStrings(f, "Events", events)
+	return f
+}
+
+func usersFields(users UserMap) map[string]fields.Fields {
+	m := map[string]fields.Fields{}
+	for id, user := range users {
+		m[id] = userFields(user)
+	}
+	return m
+}
+
+func setString(f fields.Fields, name, value string) {
+	if value != "" {
+		f[name] = fields.NewStringValue(value)
+	}
+}
+
+func setStrings(f fields.Fields, name string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	vv := []fields.Value{}
+	for _, v := range values {
+		vv = append(vv, fields.NewStringValue(v))
+	}
+	f[name] = fields.NewMultiValue(vv...)
+}
+
+func idsStrings(ids store.IDMap) []string {
+	out := []string{}
+	for id := range ids {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store/mock_store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+func TestAuditChanges(t *testing.T) {
+	rotation := &Rotation{Rotation: store.NewRotation("test")}
+	rotation.Size = 1
+	rotation.MattermostUserIDs = store.IDMap{"a": "a"}
+	before := rotationFields(rotation)
+
+	rotation.Size = 2
+	rotation.MattermostUserIDs["b"] = "b"
+	rotation.Owners = store.IDMap{"a": "a"}
+	changes := auditChanges(before, rotationFields(rotation))
+
+	require.Equal(t, []store.AuditChange{
+		{Field: "Owners", After: []string{"a"}},
+		{Field: "Size", Before: []string{"1"}, After: []string{"2"}},
+		{Field: "Users", Before: []string{"a"}, After: []string{"a", "b"}},
+	}, changes)
+	require.Nil(t, auditChanges(before, before))
+}
+
+func TestAudit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logs := map[string][]*store.AuditEntry{}
+	auditStore := mock_store.NewMockAuditStore(ctrl)
+	auditStore.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(logKey string, entry *store.AuditEntry) error {
+			logs[logKey] = append(logs[logKey], entry)
+			return nil
+		})
+	auditStore.EXPECT().LoadAuditLog(gomock.Any()).AnyTimes().DoAndReturn(
+		func(logKey string) (*store.AuditLog, error) {
+			return &store.AuditLog{Pages: 1}, nil
+		})
+	auditStore.EXPECT().LoadAuditPage(gomock.Any(), 0).AnyTimes().DoAndReturn(
+		func(logKey string, _ int) (*store.AuditPage, error) {
+			return &store.AuditPage{Entries: logs[logKey]}, nil
+		})
+
+	owner := &User{
+		User:           store.NewUser("owner"),
+		MattermostUser: &model.User{Id: "owner", Username: "owner-name"},
+	}
+	joined := &User{
+		User:           store.NewUser("joined"),
+		MattermostUser: &model.User{Id: "joined", Username: "joined-name"},
+	}
+	sl := &solarLottery{
+		Logger: &bot.NilLogger{},
+		Config: Config{
+			Config: &config.Config{},
+			Dependencies: &Dependencies{
+				AuditStore: auditStore,
+				Logger:     &bot.NilLogger{},
+			},
+		},
+		actingMattermostUserID: "owner",
+		actingUser:             owner,
+	}
+	rotation := &Rotation{Rotation: store.NewRotation("test")}
+	rotation.RotationID = "test-ID"
+	rotation.Owners = store.IDMap{"owner": "owner-name"}
+
+	sl.audit(AuditRotationUpdate, rotation.RotationID, NoShift, nil, nil, nil)
+	sl.audit(AuditShiftJoin, rotation.RotationID, 3, UserMap{"joined": joined}, nil, nil)
+
+	require.Len(t, logs[store.RotationAuditLogKey("test-ID")], 2)
+	require.Len(t, logs[store.UserAuditLogKey("owner")], 2)
+	require.Len(t, logs[store.UserAuditLogKey("joined")], 1)
+	entry := logs[store.UserAuditLogKey("joined")][0]
+	require.Equal(t, AuditShiftJoin, entry.Action)
+	require.Equal(t, "owner-name", entry.ActingUsername)
+	require.Equal(t, store.IDMap{"joined": "joined-name"}, entry.MattermostUsers)
+	require.Equal(t, 3, entry.ShiftNumber)
+
+	entries, err := sl.ListAudit(rotation, "", time.Time{}, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, AuditShiftJoin, entries[0].Action)
+	require.Equal(t, AuditRotationUpdate, entries[1].Action)
+
+	entries, err = sl.ListAudit(rotation, "", time.Time{}, 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	entries, err = sl.ListAudit(rotation, "", time.Now().Add(time.Hour), 0)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
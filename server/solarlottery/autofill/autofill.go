@@ -5,6 +5,7 @@ package autofill
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 	"github.com/pkg/errors"
@@ -14,6 +15,10 @@ var ErrInsufficientForNeeds = errors.New("failed to satisfy needs, not enough sk
 var ErrSizeExceeded = errors.New("failed to satisfy needs, exceeded rotation size")
 var ErrInsufficientForSize = errors.New("failed to satisfy rotation size requirement")
 
+// ErrSearchTruncated is returned when the search for an assignment was stopped
+// before one was found, so the needs may or may not be satisfiable.
+var ErrSearchTruncated = errors.New("search truncated before an assignment was found")
+
 type Error struct {
 	Err           error
 	UnmetNeeds    store.Needs
@@ -33,8 +38,18 @@ func (e Error) Error() string {
 		}
 		message += fmt.Sprintf("failed filling need %s", e.UnmetNeed.String())
 	}
-	if len(e.UnmetNeeds) > 0 {
-		//TODO add message
+	var unmet []string
+	for _, need := range e.UnmetNeeds {
+		if need == e.UnmetNeed {
+			continue
+		}
+		unmet = append(unmet, fmt.Sprintf("%v more %s", need.Min, need.SkillLevel()))
+	}
+	if len(unmet) > 0 {
+		if message != "" {
+			message += ", "
+		}
+		message += fmt.Sprintf("unmet needs: %s", strings.Join(unmet, ", "))
 	}
 	if e.Err != nil {
 		message = errors.WithMessage(e.Err, message).Error()
@@ -0,0 +1,316 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package exact
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/test"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+func withEvent(user *sl.User, eventType, start, end string) *sl.User {
+	user.Events = append(user.Events, store.Event{
+		Type:       eventType,
+		Start:      start,
+		End:        end,
+		RotationID: test.RotationID,
+	})
+	return user
+}
+
+func testShift(inShift ...string) *sl.Shift {
+	shift := &sl.Shift{
+		Shift:     store.NewShift("2020-01-08", "2020-01-15", nil),
+		StartTime: time.Date(2020, 1, 8, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+	for _, id := range inShift {
+		shift.MattermostUserIDs[id] = store.NotEmpty
+	}
+	return shift
+}
+
+func TestFillShift(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		size                int
+		needs               store.Needs
+		users               sl.UserMap
+		inShift             []string
+		expectAutofillError error
+		expectUnmetNeeds    store.Needs
+		expectMessage       string
+		expectedChosen      sl.UserMap
+	}{
+		{
+			name: "longest since served",
+			size: 2,
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 3),
+				test.UserServer2().WithLastServed(test.RotationID, 1),
+				test.UserServer3().WithLastServed(test.RotationID, 2),
+			),
+			expectedChosen: test.Usermap(test.UserServer2(), test.UserServer3()),
+		},
+		{
+			name: "not started yet",
+			size: 2,
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 11),
+				test.UserServer2().WithLastServed(test.RotationID, 9),
+				test.UserServer3().WithLastServed(test.RotationID, 9),
+			),
+			expectedChosen: test.Usermap(test.UserServer2(), test.UserServer3()),
+		},
+		{
+			name:  "multi-skilled user satisfies several needs",
+			size:  1,
+			needs: store.Needs{test.NeedServer_L1_Min1(), test.NeedMobile_L1_Min1()},
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 1),
+				test.UserMobile1().WithLastServed(test.RotationID, 2),
+				test.UserGuru().WithLastServed(test.RotationID, 9),
+			),
+			expectedChosen: test.Usermap(test.UserGuru()),
+		},
+		{
+			name:  "preferred user left out to satisfy needs",
+			size:  2,
+			needs: store.Needs{test.NeedMobile_L1_Min1(), test.NeedWebapp_L2_Min1()},
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 1),
+				test.UserWebapp1().WithLastServed(test.RotationID, 2),
+				test.UserMobile1().WithLastServed(test.RotationID, 3),
+			),
+			expectedChosen: test.Usermap(test.UserWebapp1(), test.UserMobile1()),
+		},
+		{
+			name:  "max constraint",
+			size:  2,
+			needs: store.Needs{test.NeedServer_L1_Min1().WithMax(1)},
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 1),
+				test.UserServer2().WithLastServed(test.RotationID, 2),
+				test.UserMobile1().WithLastServed(test.RotationID, 3),
+			),
+			expectedChosen: test.Usermap(test.UserServer1(), test.UserMobile1()),
+		},
+		{
+			name: "unavailable skipped",
+			size: 2,
+			users: test.Usermap(
+				withEvent(test.UserServer1(), store.EventTypePersonal, "2020-01-01", "2020-01-10"),
+				withEvent(test.UserServer2(), store.EventTypeShift, "2020-01-10", "2020-01-17"),
+				test.UserServer3().WithLastServed(test.RotationID, 5),
+				test.UserMobile1().WithLastServed(test.RotationID, 6),
+			),
+			expectedChosen: test.Usermap(test.UserServer3(), test.UserMobile1()),
+		},
+		{
+			name:           "already in shift",
+			size:           2,
+			needs:          store.Needs{test.NeedMobile_L1_Min1()},
+			users:          test.Usermap(test.UserServer1(), test.UserServer2(), test.UserMobile1()),
+			inShift:        []string{test.UserIDMobile1},
+			expectedChosen: test.Usermap(test.UserServer1(), test.UserMobile1()),
+		},
+		{
+			name:                "ErrInsufficientForNeeds",
+			size:                3,
+			needs:               store.Needs{test.NeedMobile_L1_Min2(), test.NeedServer_L1_Min1()},
+			users:               test.Usermap(test.UserServer1(), test.UserServer2(), test.UserMobile1()),
+			expectAutofillError: autofill.ErrInsufficientForNeeds,
+			expectUnmetNeeds:    store.Needs{test.NeedMobile_L1_Min1()},
+			expectMessage:       "unmet needs: 1 more mobile-1: failed to satisfy needs, not enough skilled users available",
+		},
+		{
+			name:                "ErrSizeExceeded",
+			size:                1,
+			needs:               store.Needs{test.NeedMobile_L1_Min1(), test.NeedServer_L1_Min1()},
+			users:               test.Usermap(test.UserServer1().WithLastServed(test.RotationID, 1), test.UserMobile1().WithLastServed(test.RotationID, 2)),
+			expectAutofillError: autofill.ErrSizeExceeded,
+			expectUnmetNeeds:    store.Needs{test.NeedMobile_L1_Min1()},
+		},
+		{
+			name:                "ErrInsufficientForNeeds with max",
+			size:                2,
+			needs:               store.Needs{test.NeedMobile_L1_Min1(), store.NewNeed(test.SkillWebapp, 1, 0).WithMax(0)},
+			users:               test.Usermap(test.UserServer1(), test.UserMobile1()),
+			expectAutofillError: autofill.ErrInsufficientForNeeds,
+			expectUnmetNeeds:    store.Needs{test.NeedMobile_L1_Min1()},
+		},
+		{
+			name:                "ErrInsufficientForSize",
+			size:                3,
+			users:               test.Usermap(test.UserServer1(), test.UserServer2()),
+			expectAutofillError: autofill.ErrInsufficientForSize,
+			expectMessage:       "failed filling to capacity, missing 1: failed to satisfy rotation size requirement",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rotation := test.GetTestRotation().WithUsers(tc.users)
+			rotation.Size = tc.size
+			rotation.Needs = tc.needs
+
+			chosen, err := New(&bot.NilLogger{}).FillShift(rotation, 10, testShift(tc.inShift...), nil, &bot.NilLogger{})
+			if tc.expectAutofillError != nil {
+				require.Error(t, err)
+				afErr, _ := err.(*autofill.Error)
+				require.NotNil(t, afErr)
+				require.Equal(t, tc.expectAutofillError, afErr.Err)
+				require.EqualValues(t, tc.expectUnmetNeeds, afErr.UnmetNeeds)
+				if tc.expectMessage != "" {
+					require.Equal(t, tc.expectMessage, afErr.Error())
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedChosen.IDMap(), chosen.IDMap())
+		})
+	}
+}
+
+func TestFillShiftRandom(t *testing.T) {
+	fill := func(seed int64) sl.UserMap {
+		rotation := test.GetTestRotation().WithUsers(test.AllUsers())
+		rotation.Size = 3
+		rotation.Needs = store.Needs{test.NeedMobile_L1_Min1(), test.NeedServer_L1_Min1().WithMax(1)}
+		chosen, err := New(&bot.NilLogger{}).FillShift(rotation, 10, testShift(), rand.New(rand.NewSource(seed)), &bot.NilLogger{})
+		require.NoError(t, err)
+		return chosen
+	}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		chosen := fill(seed)
+		require.Len(t, chosen, 3)
+		require.Len(t, sl.UsersQualifiedForNeed(chosen, test.NeedServer_L1_Min1()), 1)
+		require.NotEmpty(t, sl.UsersQualifiedForNeed(chosen, test.NeedMobile_L1_Min1()))
+		require.Equal(t, chosen.IDMap(), fill(seed).IDMap())
+	}
+}
+
+func TestFillShiftWeighting(t *testing.T) {
+	users := test.Usermap(
+		test.UserServer1().WithLastServed(test.RotationID, 1),
+		test.UserServer2().WithLastServed(test.RotationID, 5),
+	)
+	users[test.UserIDServer1].Events = []store.Event{
+		{Type: store.EventTypeShift, RotationID: test.RotationID, ShiftNumber: 0, Start: "2019-11-27", End: "2019-12-04"},
+		{Type: store.EventTypeShift, RotationID: test.RotationID, ShiftNumber: 1, Start: "2019-12-04", End: "2019-12-11"},
+	}
+
+	for weighting, expected := range map[string]string{
+		sl.WeightingExponential: test.UserIDServer1,
+		sl.WeightingFairShare:   test.UserIDServer2,
+	} {
+		t.Run(weighting, func(t *testing.T) {
+			rotation := test.GetTestRotation().WithUsers(users)
+			rotation.Size = 1
+			rotation.Weighting = weighting
+			chosen, err := New(&bot.NilLogger{}).FillShift(rotation, 10, testShift(), nil, &bot.NilLogger{})
+			require.NoError(t, err)
+			require.Equal(t, store.IDMap{expected: store.NotEmpty}, chosen.IDMap())
+		})
+	}
+}
+
+func TestFillShiftManyUsers(t *testing.T) {
+	// users alternate between the server and the mobile skill, with dual
+	// every 7th one has both
+	users := func(n int, dual bool) sl.UserMap {
+		users := sl.UserMap{}
+		for i := 0; i < n; i++ {
+			id := fmt.Sprintf("user%03d", i)
+			user := test.SkilledUser(id, test.SkillServer, 1)
+			if i%2 == 1 {
+				user = test.SkilledUser(id, test.SkillMobile, 1)
+			}
+			if dual && i%7 == 6 {
+				user = test.SkilledUser(id, test.SkillServer, 1, test.SkillMobile, 1)
+			}
+			users[id] = user.WithLastServed(test.RotationID, i%10)
+		}
+		return users
+	}
+
+	for _, tc := range []struct {
+		name                string
+		numUsers            int
+		dual                bool
+		size                int
+		needs               store.Needs
+		expectAutofillError error
+	}{
+		{
+			name:     "conflicting needs",
+			numUsers: 50,
+			size:     8,
+			needs: store.Needs{
+				store.NewNeed(test.SkillServer, 1, 5),
+				store.NewNeed(test.SkillMobile, 1, 5),
+			},
+			expectAutofillError: autofill.ErrSizeExceeded,
+		},
+		{
+			name:     "conflicting needs, many users",
+			numUsers: 200,
+			size:     8,
+			needs: store.Needs{
+				store.NewNeed(test.SkillServer, 1, 5).WithMax(6),
+				store.NewNeed(test.SkillMobile, 1, 5).WithMax(6),
+			},
+			expectAutofillError: autofill.ErrSizeExceeded,
+		},
+		{
+			// the bound does not account for max, the search is stopped
+			// after maxSearchNodes, without proving the shift infeasible
+			name:     "max leaves the shift short",
+			numUsers: 60,
+			size:     12,
+			needs: store.Needs{
+				store.NewNeed(test.SkillServer, 1, 5).WithMax(5),
+				store.NewNeed(test.SkillMobile, 1, 5).WithMax(5),
+			},
+			expectAutofillError: autofill.ErrSearchTruncated,
+		},
+		{
+			name:     "tight fit",
+			numUsers: 60,
+			dual:     true,
+			size:     8,
+			needs: store.Needs{
+				store.NewNeed(test.SkillServer, 1, 5).WithMax(5),
+				store.NewNeed(test.SkillMobile, 1, 5).WithMax(5),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rotation := test.GetTestRotation().WithUsers(users(tc.numUsers, tc.dual))
+			rotation.Size = tc.size
+			rotation.Needs = tc.needs
+
+			started := time.Now()
+			chosen, err := New(&bot.NilLogger{}).FillShift(rotation, 10, testShift(), rand.New(rand.NewSource(1)), &bot.NilLogger{})
+			require.Less(t, int64(time.Since(started)), int64(time.Second))
+			if tc.expectAutofillError != nil {
+				require.Error(t, err)
+				afErr, _ := err.(*autofill.Error)
+				require.NotNil(t, afErr)
+				require.Equal(t, tc.expectAutofillError, afErr.Err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, chosen, tc.size)
+		})
+	}
+}
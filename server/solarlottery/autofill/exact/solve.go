@@ -0,0 +1,382 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package exact
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+// maxSearchNodes bounds the search, so that filling a shift from a large
+// rotation takes a bounded time. If it is exceeded before a complete assignment
+// is found, the shift is reported as ErrSearchTruncated rather than infeasible,
+// unless the needs are unsatisfiable regardless of the search.
+const maxSearchNodes = 100000
+
+// solver looks for the assignment that satisfies all needs' min and max, and
+// the rotation size. Candidates are ordered by preference, weighted per the
+// rotation's Weighting, and the search is depth-first, trying to include each
+// candidate before trying without, so the first complete assignment found is
+// the one that prefers the most preferred candidates. If there is no complete
+// assignment, the one that comes closest is used to explain what is missing.
+// Branches that can not do better than the best assignment so far are pruned,
+// see minDeficit.
+type solver struct {
+	// Parameters
+	bot.Logger
+	rotationID  string
+	shiftNumber int
+	needs       store.Needs
+	chosen      sl.UserMap
+
+	// candidates are the available users, in the order of preference.
+	// qualified[i][n] is true if candidates[i] qualifies for needs[n], and
+	// remaining[i][n] is the number of candidates from i on that do.
+	candidates []*sl.User
+	weights    []float64
+	qualified  [][]bool
+	remaining  [][]int
+
+	// State
+	slots       int
+	counts      []int
+	picked      []int
+	found       bool
+	best        []int
+	bestCounts  []int
+	bestDeficit int
+	bestShort   int
+	nodes       int
+	truncated   bool
+
+	// scratch space for minDeficit
+	unmet  []bool
+	useful []int
+}
+
+func makeSolver(rotationID string, size int, needs store.Needs, weighting string,
+	pool sl.UserMap, chosen sl.UserMap, shiftNumber int, shiftStart, shiftEnd time.Time, random *rand.Rand, logger bot.Logger) (*solver, error) {
+	if chosen == nil {
+		chosen = sl.UserMap{}
+	}
+	weightF, err := sl.GetWeightFunc(weighting)
+	if err != nil {
+		return nil, err
+	}
+	s := solver{
+		Logger:      logger,
+		rotationID:  rotationID,
+		shiftNumber: shiftNumber,
+		needs:       needs,
+		chosen:      chosen,
+		counts:      make([]int, len(needs)),
+		unmet:       make([]bool, len(needs)),
+		useful:      make([]int, len(needs)+1),
+	}
+
+	for _, user := range chosen {
+		for n, need := range needs {
+			if sl.IsUserQualifiedForNeed(user, need) {
+				s.counts[n]++
+			}
+		}
+	}
+	s.slots = size - len(chosen)
+	if s.slots < 0 {
+		s.slots = 0
+	}
+
+	var ids []string
+	for id := range pool {
+		if chosen[id] == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	ws := weightedUsers{}
+	for _, id := range ids {
+		user := pool[id]
+		available, err := user.IsAvailable(rotationID, shiftStart, shiftEnd)
+		if err != nil {
+			return nil, err
+		}
+		if !available {
+			logger.Debugf("Disqualified %s: unavailable", user.Markdown())
+			continue
+		}
+		weight := weightF(user, rotationID, shiftNumber)
+		key := weight
+		if random != nil {
+			// Weighted random order, the larger log(u)/weight the earlier.
+			key = math.Log(1-random.Float64()) / weight
+		}
+		ws.users = append(ws.users, user)
+		ws.weights = append(ws.weights, weight)
+		ws.keys = append(ws.keys, key)
+	}
+	sort.Stable(&ws)
+	s.candidates = ws.users
+	s.weights = ws.weights
+
+	s.qualified = make([][]bool, len(s.candidates))
+	for i, user := range s.candidates {
+		s.qualified[i] = make([]bool, len(needs))
+		for n, need := range needs {
+			s.qualified[i][n] = sl.IsUserQualifiedForNeed(user, need)
+		}
+	}
+	s.remaining = make([][]int, len(s.candidates)+1)
+	s.remaining[len(s.candidates)] = make([]int, len(needs))
+	for i := len(s.candidates) - 1; i >= 0; i-- {
+		s.remaining[i] = make([]int, len(needs))
+		for n := range needs {
+			s.remaining[i][n] = s.remaining[i+1][n]
+			if s.qualified[i][n] {
+				s.remaining[i][n]++
+			}
+		}
+	}
+
+	return &s, nil
+}
+
+func (s *solver) solve() (sl.UserMap, error) {
+	s.Debugf(s.markdown())
+
+	s.search(0)
+
+	if s.truncated && (s.bestDeficit > 0 || s.bestShort > 0) {
+		// The closest assignment found does not prove anything, only report
+		// what is missing regardless of the search.
+		for n, need := range s.needs {
+			if need.Min > s.counts[n]+s.remaining[0][n] {
+				return nil, s.newError(autofill.ErrInsufficientForNeeds)
+			}
+		}
+		if len(s.candidates) < s.slots {
+			return nil, s.newError(autofill.ErrInsufficientForSize)
+		}
+		return nil, s.newError(autofill.ErrSearchTruncated)
+	}
+	if s.bestDeficit > 0 {
+		if len(s.best) < s.slots {
+			return nil, s.newError(autofill.ErrInsufficientForNeeds)
+		}
+		for n, need := range s.needs {
+			if need.Min > s.counts[n]+s.remaining[0][n] {
+				return nil, s.newError(autofill.ErrInsufficientForNeeds)
+			}
+		}
+		// There are enough qualified users for each need, they just don't fit
+		// in the shift together.
+		return nil, s.newError(autofill.ErrSizeExceeded)
+	}
+	if s.bestShort > 0 {
+		return nil, s.newError(autofill.ErrInsufficientForSize)
+	}
+
+	result := sl.UserMap{}
+	for id, user := range s.chosen {
+		result[id] = user
+	}
+	for _, i := range s.best {
+		user := s.candidates[i]
+		result[user.MattermostUserID] = user
+	}
+	return result, nil
+}
+
+// search returns true once a complete assignment is found, or the search is
+// to be stopped.
+func (s *solver) search(i int) bool {
+	s.nodes++
+	if s.nodes > maxSearchNodes {
+		if !s.truncated {
+			s.truncated = true
+			s.Debugf("search truncated after %v assignments", maxSearchNodes)
+		}
+		return true
+	}
+
+	deficit := s.deficit()
+	short := s.slots - len(s.picked)
+	if !s.found || deficit < s.bestDeficit || (deficit == s.bestDeficit && short < s.bestShort) {
+		s.found = true
+		s.best = append([]int{}, s.picked...)
+		s.bestCounts = append([]int{}, s.counts...)
+		s.bestDeficit = deficit
+		s.bestShort = short
+		if deficit == 0 && short == 0 {
+			return true
+		}
+	}
+	if short == 0 || i >= len(s.candidates) {
+		return false
+	}
+
+	// Prune if even the best pick of the remaining candidates can not do
+	// better than the best so far.
+	boundDeficit := s.minDeficit(i, short)
+	boundShort := short - (len(s.candidates) - i)
+	if boundShort < 0 {
+		boundShort = 0
+	}
+	if boundDeficit > s.bestDeficit || (boundDeficit == s.bestDeficit && boundShort >= s.bestShort) {
+		return false
+	}
+
+	if s.meetsConstraints(i) {
+		s.pick(i, 1)
+		s.picked = append(s.picked, i)
+		done := s.search(i + 1)
+		s.picked = s.picked[:len(s.picked)-1]
+		s.pick(i, -1)
+		if done {
+			return true
+		}
+	}
+	return s.search(i + 1)
+}
+
+func (s *solver) deficit() int {
+	deficit := 0
+	for n, need := range s.needs {
+		if d := need.Min - s.counts[n]; d > 0 {
+			deficit += d
+		}
+	}
+	return deficit
+}
+
+// minDeficit returns a lower bound of the deficit left after picking up to
+// short more candidates from i on. Ignoring the needs' max, a candidate can
+// reduce the deficit by at most the number of unmet needs it qualifies for,
+// so the deficit can not be reduced by more than the short most useful
+// candidates together, nor by more than the qualified candidates left for
+// each need.
+func (s *solver) minDeficit(i, short int) int {
+	deficit := 0
+	perNeed := 0
+	for n, need := range s.needs {
+		d := need.Min - s.counts[n]
+		s.unmet[n] = d > 0
+		if d <= 0 {
+			continue
+		}
+		deficit += d
+		r := s.remaining[i][n]
+		if r > short {
+			r = short
+		}
+		if r > d {
+			r = d
+		}
+		perNeed += r
+	}
+	if deficit == 0 {
+		return 0
+	}
+
+	// useful[k] is the number of candidates that qualify for k unmet needs
+	for k := range s.useful {
+		s.useful[k] = 0
+	}
+	for j := i; j < len(s.candidates); j++ {
+		k := 0
+		for n := range s.needs {
+			if s.unmet[n] && s.qualified[j][n] {
+				k++
+			}
+		}
+		s.useful[k]++
+	}
+	byCandidates := 0
+	left := short
+	for k := len(s.needs); k > 0 && left > 0; k-- {
+		c := s.useful[k]
+		if c > left {
+			c = left
+		}
+		byCandidates += k * c
+		left -= c
+	}
+
+	if byCandidates < perNeed {
+		return deficit - byCandidates
+	}
+	return deficit - perNeed
+}
+
+func (s *solver) meetsConstraints(i int) bool {
+	for n, need := range s.needs {
+		if s.qualified[i][n] && need.Max >= 0 && s.counts[n]+1 > need.Max {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *solver) pick(i int, delta int) {
+	for n := range s.needs {
+		if s.qualified[i][n] {
+			s.counts[n] += delta
+		}
+	}
+}
+
+func (s *solver) markdown() string {
+	out := fmt.Sprintf("filling shift %v, candidates in order of preference:\n", s.shiftNumber)
+	for i, user := range s.candidates {
+		out += fmt.Sprintf("%v. **%.3f**: %s\n", i+1, s.weights[i], user.MarkdownWithSkills())
+	}
+	return out
+}
+
+// newError reports the needs that are unmet by the closest assignment found,
+// with Min set to the number of users still missing for each.
+func (s *solver) newError(err error) *autofill.Error {
+	var unmet store.Needs
+	for n, need := range s.needs {
+		if d := need.Min - s.bestCounts[n]; d > 0 {
+			unmetNeed := *need
+			unmetNeed.Min = d
+			unmet = append(unmet, &unmetNeed)
+		}
+	}
+	return &autofill.Error{
+		Err:           err,
+		UnmetNeeds:    unmet,
+		UnmetCapacity: s.slots - len(s.best),
+		ShiftNumber:   s.shiftNumber,
+	}
+}
+
+type weightedUsers struct {
+	users   []*sl.User
+	weights []float64
+	keys    []float64
+}
+
+func (ws *weightedUsers) Len() int {
+	return len(ws.users)
+}
+
+func (ws *weightedUsers) Less(a, b int) bool {
+	return ws.keys[a] > ws.keys[b]
+}
+
+func (ws *weightedUsers) Swap(a, b int) {
+	ws.users[a], ws.users[b] = ws.users[b], ws.users[a]
+	ws.weights[a], ws.weights[b] = ws.weights[b], ws.weights[a]
+	ws.keys[a], ws.keys[b] = ws.keys[b], ws.keys[a]
+}
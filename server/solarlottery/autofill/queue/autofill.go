@@ -4,13 +4,13 @@
 package queue
 
 import (
-	"github.com/pkg/errors"
+	"math/rand"
 
 	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
 )
 
-const Type = "queue"
+const Type = sl.QueueType
 
 type autofiller struct{}
 
@@ -26,6 +26,22 @@ func New(logger bot.Logger) sl.Autofiller {
 // map intact, but when called for a sequence of shifts, it relies on the caller
 // to carry the users from one call to the next, presumably by using the same
 // rotation object.
-func (*autofiller) FillShift(rotation *sl.Rotation, shiftNumber int, shift *sl.Shift, logger bot.Logger) (sl.UserMap, error) {
-	return nil, errors.New("Queue autofill is not implemented")
+func (*autofiller) FillShift(rotation *sl.Rotation, shiftNumber int, shift *sl.Shift, random *rand.Rand, logger bot.Logger) (sl.UserMap, error) {
+	af, err := makeAutofill(
+		rotation.RotationID,
+		rotation.Size,
+		rotation.Needs.Clone(),
+		rotation.Users.Clone(false),
+		rotation.QueueOrder(),
+		rotation.ShiftUsers(shift),
+		shiftNumber,
+		shift.StartTime,
+		shift.EndTime,
+		logger,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return af.fill()
 }
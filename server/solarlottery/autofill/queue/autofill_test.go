@@ -5,7 +5,204 @@ package queue
 
 import (
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/test"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
 )
 
+func withEvent(user *sl.User, eventType, start, end string) *sl.User {
+	user.Events = append(user.Events, store.Event{
+		Type:       eventType,
+		Start:      start,
+		End:        end,
+		RotationID: test.RotationID,
+	})
+	return user
+}
+
 func TestFillShift(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		size                int
+		needs               store.Needs
+		users               sl.UserMap
+		queue               []string
+		inShift             []string
+		expectAutofillError error
+		expectedChosen      sl.UserMap
+	}{
+		{
+			name: "in queue order",
+			size: 2,
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 3),
+				test.UserServer2().WithLastServed(test.RotationID, 1),
+				test.UserServer3().WithLastServed(test.RotationID, 2),
+			),
+			expectedChosen: test.Usermap(test.UserServer2(), test.UserServer3()),
+		},
+		{
+			name: "explicit queue order",
+			size: 2,
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 3),
+				test.UserServer2().WithLastServed(test.RotationID, 1),
+				test.UserServer3().WithLastServed(test.RotationID, 2),
+			),
+			queue:          []string{test.UserIDServer1, test.UserIDServer3},
+			expectedChosen: test.Usermap(test.UserServer1(), test.UserServer3()),
+		},
+		{
+			name: "not started yet",
+			size: 2,
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 11),
+				test.UserServer2(),
+				test.UserServer3(),
+			),
+			queue:          []string{test.UserIDServer1, test.UserIDServer2, test.UserIDServer3},
+			expectedChosen: test.Usermap(test.UserServer2(), test.UserServer3()),
+		},
+		{
+			name: "ties broken by ID",
+			size: 2,
+			users: test.Usermap(
+				test.UserServer3(),
+				test.UserServer2(),
+				test.UserServer1(),
+			),
+			expectedChosen: test.Usermap(test.UserServer1(), test.UserServer2()),
+		},
+		{
+			name:  "skipped to make room for a need",
+			size:  2,
+			needs: store.Needs{test.NeedMobile_L1_Min1()},
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 1),
+				test.UserServer2().WithLastServed(test.RotationID, 2),
+				test.UserMobile1().WithLastServed(test.RotationID, 3),
+			),
+			expectedChosen: test.Usermap(test.UserServer1(), test.UserMobile1()),
+		},
+		{
+			name:  "need satisfied out of order, skipped user taken",
+			size:  2,
+			needs: store.Needs{test.NeedMobile_L1_Min1()},
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 1),
+				test.UserMobile1().WithLastServed(test.RotationID, 2),
+				test.UserServer2().WithLastServed(test.RotationID, 3),
+			),
+			expectedChosen: test.Usermap(test.UserServer1(), test.UserMobile1()),
+		},
+		{
+			name:  "max constraint",
+			size:  2,
+			needs: store.Needs{test.NeedServer_L1_Min1().WithMax(1)},
+			users: test.Usermap(
+				test.UserServer1().WithLastServed(test.RotationID, 1),
+				test.UserServer2().WithLastServed(test.RotationID, 2),
+				test.UserMobile1().WithLastServed(test.RotationID, 3),
+			),
+			expectedChosen: test.Usermap(test.UserServer1(), test.UserMobile1()),
+		},
+		{
+			name: "unavailable skipped",
+			size: 2,
+			users: test.Usermap(
+				withEvent(test.UserServer1(), store.EventTypePersonal, "2020-01-01", "2020-01-10"),
+				withEvent(test.UserServer2(), store.EventTypeShift, "2020-01-10", "2020-01-17"),
+				test.UserServer3().WithLastServed(test.RotationID, 5),
+				test.UserMobile1().WithLastServed(test.RotationID, 6),
+			),
+			expectedChosen: test.Usermap(test.UserServer3(), test.UserMobile1()),
+		},
+		{
+			name:           "already in shift",
+			size:           2,
+			needs:          store.Needs{test.NeedMobile_L1_Min1()},
+			users:          test.Usermap(test.UserServer1(), test.UserServer2(), test.UserMobile1()),
+			inShift:        []string{test.UserIDMobile1},
+			expectedChosen: test.Usermap(test.UserServer1(), test.UserMobile1()),
+		},
+		{
+			name:                "ErrInsufficientForNeeds",
+			size:                2,
+			needs:               store.Needs{test.NeedMobile_L1_Min1()},
+			users:               test.Usermap(test.UserServer1(), test.UserServer2()),
+			expectAutofillError: autofill.ErrInsufficientForNeeds,
+		},
+		{
+			name:                "ErrSizeExceeded",
+			size:                1,
+			needs:               store.Needs{test.NeedMobile_L1_Min1(), test.NeedServer_L1_Min1()},
+			users:               test.Usermap(test.UserServer1(), test.UserMobile1()),
+			expectAutofillError: autofill.ErrSizeExceeded,
+		},
+		{
+			name:                "ErrInsufficientForSize",
+			size:                3,
+			users:               test.Usermap(test.UserServer1(), test.UserServer2()),
+			expectAutofillError: autofill.ErrInsufficientForSize,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rotation := test.GetTestRotation().WithUsers(tc.users)
+			rotation.Size = tc.size
+			rotation.Needs = tc.needs
+			rotation.Queue = tc.queue
+			shift := &sl.Shift{
+				Shift:     store.NewShift("2020-01-08", "2020-01-15", nil),
+				StartTime: time.Date(2020, 1, 8, 0, 0, 0, 0, time.UTC),
+				EndTime:   time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC),
+			}
+			for _, id := range tc.inShift {
+				shift.MattermostUserIDs[id] = store.NotEmpty
+			}
+
+			chosen, err := New(&bot.NilLogger{}).FillShift(rotation, 10, shift, nil, &bot.NilLogger{})
+			if tc.expectAutofillError != nil {
+				require.Error(t, err)
+				afErr, _ := err.(*autofill.Error)
+				require.NotNil(t, afErr)
+				require.Equal(t, tc.expectAutofillError, afErr.Err)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, tc.expectedChosen.IDMap(), chosen.IDMap())
+		})
+	}
+}
+
+func TestFillShiftSequence(t *testing.T) {
+	rotation := test.GetTestRotation().WithUsers(test.Usermap(
+		test.UserServer1(),
+		test.UserServer2(),
+		test.UserServer3(),
+	))
+	rotation.Size = 1
+
+	var served []string
+	for shiftNumber := 1; shiftNumber <= 4; shiftNumber++ {
+		shift := &sl.Shift{Shift: store.NewShift("", "", nil)}
+		chosen, err := New(&bot.NilLogger{}).FillShift(rotation, shiftNumber, shift, nil, &bot.NilLogger{})
+		require.NoError(t, err)
+		require.Len(t, chosen, 1)
+		for id, user := range chosen {
+			served = append(served, id)
+			user.LastServed[rotation.RotationID] = shiftNumber
+		}
+	}
+	require.Equal(t, []string{
+		test.UserIDServer1,
+		test.UserIDServer2,
+		test.UserIDServer3,
+		test.UserIDServer1,
+	}, served)
 }
@@ -0,0 +1,175 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package queue
+
+import (
+	"fmt"
+	"time"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+// fill takes users in the queue order, skipping those who are unavailable, or
+// who would take a spot needed to satisfy a required need. Skipped users do not
+// serve, so they keep their position in the queue.
+type fill struct {
+	// Parameters
+	bot.Logger
+	rotationID  string
+	size        int
+	shiftNumber int
+
+	// State
+	queue            []*sl.User
+	chosen           sl.UserMap
+	requiredNeeds    store.Needs
+	constrainedNeeds store.Needs
+}
+
+func makeAutofill(rotationID string, size int, needs store.Needs,
+	pool sl.UserMap, order []string, chosen sl.UserMap, shiftNumber int, shiftStart, shiftEnd time.Time, logger bot.Logger) (*fill, error) {
+	af := fill{
+		Logger:      logger,
+		rotationID:  rotationID,
+		size:        size,
+		shiftNumber: shiftNumber,
+		chosen:      sl.UserMap{},
+	}
+
+	for _, need := range needs {
+		if need.Min > 0 {
+			af.requiredNeeds = append(af.requiredNeeds, need)
+		}
+		if need.Max >= 0 {
+			af.constrainedNeeds = append(af.constrainedNeeds, need)
+		}
+	}
+
+	for _, user := range chosen {
+		af.acceptUser(user)
+	}
+
+	for _, id := range order {
+		user := pool[id]
+		if user == nil || af.chosen[id] != nil {
+			continue
+		}
+		if user.LastServed[rotationID] > shiftNumber {
+			logger.Debugf("Skipped %s: not started yet", user.Markdown())
+			continue
+		}
+		available, err := user.IsAvailable(rotationID, shiftStart, shiftEnd)
+		if err != nil {
+			return nil, err
+		}
+		if !available {
+			logger.Debugf("Skipped %s: unavailable", user.Markdown())
+			continue
+		}
+		af.queue = append(af.queue, user)
+	}
+
+	return &af, nil
+}
+
+func (af *fill) fill() (sl.UserMap, error) {
+	af.Debugf(af.markdown())
+
+	// Users skipped to leave room for a required need may still be taken once
+	// the need is satisfied, so keep going over the queue while it helps.
+	for progress := true; progress && len(af.chosen) < af.size; {
+		progress = false
+		for _, user := range af.queue {
+			if len(af.chosen) >= af.size {
+				break
+			}
+			if af.chosen[user.MattermostUserID] != nil {
+				continue
+			}
+			if !af.meetsConstraints(user) || !af.fitsRequirements(user) {
+				continue
+			}
+			af.acceptUser(user)
+			progress = true
+		}
+	}
+
+	if len(af.requiredNeeds) > 0 {
+		if len(af.chosen) >= af.size {
+			return nil, af.newError(af.requiredNeeds[0], autofill.ErrSizeExceeded)
+		}
+		return nil, af.newError(af.requiredNeeds[0], autofill.ErrInsufficientForNeeds)
+	}
+	if len(af.chosen) < af.size {
+		return nil, af.newError(nil, autofill.ErrInsufficientForSize)
+	}
+
+	return af.chosen, nil
+}
+
+func (af *fill) meetsConstraints(user *sl.User) bool {
+	for _, need := range af.constrainedNeeds {
+		if sl.IsUserQualifiedForNeed(user, need) && need.Max-1 < 0 {
+			af.Debugf("Skipped %s against max on %s", user.Markdown(), need.Markdown())
+			return false
+		}
+	}
+	return true
+}
+
+// fitsRequirements returns true if taking the user would still leave enough
+// room in the shift to satisfy the remaining required needs.
+func (af *fill) fitsRequirements(user *sl.User) bool {
+	required := 0
+	for _, need := range af.requiredNeeds {
+		if sl.IsUserQualifiedForNeed(user, need) {
+			return true
+		}
+		required += need.Min
+	}
+	return af.size-len(af.chosen) > required
+}
+
+func (af *fill) acceptUser(user *sl.User) {
+	for _, need := range af.constrainedNeeds {
+		if sl.IsUserQualifiedForNeed(user, need) {
+			need.Max--
+		}
+	}
+
+	var updatedRequiredNeeds store.Needs
+	for _, need := range af.requiredNeeds {
+		if sl.IsUserQualifiedForNeed(user, need) {
+			need.Min--
+			if need.Min == 0 {
+				continue
+			}
+		}
+		updatedRequiredNeeds = append(updatedRequiredNeeds, need)
+	}
+	af.requiredNeeds = updatedRequiredNeeds
+
+	af.chosen[user.MattermostUserID] = user
+}
+
+func (af *fill) markdown() string {
+	out := fmt.Sprintf("filling shift %v, queue:\n", af.shiftNumber)
+	for i, user := range af.queue {
+		out += fmt.Sprintf("%v. %s\n", i+1, user.MarkdownWithSkills())
+	}
+	return out
+}
+
+func (af *fill) newError(need *store.Need, err error) *autofill.Error {
+	return &autofill.Error{
+		Err:           err,
+		UnmetNeeds:    af.requiredNeeds,
+		UnmetNeed:     need,
+		UnmetCapacity: af.size - len(af.chosen),
+		ShiftNumber:   af.shiftNumber,
+	}
+}
@@ -4,6 +4,8 @@
 package solarlottery
 
 import (
+	"math/rand"
+
 	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
 )
@@ -24,16 +26,18 @@ func New(logger bot.Logger) sl.Autofiller {
 // map intact, but when called for a sequence of shifts, it relies on the caller
 // to carry the users from one call to the next, presumably by using the same
 // rotation object.
-func (*autofiller) FillShift(rotation *sl.Rotation, shiftNumber int, shift *sl.Shift, logger bot.Logger) (sl.UserMap, error) {
+func (*autofiller) FillShift(rotation *sl.Rotation, shiftNumber int, shift *sl.Shift, random *rand.Rand, logger bot.Logger) (sl.UserMap, error) {
 	af, err := makeAutofill(
 		rotation.RotationID,
 		rotation.Size,
 		rotation.Needs.Clone(),
+		rotation.Weighting,
 		rotation.Users.Clone(false),
 		rotation.ShiftUsers(shift),
 		shiftNumber,
 		shift.StartTime,
 		shift.EndTime,
+		random,
 		logger,
 	)
 	if err != nil {
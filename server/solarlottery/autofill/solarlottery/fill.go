@@ -5,6 +5,7 @@ package solarlottery
 
 import (
 	"fmt"
+	"math/rand"
 	"sort"
 	"time"
 
@@ -20,7 +21,9 @@ type fill struct {
 	rotationID  string
 	size        int
 	shiftNumber int
+	weighting   string
 	userWeightF func(user *sl.User) float64
+	rand        *rand.Rand
 
 	// State
 	pool             sl.UserMap
@@ -30,8 +33,8 @@ type fill struct {
 	constrainedNeeds store.Needs
 }
 
-func makeAutofill(rotationID string, size int, needs store.Needs,
-	pool sl.UserMap, chosen sl.UserMap, shiftNumber int, shiftStart, shiftEnd time.Time, logger bot.Logger) (*fill, error) {
+func makeAutofill(rotationID string, size int, needs store.Needs, weighting string,
+	pool sl.UserMap, chosen sl.UserMap, shiftNumber int, shiftStart, shiftEnd time.Time, random *rand.Rand, logger bot.Logger) (*fill, error) {
 	if chosen == nil {
 		chosen = sl.UserMap{}
 	}
@@ -42,26 +45,28 @@ func makeAutofill(rotationID string, size int, needs store.Needs,
 		size:          size,
 		pool:          pool,
 		shiftNumber:   shiftNumber,
+		weighting:     weighting,
+		rand:          random,
 		requiredNeeds: store.Needs{},
 		needPools:     map[string]sl.UserMap{},
 	}
-	af.userWeightF = af.userWeight
+	weightF, err := sl.GetWeightFunc(weighting)
+	if err != nil {
+		return nil, err
+	}
+	af.userWeightF = func(user *sl.User) float64 {
+		return weightF(user, rotationID, shiftNumber)
+	}
 
 	// remove any unavailable users from the pool, update weights
 	for _, user := range af.pool {
-		overlappingEvents, err := user.OverlapEvents(shiftStart, shiftEnd, false)
+		available, err := user.IsAvailable(rotationID, shiftStart, shiftEnd)
 		if err != nil {
 			return nil, err
 		}
-		for _, event := range overlappingEvents {
-			// Unavailable events apply to all rotations, Shift events apply
-			// only to the rotation from which they come.
-			if event.Type == store.EventTypePersonal ||
-				(event.Type == store.EventTypeShift && event.RotationID == rotationID) {
-
-				delete(af.pool, user.MattermostUserID)
-				logger.Debugf("Disqualified %s: unavailable", user.Markdown())
-			}
+		if !available {
+			delete(af.pool, user.MattermostUserID)
+			logger.Debugf("Disqualified %s: unavailable", user.Markdown())
 		}
 	}
 
@@ -185,13 +190,17 @@ func (af *fill) markdown() string {
 	total := float64(0)
 	for id, user := range af.pool {
 		ws.ids = append(ws.ids, id)
-		weight := af.userWeight(user)
+		weight := af.userWeightF(user)
 		ws.weights = append(ws.weights, weight)
 		total += weight
 	}
 	sort.Sort(&ws)
 	out := ""
-	out += fmt.Sprintf("filling shift %v, choosing from:\n", af.shiftNumber)
+	weighting := af.weighting
+	if weighting == "" {
+		weighting = sl.WeightingExponential
+	}
+	out += fmt.Sprintf("filling shift %v, %s weighting, choosing from:\n", af.shiftNumber, weighting)
 	for i, id := range ws.ids {
 		out += fmt.Sprintf("- **%.3f**: %s\n", ws.weights[i]/total, af.pool[id].MarkdownWithSkills())
 	}
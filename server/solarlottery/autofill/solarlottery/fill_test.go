@@ -4,6 +4,7 @@
 package solarlottery
 
 import (
+	"math/rand"
 	"testing"
 	"time"
 
@@ -200,7 +201,7 @@ func TestMeetsConstraints(t *testing.T) {
 
 func makeTestAutofill(t testing.TB, size int, needs store.Needs,
 	pool sl.UserMap, chosen sl.UserMap, shiftNumber int) (*fill, error) {
-	return makeAutofill(test.RotationID, size, needs, pool, chosen, shiftNumber, time.Time{}, time.Time{},
+	return makeAutofill(test.RotationID, size, needs, "", pool, chosen, shiftNumber, time.Time{}, time.Time{}, rand.New(rand.NewSource(1)),
 		// &bot.TestLogger{TB: t},
 		&bot.NilLogger{},
 	)
@@ -4,8 +4,6 @@
 package solarlottery
 
 import (
-	"math"
-	"math/rand"
 	"sort"
 
 	"gonum.org/v1/gonum/floats"
@@ -19,18 +17,24 @@ func (af *fill) pickUser(from sl.UserMap) *sl.User {
 		return nil
 	}
 
+	// Iterate in a stable order, for the pick to be reproducible with the
+	// same random source.
+	ids := []string{}
+	for id := range from {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
 	cdf := make([]float64, len(from))
 	weights := []float64{}
-	ids := []string{}
 	total := float64(0)
-	for id, user := range from {
-		ids = append(ids, id)
-		weight := af.userWeightF(user)
+	for _, id := range ids {
+		weight := af.userWeightF(from[id])
 		weights = append(weights, weight)
 		total += weight
 	}
 	floats.CumSum(cdf, weights)
-	random := rand.Float64() * total
+	random := af.rand.Float64() * total
 	i := sort.Search(len(cdf), func(i int) bool {
 		return cdf[i] >= random
 	})
@@ -41,16 +45,6 @@ func (af *fill) pickUser(from sl.UserMap) *sl.User {
 	return from[ids[i]]
 }
 
-func (af *fill) userWeight(user *sl.User) float64 {
-	lastServed := user.LastServed[af.rotationID]
-	if lastServed > af.shiftNumber {
-		// return a non-0 but very low number, to prevent user from serving
-		// other than if all have 0 weights
-		return 1e-12
-	}
-	return math.Pow(2.0, float64(af.shiftNumber-lastServed))
-}
-
 func (af *fill) pickNeed(requiredNeeds store.Needs, needPools map[string]sl.UserMap) (*store.Need, sl.UserMap) {
 	if len(requiredNeeds) == 0 {
 		return nil, nil
@@ -5,6 +5,7 @@ package solarlottery
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -67,9 +68,11 @@ func TestPickUser(t *testing.T) {
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			counters := map[string]int{}
+			random := rand.New(rand.NewSource(1))
 			for i := 0; i < sampleSize; i++ {
 				af, err := makeTestAutofill(t, 10, nil, nil, nil, 0)
 				require.NoError(t, err)
+				af.rand = random
 				origWeightF := af.userWeightF
 				af.userWeightF = func(u *sl.User) float64 {
 					if tc.weights[u.MattermostUserID] != 0 {
@@ -223,7 +226,7 @@ func TestUserWeight(t *testing.T) {
 
 			user := test.User("test").WithLastServed(test.RotationID, tc.lastServed)
 
-			weight := af.userWeight(user)
+			weight := af.userWeightF(user)
 			require.Equal(t, tc.expectedWeight, weight)
 		})
 	}
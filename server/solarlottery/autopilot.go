@@ -12,16 +12,26 @@ import (
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
 )
 
 type Autopilot interface {
 	AutopilotRotation(rotation *Rotation, now time.Time) error
 }
 
+// ErrAutopilotRunning is returned when autopilot for the rotation is already
+// running, possibly on another server in the cluster.
+var ErrAutopilotRunning = errors.New("autopilot is already running")
+
+// autopilotLockTTL is how long an autopilot run may hold its rotation's lock
+// before it is considered abandoned.
+const autopilotLockTTL = 5 * time.Minute
+
 func (sl *solarLottery) AutopilotRotation(rotation *Rotation, now time.Time) error {
 	err := sl.Filter(
 		withActingUserExpanded,
 		withRotationExpanded(rotation),
+		withRotationManager(rotation),
 	)
 	if err != nil {
 		return err
@@ -36,6 +46,22 @@ func (sl *solarLottery) AutopilotRotation(rotation *Rotation, now time.Time) err
 	if !rotation.Autopilot.On {
 		return nil
 	}
+
+	mutex := kvstore.NewMutex(sl.LockStore, "autopilot-"+rotation.RotationID, autopilotLockTTL)
+	locked, err := mutex.TryLock()
+	if err != nil {
+		return errors.WithMessage(err, "failed to lock autopilot")
+	}
+	if !locked {
+		return ErrAutopilotRunning
+	}
+	defer func() {
+		unlockErr := mutex.Unlock()
+		if unlockErr != nil {
+			logger.Errorf("failed to unlock autopilot for %s: %v", rotation.Markdown(), unlockErr)
+		}
+	}()
+
 	currentShiftNumber, err := rotation.ShiftNumberForTime(now)
 	if err != nil {
 		return err
@@ -102,20 +128,24 @@ func (sl *solarLottery) AutopilotRotation(rotation *Rotation, now time.Time) err
 	nextNotified, err := sl.autopilotNotifyNext(rotation, now, currentShiftNumber)
 	nextNotifiedStatus := status(err, fmt.Sprintf("notified %s", nextNotified.Markdown()))
 
+	reminded, escalated, err := sl.autopilotAcknowledge(rotation, now, currentShiftNumber)
+	ackStatus := status(err, fmt.Sprintf("reminded %s, escalated %s", reminded.Markdown(), escalated.Markdown()))
+
 	logger.Infof("%s ran autopilot on %s for %v. Status:\n"+
 		"- finish previous shift: %s\n"+
 		"- fill shift(s): %s\n"+
 		"- start next shift: %s\n"+
 		"- notify current shift's users: %s\n"+
-		"- notify next shift's users: %s\n",
+		"- notify next shift's users: %s\n"+
+		"- remind and escalate unacknowledged users: %s\n",
 		sl.actingUser.Markdown(), rotation.Markdown(), now,
 		finishedStatus,
 		fillStatus,
 		startedStatus,
 		currentNotifiedStatus,
 		nextNotifiedStatus,
+		ackStatus,
 	)
-
 	return nil
 }
 
@@ -123,11 +153,17 @@ func (sl *solarLottery) autopilotFinishShift(rotation *Rotation, now time.Time,
 	if !rotation.Autopilot.StartFinish {
 		return 0, nil, errors.New("not configured")
 	}
-	finishShiftNumber, err := rotation.ShiftNumberForTime(now.Add(-1 * 24 * time.Hour))
+	finishShiftNumber := currentShiftNumber - 1
+	if finishShiftNumber < 0 {
+		return 0, nil, errors.New("no previous shift")
+	}
+	// Finish the previous shift within a day after it ended, a day on the wall
+	// clock, so that DST transitions do not matter.
+	_, end, err := rotation.ShiftDatesForNumber(finishShiftNumber)
 	if err != nil {
 		return 0, nil, err
 	}
-	if finishShiftNumber == -1 || finishShiftNumber == currentShiftNumber {
+	if !now.In(rotation.StartTime.Location()).AddDate(0, 0, -1).Before(end) {
 		return 0, nil, errors.New("no previous shift")
 	}
 
@@ -198,13 +234,17 @@ func (sl *solarLottery) autopilotNotifyCurrent(rotation *Rotation, now time.Time
 		return nil, errors.New("already notified")
 	}
 
-	sl.messageShiftWillFinish(rotation, currentShift)
-
-	currentShift.Shift.Autopilot.NotifiedFinish = now
-	err = sl.ShiftStore.StoreShift(rotation.RotationID, currentShiftNumber, currentShift.Shift)
+	err = sl.updateShift(rotation, currentShift, func(shift *Shift) error {
+		if !shift.Autopilot.NotifiedFinish.IsZero() {
+			return errors.New("already notified")
+		}
+		shift.Autopilot.NotifiedFinish = now
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	sl.messageShiftWillFinish(rotation, currentShift)
 
 	return rotation.ShiftUsers(currentShift), nil
 }
@@ -230,22 +270,110 @@ func (sl *solarLottery) autopilotNotifyNext(rotation *Rotation, now time.Time, c
 		return nil, errors.New("already notified")
 	}
 
-	sl.messageShiftWillStart(rotation, nextShift)
-
-	nextShift.Shift.Autopilot.NotifiedStart = now
-	err = sl.ShiftStore.StoreShift(rotation.RotationID, nextShiftNumber, nextShift.Shift)
+	err = sl.updateShift(rotation, nextShift, func(shift *Shift) error {
+		if !shift.Autopilot.NotifiedStart.IsZero() {
+			return errors.New("already notified")
+		}
+		shift.Autopilot.NotifiedStart = now
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	sl.messageShiftWillStart(rotation, nextShift)
 
 	return rotation.ShiftUsers(nextShift), nil
 }
 
+// autopilotAcknowledge reminds the users who have not acknowledged the shifts
+// starting within AckPrior, daily, and tells the admins about those who still
+// have not once the shift starts within EscalatePrior. If those include users
+// of the shift's first tier, the users of the later tiers are called in.
+func (sl *solarLottery) autopilotAcknowledge(rotation *Rotation, now time.Time, currentShiftNumber int) (UserMap, UserMap, error) {
+	if rotation.Autopilot.AckPrior == 0 {
+		return nil, nil, errors.New("not configured")
+	}
+	upToShiftNumber, err := rotation.ShiftNumberForTime(now.Add(rotation.Autopilot.AckPrior))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	startingShiftNumber := currentShiftNumber
+	if startingShiftNumber < 0 {
+		startingShiftNumber = 0
+	}
+
+	reminded, escalated := UserMap{}, UserMap{}
+	for shiftNumber := startingShiftNumber; shiftNumber <= upToShiftNumber; shiftNumber++ {
+		shift, err := sl.loadShift(rotation, shiftNumber)
+		if err == store.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if shift.Status != store.ShiftStatusOpen {
+			continue
+		}
+		unacknowledged := shift.Unacknowledged(rotation)
+		if len(unacknowledged) == 0 {
+			continue
+		}
+
+		start, _, err := rotation.ShiftDatesForNumber(shiftNumber)
+		if err != nil {
+			return nil, nil, err
+		}
+		remind := now.Sub(shift.Autopilot.AckReminded) >= DayDuration
+		escalate := shift.Autopilot.Escalated.IsZero() &&
+			!start.After(now.Add(rotation.Autopilot.EscalatePrior))
+		if !remind && !escalate {
+			continue
+		}
+		err = sl.updateShift(rotation, shift, func(shift *Shift) error {
+			if remind {
+				shift.Autopilot.AckReminded = now
+			}
+			if escalate {
+				shift.Autopilot.Escalated = now
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if remind {
+			sl.messageShiftAckReminder(rotation, shift, unacknowledged)
+			for id, user := range unacknowledged {
+				reminded[id] = user
+			}
+		}
+		if escalate {
+			sl.messageShiftEscalated(rotation, shift, unacknowledged)
+			unacknowledgedFirst := UserMap{}
+			for id, user := range rotation.ShiftUsersByTier(shift)[0] {
+				if unacknowledged[id] != nil {
+					unacknowledgedFirst[id] = user
+				}
+			}
+			if len(unacknowledgedFirst) > 0 {
+				sl.messageShiftBackupsCalled(rotation, shift, unacknowledgedFirst)
+			}
+			for id, user := range unacknowledged {
+				escalated[id] = user
+			}
+		}
+	}
+	return reminded, escalated, nil
+}
+
 func (sl *solarLottery) fillShifts(rotation *Rotation, startingShiftNumber, numShifts int, now time.Time, logger bot.Logger) ([]int, []*Shift, []UserMap, error) {
 	// Guess' logs are too verbose - suppress
+	seed := NewSeed()
 	prevLogger := sl.Logger
 	sl.Logger = &bot.NilLogger{}
-	shifts, err := sl.Guess(rotation, startingShiftNumber, numShifts)
+	shifts, err := sl.Guess(rotation, startingShiftNumber, numShifts, seed)
 	sl.Logger = prevLogger
 	if err != nil {
 		return nil, nil, nil, err
@@ -297,15 +425,17 @@ func (sl *solarLottery) fillShifts(rotation *Rotation, startingShiftNumber, numS
 			continue
 		}
 
-		loadedShift.Autopilot.Filled = now
-
-		_, err = sl.joinShift(rotation, shiftNumber, loadedShift, added, true)
+		_, err = sl.joinShift(rotation, shiftNumber, loadedShift, added, shift.Roles, true)
 		if err != nil {
 			return filledShiftNumbers, filledShifts, addedUsers,
 				errors.WithMessagef(err, "failed to join autofilled users to %s", loadedShift.Markdown())
 		}
 
-		err = sl.ShiftStore.StoreShift(rotation.RotationID, shiftNumber, loadedShift.Shift)
+		err = sl.updateShift(rotation, loadedShift, func(shift *Shift) error {
+			shift.Autopilot.Filled = now
+			shift.Autopilot.Seed = seed
+			return nil
+		})
 		if err != nil {
 			return filledShiftNumbers, filledShifts, addedUsers,
 				errors.WithMessagef(err, "failed to store autofilled %s", loadedShift.Markdown())
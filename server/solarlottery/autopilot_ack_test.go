@@ -0,0 +1,219 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store/mock_store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot/mock_bot"
+)
+
+// testAckAutopilot returns a rotation with users "a" and "b", and a
+// solarLottery that stores its shift 1, starting on 2020-03-09, in *stored.
+// Other shifts are not started.
+func testAckAutopilot(t *testing.T, ctrl *gomock.Controller, stored **store.Shift) (*solarLottery, *Rotation, *mock_bot.MockPoster) {
+	rotation := &Rotation{
+		Rotation: store.NewRotation("test"),
+		Users:    UserMap{},
+	}
+	rotation.RotationID = "test-ID"
+	rotation.Period = EveryWeek
+	rotation.Start = "2020-03-02"
+	rotation.Autopilot.AckPrior = 3 * DayDuration
+	rotation.Autopilot.EscalatePrior = DayDuration
+	require.NoError(t, rotation.init(nil))
+	for _, id := range []string{"a", "b"} {
+		user := &User{
+			User:           store.NewUser(id),
+			MattermostUser: &model.User{Id: id, Username: id},
+		}
+		rotation.MattermostUserIDs[id] = id
+		rotation.Users[id] = user
+	}
+
+	shiftStore := mock_store.NewMockShiftStore(ctrl)
+	shiftStore.EXPECT().LoadShift("test-ID", 1).AnyTimes().DoAndReturn(
+		func(string, int) (*store.Shift, error) {
+			s := **stored
+			return &s, nil
+		})
+	shiftStore.EXPECT().LoadShift("test-ID", gomock.Any()).AnyTimes().Return(nil, store.ErrNotFound)
+	shiftStore.EXPECT().StoreShift("test-ID", 1, gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ string, _ int, shift *store.Shift) error {
+			*stored = shift
+			return nil
+		})
+	auditStore := mock_store.NewMockAuditStore(ctrl)
+	auditStore.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	poster := mock_bot.NewMockPoster(ctrl)
+
+	sl := &solarLottery{
+		Logger: &bot.NilLogger{},
+		Config: Config{
+			Config: &config.Config{
+				StoredConfig: config.StoredConfig{
+					BotConfig: bot.BotConfig{AdminUserIDs: "admin1, admin2"},
+				},
+			},
+			Dependencies: &Dependencies{
+				AuditStore: auditStore,
+				Logger:     &bot.NilLogger{},
+				Poster:     poster,
+				ShiftStore: shiftStore,
+			},
+		},
+	}
+	return sl, rotation, poster
+}
+
+func TestAutopilotAcknowledge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// "a" acknowledged the shift.
+	stored := store.NewShift("2020-03-09", "2020-03-16", store.IDMap{"a": store.NotEmpty, "b": store.NotEmpty})
+	stored.Acknowledged = map[string]time.Time{"a": time.Date(2020, 3, 3, 0, 0, 0, 0, time.UTC)}
+	sl, rotation, poster := testAckAutopilot(t, ctrl, &stored)
+
+	run := func(now time.Time) (UserMap, UserMap) {
+		reminded, escalated, err := sl.autopilotAcknowledge(rotation, now, 0)
+		require.NoError(t, err)
+		return reminded, escalated
+	}
+
+	// Too early.
+	reminded, escalated := run(time.Date(2020, 3, 4, 12, 0, 0, 0, time.UTC))
+	require.Empty(t, reminded)
+	require.Empty(t, escalated)
+
+	// Within AckPrior, "b" is reminded, once a day.
+	poster.EXPECT().DM("b", gomock.Any()).Times(1)
+	reminded, escalated = run(time.Date(2020, 3, 6, 12, 0, 0, 0, time.UTC))
+	require.Equal(t, UserMap{"b": rotation.Users["b"]}, reminded)
+	require.Empty(t, escalated)
+	reminded, _ = run(time.Date(2020, 3, 6, 18, 0, 0, 0, time.UTC))
+	require.Empty(t, reminded)
+
+	// Within EscalatePrior, "b" is reminded again, and the admins are told,
+	// once.
+	poster.EXPECT().DM("b", gomock.Any()).Times(1)
+	poster.EXPECT().DM("admin1", "%s", gomock.Any()).Times(1)
+	poster.EXPECT().DM("admin2", "%s", gomock.Any()).Times(1)
+	reminded, escalated = run(time.Date(2020, 3, 8, 12, 0, 0, 0, time.UTC))
+	require.Equal(t, UserMap{"b": rotation.Users["b"]}, reminded)
+	require.Equal(t, UserMap{"b": rotation.Users["b"]}, escalated)
+	_, escalated = run(time.Date(2020, 3, 8, 18, 0, 0, 0, time.UTC))
+	require.Empty(t, escalated)
+
+	// Once "b" acknowledges, there is nothing to do.
+	stored.Acknowledged["b"] = time.Date(2020, 3, 8, 20, 0, 0, 0, time.UTC)
+	reminded, escalated = run(time.Date(2020, 3, 9, 0, 0, 0, 0, time.UTC).Add(-time.Minute))
+	require.Empty(t, reminded)
+	require.Empty(t, escalated)
+}
+
+func TestAutopilotAcknowledgeCallsBackups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// "a" is primary and has not acknowledged the shift, "b" is secondary.
+	stored := store.NewShift("2020-03-09", "2020-03-16", store.IDMap{"a": store.NotEmpty, "b": store.NotEmpty})
+	stored.Roles = map[string]string{"a": "primary", "b": "secondary"}
+	stored.Acknowledged = map[string]time.Time{"b": time.Date(2020, 3, 3, 0, 0, 0, 0, time.UTC)}
+	sl, rotation, poster := testAckAutopilot(t, ctrl, &stored)
+	require.NoError(t, rotation.SetRole(&store.ShiftRole{Name: "primary", Count: 1}))
+	require.NoError(t, rotation.SetRole(&store.ShiftRole{Name: "secondary", Count: 1}))
+
+	// Before the shift starts, both tiers are told.
+	poster.EXPECT().DM("a", gomock.Any()).Times(1)
+	poster.EXPECT().DM("b", gomock.Any()).Times(1)
+	sl.messageShiftWillStart(rotation, &Shift{Shift: stored, ShiftNumber: 1})
+
+	// On escalation, "a" is reminded, and "b" is called in with the admins.
+	poster.EXPECT().DM("a", gomock.Any()).Times(1)
+	poster.EXPECT().DM("b", gomock.Any()).Times(1)
+	poster.EXPECT().DM("admin1", "%s", gomock.Any()).Times(1)
+	poster.EXPECT().DM("admin2", "%s", gomock.Any()).Times(1)
+	reminded, escalated, err := sl.autopilotAcknowledge(rotation, time.Date(2020, 3, 8, 12, 0, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+	require.Equal(t, UserMap{"a": rotation.Users["a"]}, reminded)
+	require.Equal(t, UserMap{"a": rotation.Users["a"]}, escalated)
+}
+
+func TestMessageShiftStartedTiers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The primary role is not filled, "b" is secondary.
+	stored := store.NewShift("2020-03-09", "2020-03-16", store.IDMap{"b": store.NotEmpty})
+	stored.Roles = map[string]string{"b": "secondary"}
+	sl, rotation, poster := testAckAutopilot(t, ctrl, &stored)
+	require.NoError(t, rotation.SetRole(&store.ShiftRole{Name: "primary", Count: 1}))
+	require.NoError(t, rotation.SetRole(&store.ShiftRole{Name: "secondary", Count: 1}))
+	sl.actingUser = rotation.Users["a"]
+
+	poster.EXPECT().DM("b", gomock.Any()).Times(2)
+	sl.messageShiftWillStart(rotation, &Shift{Shift: stored, ShiftNumber: 1})
+	sl.messageShiftStarted(rotation, &Shift{Shift: stored, ShiftNumber: 1})
+}
+
+func TestAutopilotAcknowledgeCurrentShift(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// shift 1 started on 2020-03-09, and is still open.
+	stored := store.NewShift("2020-03-09", "2020-03-16", store.IDMap{"a": store.NotEmpty})
+	sl, rotation, poster := testAckAutopilot(t, ctrl, &stored)
+
+	poster.EXPECT().DM("a", gomock.Any()).Times(1)
+	poster.EXPECT().DM("admin1", "%s", gomock.Any()).Times(1)
+	poster.EXPECT().DM("admin2", "%s", gomock.Any()).Times(1)
+	now := time.Date(2020, 3, 9, 6, 0, 0, 0, time.UTC)
+	_, escalated, err := sl.autopilotAcknowledge(rotation, now, 1)
+	require.NoError(t, err)
+	require.Equal(t, UserMap{"a": rotation.Users["a"]}, escalated)
+	require.Equal(t, now, stored.Autopilot.Escalated)
+
+	// "a" acknowledges it, as of the same time.
+	sl.actingMattermostUserID = "a"
+	sl.actingUser = rotation.Users["a"]
+	_, err = sl.ConfirmShift(rotation, 1, now)
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Time{"a": now}, stored.Acknowledged)
+}
+
+func TestAutopilotAcknowledgeHandoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stored := store.NewShift("2020-03-09", "2020-03-16", store.IDMap{"a": store.NotEmpty, "b": store.NotEmpty})
+	stored.Acknowledged = map[string]time.Time{"a": time.Date(2020, 3, 3, 0, 0, 0, 0, time.UTC)}
+	sl, rotation, poster := testAckAutopilot(t, ctrl, &stored)
+	rotation.TimeZone = "America/New_York"
+	rotation.HandoffTime = "18:00"
+	rotation.StartTime = time.Time{}
+	require.NoError(t, rotation.init(nil))
+
+	// Shift 1 starts at 22:00 UTC on 2020-03-09, it is escalated a day before.
+	poster.EXPECT().DM("b", gomock.Any()).Times(1)
+	_, escalated, err := sl.autopilotAcknowledge(rotation, time.Date(2020, 3, 8, 21, 0, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+	require.Empty(t, escalated)
+
+	poster.EXPECT().DM("admin1", "%s", gomock.Any()).Times(1)
+	poster.EXPECT().DM("admin2", "%s", gomock.Any()).Times(1)
+	_, escalated, err = sl.autopilotAcknowledge(rotation, time.Date(2020, 3, 8, 22, 0, 0, 0, time.UTC), 0)
+	require.NoError(t, err)
+	require.Equal(t, UserMap{"b": rotation.Users["b"]}, escalated)
+}
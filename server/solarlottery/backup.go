@@ -0,0 +1,413 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+// BackupVersion is the version of the backup format. Backups from newer
+// versions can not be imported.
+const BackupVersion = 1
+
+// Import modes. Merging keeps the stored records that are not in the backup,
+// replacing deletes them.
+const (
+	ImportMerge   = "merge"
+	ImportReplace = "replace"
+)
+
+type Backups interface {
+	ExportBackup(now time.Time) (*Backup, error)
+	DMBackup(now time.Time) (*Backup, error)
+	ImportBackup(backup *Backup, mode string) error
+}
+
+// Backup is a snapshot of all the rotations, shifts, users and skills.
+type Backup struct {
+	Version        int
+	PluginVersion  string
+	Created        time.Time
+	KnownSkills    store.IDMap
+	KnownRotations store.IDMap
+	Rotations      []*store.Rotation
+	Shifts         []*BackupShift
+	Users          []*store.User
+}
+
+type BackupShift struct {
+	RotationID  string
+	ShiftNumber int
+	Shift       *store.Shift
+}
+
+func (backup *Backup) Markdown() string {
+	return fmt.Sprintf("%v rotations, %v shifts, %v users and %v skills",
+		len(backup.Rotations), len(backup.Shifts), len(backup.Users), len(backup.KnownSkills))
+}
+
+// ExportBackup snapshots the stored data. Only plugin admins may do it.
+func (sl *solarLottery) ExportBackup(now time.Time) (*Backup, error) {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withActingUserAdmin,
+	)
+	if err != nil {
+		return nil, err
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":       "sl.ExportBackup",
+		"ActingUsername": sl.actingUser.MattermostUsername(),
+	})
+
+	backup, err := sl.loadBackup(now)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("%s exported %s.", sl.actingUser.Markdown(), backup.Markdown())
+	return backup, nil
+}
+
+// DMBackup exports a backup, and sends it to the acting user as a JSON file.
+func (sl *solarLottery) DMBackup(now time.Time) (*Backup, error) {
+	backup, err := sl.ExportBackup(now)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	filename := fmt.Sprintf("solar-lottery-backup-%s.json", now.UTC().Format("20060102-150405"))
+	err = sl.Poster.DMFile(sl.actingMattermostUserID, filename, data,
+		"Backup of %s, restore it with `/%s admin import --file <file ID>`.", backup.Markdown(), config.CommandTrigger)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to send the backup")
+	}
+	return backup, nil
+}
+
+// ImportBackup stores the backup's records over the stored ones, after
+// validating it. Only plugin admins may do it.
+func (sl *solarLottery) ImportBackup(backup *Backup, mode string) error {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withActingUserAdmin,
+	)
+	if err != nil {
+		return err
+	}
+	if mode != ImportMerge && mode != ImportReplace {
+		return errors.Errorf("invalid import mode %q, must be %s or %s", mode, ImportMerge, ImportReplace)
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":       "sl.ImportBackup",
+		"ActingUsername": sl.actingUser.MattermostUsername(),
+		"Mode":           mode,
+	})
+
+	err = backup.Validate()
+	if err != nil {
+		return err
+	}
+	existing, err := sl.loadBackup(time.Now())
+	if err != nil {
+		return errors.WithMessage(err, "failed to load the stored data")
+	}
+
+	for _, rotation := range backup.Rotations {
+		// stored over the stored record, if any
+		stored, err := sl.RotationStore.LoadRotation(rotation.RotationID)
+		switch err {
+		case nil:
+			rotation.Revision = stored.Revision
+		case store.ErrNotFound:
+			rotation.Revision = 0
+		default:
+			return err
+		}
+		err = sl.RotationStore.StoreRotation(rotation)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to store rotation %s", rotation.RotationID)
+		}
+	}
+	for _, s := range backup.Shifts {
+		stored, err := sl.ShiftStore.LoadShift(s.RotationID, s.ShiftNumber)
+		switch err {
+		case nil:
+			s.Shift.Revision = stored.Revision
+		case store.ErrNotFound:
+			s.Shift.Revision = 0
+		default:
+			return err
+		}
+		err = sl.ShiftStore.StoreShift(s.RotationID, s.ShiftNumber, s.Shift)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to store shift %s#%v", s.RotationID, s.ShiftNumber)
+		}
+	}
+	for _, user := range backup.Users {
+		stored, err := sl.UserStore.LoadUser(user.MattermostUserID)
+		switch err {
+		case nil:
+			user.Revision = stored.Revision
+		case store.ErrNotFound:
+			user.Revision = 0
+		default:
+			return err
+		}
+		err = sl.UserStore.StoreUser(user)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to store user %s", user.MattermostUserID)
+		}
+	}
+
+	knownRotations := backup.KnownRotations.Clone()
+	knownSkills := backup.KnownSkills.Clone()
+	if mode == ImportReplace {
+		err = sl.deleteNotInBackup(existing, backup)
+		if err != nil {
+			return err
+		}
+	} else {
+		for id, v := range existing.KnownRotations {
+			if knownRotations[id] == "" {
+				knownRotations[id] = v
+			}
+		}
+		for skill, v := range existing.KnownSkills {
+			if knownSkills[skill] == "" {
+				knownSkills[skill] = v
+			}
+		}
+	}
+	err = sl.RotationStore.StoreKnownRotations(knownRotations)
+	if err != nil {
+		return errors.WithMessage(err, "failed to store known rotations")
+	}
+	err = sl.SkillsStore.StoreKnownSkills(knownSkills)
+	if err != nil {
+		return errors.WithMessage(err, "failed to store known skills")
+	}
+	sl.audit(AuditAdminImport, "", NoShift, nil, nil, nil)
+
+	logger.Infof("%s imported (%s) %s.", sl.actingUser.Markdown(), mode, backup.Markdown())
+	return nil
+}
+
+// Validate checks the backup's version, and that its records only refer to
+// the rotations, users and skills in it. Archived rotations are not in the
+// known rotations.
+func (backup *Backup) Validate() error {
+	if backup.Version < 1 || backup.Version > BackupVersion {
+		return errors.Errorf("unsupported backup version %v, expected up to %v", backup.Version, BackupVersion)
+	}
+
+	var problems []string
+	problemf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	users := map[string]bool{}
+	for _, user := range backup.Users {
+		if user == nil || user.MattermostUserID == "" {
+			problemf("user with no ID")
+			continue
+		}
+		if users[user.MattermostUserID] {
+			problemf("duplicate user %s", user.MattermostUserID)
+		}
+		users[user.MattermostUserID] = true
+		for skill := range user.SkillLevels {
+			if backup.KnownSkills[skill] == "" {
+				problemf("user %s has unknown skill %s", user.MattermostUserID, skill)
+			}
+		}
+	}
+
+	rotations := map[string]bool{}
+	for _, rotation := range backup.Rotations {
+		if rotation == nil || rotation.RotationID == "" {
+			problemf("rotation with no ID")
+			continue
+		}
+		id := rotation.RotationID
+		if rotations[id] {
+			problemf("duplicate rotation %s", id)
+		}
+		rotations[id] = true
+		if backup.KnownRotations[id] == "" && !rotation.IsArchived {
+			problemf("rotation %s is not archived, and not in the known rotations", id)
+		}
+		for userID := range rotation.MattermostUserIDs {
+			if !users[userID] {
+				problemf("rotation %s has unknown user %s", id, userID)
+			}
+		}
+		for _, need := range rotation.Needs {
+			if backup.KnownSkills[need.Skill] == "" {
+				problemf("rotation %s needs unknown skill %s", id, need.Skill)
+			}
+		}
+	}
+	for id := range backup.KnownRotations {
+		if !rotations[id] {
+			problemf("known rotation %s is missing", id)
+		}
+	}
+
+	shifts := map[string]bool{}
+	for _, s := range backup.Shifts {
+		if s == nil || s.Shift == nil {
+			problemf("empty shift")
+			continue
+		}
+		ref := fmt.Sprintf("%s#%v", s.RotationID, s.ShiftNumber)
+		if shifts[ref] {
+			problemf("duplicate shift %s", ref)
+		}
+		shifts[ref] = true
+		if !rotations[s.RotationID] {
+			problemf("shift %s has unknown rotation", ref)
+		}
+		if s.ShiftNumber < 0 {
+			problemf("shift %s has a negative number", ref)
+		}
+		for userID := range s.Shift.MattermostUserIDs {
+			if !users[userID] {
+				problemf("shift %s has unknown user %s", ref, userID)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf("invalid backup: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// loadBackup loads all the stored records, including the archived rotations
+// and their shifts.
+func (sl *solarLottery) loadBackup(now time.Time) (*Backup, error) {
+	backup := &Backup{
+		Version:       BackupVersion,
+		PluginVersion: sl.Config.PluginVersion,
+		Created:       now,
+		Rotations:     []*store.Rotation{},
+		Shifts:        []*BackupShift{},
+	}
+
+	var err error
+	backup.KnownSkills, err = sl.SkillsStore.LoadKnownSkills()
+	if err == store.ErrNotFound {
+		backup.KnownSkills, err = store.IDMap{}, nil
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load known skills")
+	}
+	backup.KnownRotations, err = sl.RotationStore.LoadKnownRotations()
+	if err == store.ErrNotFound {
+		backup.KnownRotations, err = store.IDMap{}, nil
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load known rotations")
+	}
+
+	backup.Rotations, err = sl.RotationStore.LoadAllRotations()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load rotations")
+	}
+	sort.Slice(backup.Rotations, func(i, j int) bool {
+		return backup.Rotations[i].RotationID < backup.Rotations[j].RotationID
+	})
+	for _, storedRotation := range backup.Rotations {
+		id := storedRotation.RotationID
+		shiftNumbers, err := sl.ShiftStore.ListShiftNumbers(id)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to list shifts of %s", id)
+		}
+		for _, shiftNumber := range shiftNumbers {
+			shift, err := sl.ShiftStore.LoadShift(id, shiftNumber)
+			if err == store.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed to load shift %s#%v", id, shiftNumber)
+			}
+			backup.Shifts = append(backup.Shifts, &BackupShift{
+				RotationID:  id,
+				ShiftNumber: shiftNumber,
+				Shift:       shift,
+			})
+		}
+	}
+
+	backup.Users, err = sl.UserStore.LoadAllUsers()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to load users")
+	}
+	sort.Slice(backup.Users, func(i, j int) bool {
+		return backup.Users[i].MattermostUserID < backup.Users[j].MattermostUserID
+	})
+	return backup, nil
+}
+
+// deleteNotInBackup deletes the existing records that are not in the backup.
+func (sl *solarLottery) deleteNotInBackup(existing, backup *Backup) error {
+	shifts := map[string]bool{}
+	for _, s := range backup.Shifts {
+		shifts[fmt.Sprintf("%s#%v", s.RotationID, s.ShiftNumber)] = true
+	}
+	for _, s := range existing.Shifts {
+		if shifts[fmt.Sprintf("%s#%v", s.RotationID, s.ShiftNumber)] {
+			continue
+		}
+		err := sl.ShiftStore.DeleteShift(s.RotationID, s.ShiftNumber)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to delete shift %s#%v", s.RotationID, s.ShiftNumber)
+		}
+	}
+	rotations := map[string]bool{}
+	for _, rotation := range backup.Rotations {
+		rotations[rotation.RotationID] = true
+	}
+	for _, rotation := range existing.Rotations {
+		if rotations[rotation.RotationID] {
+			continue
+		}
+		err := sl.RotationStore.DeleteRotation(rotation.RotationID)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to delete rotation %s", rotation.RotationID)
+		}
+		err = sl.ShiftStore.DeleteShiftNumbers(rotation.RotationID)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to delete the shift index of %s", rotation.RotationID)
+		}
+	}
+	users := map[string]bool{}
+	for _, user := range backup.Users {
+		users[user.MattermostUserID] = true
+	}
+	for _, user := range existing.Users {
+		if users[user.MattermostUserID] {
+			continue
+		}
+		err := sl.UserStore.DeleteUser(user.MattermostUserID)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to delete user %s", user.MattermostUserID)
+		}
+	}
+	return nil
+}
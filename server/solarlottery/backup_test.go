@@ -0,0 +1,167 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store/mock_store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+func testBackup() *Backup {
+	rotation := store.NewRotation("test")
+	rotation.RotationID = "test-ID"
+	rotation.Period = EveryWeek
+	rotation.Start = "2020-01-06"
+	rotation.MattermostUserIDs = store.IDMap{"user": store.NotEmpty}
+	rotation.Needs = store.Needs{store.NewNeed("webapp", 2, 1)}
+
+	user := store.NewUser("user")
+	user.SkillLevels = store.IntMap{"webapp": 2}
+
+	return &Backup{
+		Version:        BackupVersion,
+		KnownSkills:    store.IDMap{"webapp": store.NotEmpty},
+		KnownRotations: store.IDMap{"test-ID": store.NotEmpty},
+		Rotations:      []*store.Rotation{rotation},
+		Shifts: []*BackupShift{{
+			RotationID:  "test-ID",
+			ShiftNumber: 1,
+			Shift:       store.NewShift("2020-01-13", "2020-01-20", store.IDMap{"user": store.NotEmpty}),
+		}},
+		Users: []*store.User{user},
+	}
+}
+
+func TestBackupValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		modify        func(*Backup)
+		expectedError string
+	}{
+		"valid": {
+			modify: func(*Backup) {},
+		},
+		"newer version": {
+			modify:        func(b *Backup) { b.Version = BackupVersion + 1 },
+			expectedError: "unsupported backup version",
+		},
+		"unknown rotation user": {
+			modify:        func(b *Backup) { b.Users = nil },
+			expectedError: "rotation test-ID has unknown user user",
+		},
+		"unknown skill": {
+			modify:        func(b *Backup) { b.KnownSkills = store.IDMap{} },
+			expectedError: "user user has unknown skill webapp",
+		},
+		"rotation not known": {
+			modify:        func(b *Backup) { b.KnownRotations = store.IDMap{} },
+			expectedError: "rotation test-ID is not archived, and not in the known rotations",
+		},
+		"archived rotation": {
+			modify: func(b *Backup) {
+				b.KnownRotations = store.IDMap{}
+				b.Rotations[0].IsArchived = true
+			},
+		},
+		"known rotation missing": {
+			modify:        func(b *Backup) { b.KnownRotations["other"] = store.NotEmpty },
+			expectedError: "known rotation other is missing",
+		},
+		"shift of unknown rotation": {
+			modify:        func(b *Backup) { b.Shifts[0].RotationID = "other" },
+			expectedError: "shift other#1 has unknown rotation",
+		},
+		"duplicate shift": {
+			modify:        func(b *Backup) { b.Shifts = append(b.Shifts, b.Shifts[0]) },
+			expectedError: "duplicate shift test-ID#1",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			backup := testBackup()
+			tc.modify(backup)
+			err := backup.Validate()
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
+
+func TestImportBackup(t *testing.T) {
+	for _, mode := range []string{ImportMerge, ImportReplace} {
+		t.Run(mode, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			existingRotation := store.NewRotation("old")
+			existingRotation.RotationID = "old-ID"
+			existingRotation.Period = EveryWeek
+			existingRotation.Start = "2020-01-06"
+			existingUser := store.NewUser("old-user")
+			existingUser.Revision = 3
+
+			rotationStore := mock_store.NewMockRotationStore(ctrl)
+			rotationStore.EXPECT().LoadKnownRotations().Return(store.IDMap{"old-ID": store.NotEmpty}, nil)
+			rotationStore.EXPECT().LoadAllRotations().Return([]*store.Rotation{existingRotation}, nil)
+			rotationStore.EXPECT().LoadRotation("test-ID").Return(nil, store.ErrNotFound)
+			rotationStore.EXPECT().StoreRotation(gomock.Any()).Return(nil)
+			skillsStore := mock_store.NewMockSkillsStore(ctrl)
+			skillsStore.EXPECT().LoadKnownSkills().Return(store.IDMap{"server": store.NotEmpty}, nil)
+			shiftStore := mock_store.NewMockShiftStore(ctrl)
+			shiftStore.EXPECT().LoadShift(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, store.ErrNotFound)
+			shiftStore.EXPECT().StoreShift("test-ID", 1, gomock.Any()).Return(nil)
+			shiftStore.EXPECT().ListShiftNumbers("old-ID").Return([]int{}, nil)
+			userStore := mock_store.NewMockUserStore(ctrl)
+			userStore.EXPECT().LoadAllUsers().Return([]*store.User{existingUser}, nil)
+			userStore.EXPECT().LoadUser("user").Return(nil, store.ErrNotFound)
+			userStore.EXPECT().StoreUser(gomock.Any()).Return(nil)
+			auditStore := mock_store.NewMockAuditStore(ctrl)
+			auditStore.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+			if mode == ImportMerge {
+				rotationStore.EXPECT().StoreKnownRotations(store.IDMap{"old-ID": store.NotEmpty, "test-ID": store.NotEmpty}).Return(nil)
+				skillsStore.EXPECT().StoreKnownSkills(store.IDMap{"server": store.NotEmpty, "webapp": store.NotEmpty}).Return(nil)
+			} else {
+				rotationStore.EXPECT().DeleteRotation("old-ID").Return(nil)
+				shiftStore.EXPECT().DeleteShiftNumbers("old-ID").Return(nil)
+				userStore.EXPECT().DeleteUser("old-user").Return(nil)
+				rotationStore.EXPECT().StoreKnownRotations(store.IDMap{"test-ID": store.NotEmpty}).Return(nil)
+				skillsStore.EXPECT().StoreKnownSkills(store.IDMap{"webapp": store.NotEmpty}).Return(nil)
+			}
+
+			sl := &solarLottery{
+				Logger: &bot.NilLogger{},
+				Config: Config{
+					Config: &config.Config{BotUserID: "admin"},
+					Dependencies: &Dependencies{
+						AuditStore:    auditStore,
+						RotationStore: rotationStore,
+						ShiftStore:    shiftStore,
+						SkillsStore:   skillsStore,
+						UserStore:     userStore,
+						Logger:        &bot.NilLogger{},
+					},
+				},
+				actingMattermostUserID: "admin",
+				actingUser: &User{
+					User:           store.NewUser("admin"),
+					MattermostUser: &model.User{Id: "admin", Username: "admin"},
+				},
+			}
+			err := sl.ImportBackup(testBackup(), mode)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/ical"
+)
+
+const (
+	// CalendarPastShifts is how many of a rotation's past shifts its calendar
+	// includes, CalendarShifts how many upcoming, including the current one.
+	CalendarPastShifts = 4
+	CalendarShifts     = 12
+
+	// calendarSeed keeps the guessed shifts the same from one calendar refresh
+	// to the next, as long as the rotation does not change.
+	calendarSeed = 0
+
+	calendarProductID = "-//Mattermost//" + config.ApplicationName + "//EN"
+)
+
+// PathCalendarRotation and PathCalendarUser are where the calendar feeds are
+// served, under config.PathCalendar.
+const (
+	PathCalendarRotation = "/rotations/{rotationID}.ics"
+	PathCalendarUser     = "/user.ics"
+)
+
+// RotationCalendarURL returns the URL of the rotation's calendar feed.
+func RotationCalendarURL(pluginURL, rotationID, token string) string {
+	return pluginURL + config.PathCalendar + "/rotations/" + url.PathEscape(rotationID) + ".ics?token=" + url.QueryEscape(token)
+}
+
+// UserCalendarURL returns the URL of the token owner's calendar feed.
+func UserCalendarURL(pluginURL, token string) string {
+	return pluginURL + config.PathCalendar + PathCalendarUser + "?token=" + url.QueryEscape(token)
+}
+
+// Calendars serve the iCalendar feeds that users subscribe to from their
+// calendar apps. The feed requests are authenticated by the users' secret
+// calendar tokens rather than by a Mattermost session.
+type Calendars interface {
+	GetCalendarToken(regenerate bool) (string, error)
+	RevokeCalendarToken() error
+	RotationCalendar(token, rotationID string, now time.Time) ([]byte, error)
+	UserCalendar(token string, now time.Time) ([]byte, error)
+}
+
+// GetCalendarToken returns the acting user's calendar token, creating it if
+// needed. With regenerate, the previous token is replaced and stops working.
+func (sl *solarLottery) GetCalendarToken(regenerate bool) (string, error) {
+	err := sl.Filter(
+		withActingUserExpanded,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if !regenerate {
+		token, err := sl.CalendarStore.LoadCalendarToken(sl.actingMattermostUserID)
+		if err == nil {
+			return token, nil
+		}
+		if err != store.ErrNotFound {
+			return "", err
+		}
+	}
+
+	token, err := newCalendarToken()
+	if err != nil {
+		return "", err
+	}
+	err = sl.CalendarStore.StoreCalendarToken(sl.actingMattermostUserID, token)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to store calendar token")
+	}
+	sl.audit(AuditCalendarToken, "", NoShift, nil, nil, nil)
+
+	sl.Logger.Infof("%s created a calendar token.", sl.actingUser.Markdown())
+	return token, nil
+}
+
+// RevokeCalendarToken deletes the acting user's calendar token, so that their
+// calendar feeds can no longer be accessed with it.
+func (sl *solarLottery) RevokeCalendarToken() error {
+	err := sl.Filter(
+		withActingUserExpanded,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = sl.CalendarStore.DeleteCalendarToken(sl.actingMattermostUserID)
+	if err != nil {
+		return errors.WithMessage(err, "failed to delete calendar token")
+	}
+	sl.audit(AuditCalendarRevoke, "", NoShift, nil, nil, nil)
+
+	sl.Logger.Infof("%s revoked their calendar token.", sl.actingUser.Markdown())
+	return nil
+}
+
+// RotationCalendar returns the rotation's shifts from CalendarPastShifts
+// before the current one, through CalendarShifts after. Past shifts are only
+// included if they were stored; upcoming shifts that are not filled yet are
+// guessed, and marked tentative. A shift that can not be autofilled, e.g. in an
+// understaffed rotation, is included as it is.
+func (sl *solarLottery) RotationCalendar(token, rotationID string, now time.Time) ([]byte, error) {
+	err := sl.Filter(
+		withCalendarToken(token),
+		withActingUserExpanded,
+	)
+	if err != nil {
+		return nil, err
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":       "sl.RotationCalendar",
+		"ActingUsername": sl.actingUser.MattermostUsername(),
+		"RotationID":     rotationID,
+	})
+
+	rotation, err := sl.LoadRotation(rotationID)
+	if err != nil {
+		return nil, err
+	}
+	err = sl.ExpandRotation(rotation)
+	if err != nil {
+		return nil, err
+	}
+	current, err := rotation.ShiftNumberForTime(now)
+	if err != nil {
+		return nil, err
+	}
+	if current < 0 {
+		current = 0
+	}
+	first := current - CalendarPastShifts
+	if first < 0 {
+		first = 0
+	}
+
+	// Guessed shifts are carried from one to the next, as in Guess.
+	rotation = rotation.Clone(true)
+	calendar := &ical.Calendar{
+		ProductID: calendarProductID,
+		Name:      rotation.Name,
+	}
+	for shiftNumber := first; shiftNumber < current+CalendarShifts; shiftNumber++ {
+		shift, created, err := sl.getShiftForGuess(rotation, shiftNumber)
+		if err != nil {
+			return nil, err
+		}
+		if shiftNumber < current && created {
+			continue
+		}
+		if shiftNumber >= current && shift.Status == store.ShiftStatusOpen {
+			sl.guessCalendarShift(rotation, shiftNumber, shift, logger)
+		}
+		rotation.markShiftUsersEvents(shiftNumber, shift)
+		rotation.markShiftUsersServed(shiftNumber, shift)
+
+		event, err := sl.shiftCalendarEvent(rotation, shiftNumber, shift)
+		if err != nil {
+			return nil, err
+		}
+		calendar.Events = append(calendar.Events, event)
+	}
+
+	logger.Debugf("%s loaded the calendar of %s, %v events.",
+		sl.actingUser.Markdown(), rotation.Markdown(), len(calendar.Events))
+	return calendar.Encode(now), nil
+}
+
+// guessCalendarShift autofills the open shift, without storing it. If the
+// shift can not be autofilled, it is left as it was.
+func (sl *solarLottery) guessCalendarShift(rotation *Rotation, shiftNumber int, shift *Shift, logger bot.Logger) {
+	autofiller := sl.Dependencies.Autofillers[rotation.Type]
+	if autofiller == nil {
+		logger.Debugf("calendar: unsupported rotation type %s", rotation.Type)
+		return
+	}
+	added, roles, err := rotation.ForShift(shift).autofill(autofiller, shiftNumber, shift, shiftRand(calendarSeed, shiftNumber), logger)
+	if err != nil {
+		logger.Debugf("calendar: failed to guess shift %v: %v", shiftNumber, err)
+		return
+	}
+	_, err = sl.joinShift(rotation, shiftNumber, shift, added, roles, false)
+	if err != nil {
+		logger.Debugf("calendar: failed to guess shift %v: %v", shiftNumber, err)
+	}
+}
+
+// UserCalendar returns the token owner's shifts, and their personal
+// unavailability.
+func (sl *solarLottery) UserCalendar(token string, now time.Time) ([]byte, error) {
+	err := sl.Filter(
+		withCalendarToken(token),
+		withActingUserExpanded,
+	)
+	if err != nil {
+		return nil, err
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":       "sl.UserCalendar",
+		"ActingUsername": sl.actingUser.MattermostUsername(),
+	})
+
+	calendar := &ical.Calendar{
+		ProductID: calendarProductID,
+		Name:      fmt.Sprintf("%s (%s)", config.ApplicationName, sl.actingUser.MattermostUsername()),
+	}
+	rotations := map[string]*Rotation{}
+	for i, storedEvent := range sl.actingUser.Events {
+		event, err := sl.userCalendarEvent(rotations, i, storedEvent)
+		if err != nil {
+			return nil, err
+		}
+		calendar.Events = append(calendar.Events, event)
+	}
+
+	logger.Debugf("%s loaded their calendar, %v events.",
+		sl.actingUser.Markdown(), len(calendar.Events))
+	return calendar.Encode(now), nil
+}
+
+// userCalendarEvent converts a user's event. Shift events are timed by their
+// rotation's hand-off, the other events are all-day. rotations caches the
+// rotations that have been loaded.
+func (sl *solarLottery) userCalendarEvent(rotations map[string]*Rotation, i int, storedEvent store.Event) (*ical.Event, error) {
+	if storedEvent.Type == store.EventTypeShift {
+		rotation, ok := rotations[storedEvent.RotationID]
+		if !ok {
+			var err error
+			rotation, err = sl.LoadRotation(storedEvent.RotationID)
+			if err != nil {
+				// The rotation may have been deleted, fall back to the dates.
+				sl.Logger.Debugf("failed to load rotation %s for a calendar event: %v", storedEvent.RotationID, err)
+				rotation = nil
+			} else {
+				err = sl.ExpandRotation(rotation)
+				if err != nil {
+					return nil, err
+				}
+			}
+			rotations[storedEvent.RotationID] = rotation
+		}
+		if rotation != nil {
+			shift, err := sl.loadShift(rotation, storedEvent.ShiftNumber)
+			if err == store.ErrNotFound {
+				shift, err = rotation.makeShift(storedEvent.ShiftNumber)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return sl.shiftCalendarEvent(rotation, storedEvent.ShiftNumber, shift)
+		}
+	}
+
+	start, end, allDay, err := ParseEventTimes(storedEvent)
+	if err != nil {
+		return nil, err
+	}
+	summary := "Unavailable"
+	if storedEvent.Type == store.EventTypeShift {
+		summary = fmt.Sprintf("Shift %s#%v", storedEvent.RotationID, storedEvent.ShiftNumber)
+	}
+	return &ical.Event{
+		UID:     fmt.Sprintf("%s-%s-%s-%v@%s", storedEvent.Type, sl.actingMattermostUserID, storedEvent.Start, i, sl.Config.PluginID),
+		Start:   start,
+		End:     end,
+		AllDay:  allDay,
+		Summary: summary,
+	}, nil
+}
+
+// shiftCalendarEvent converts a shift, timed by ShiftDatesForNumber. The
+// shifts that are not filled yet are tentative.
+func (sl *solarLottery) shiftCalendarEvent(rotation *Rotation, shiftNumber int, shift *Shift) (*ical.Event, error) {
+	start, end, err := rotation.ShiftDatesForNumber(shiftNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := []string{}
+	description := []string{"Status: " + shift.Status}
+	for id := range shift.MattermostUserIDs {
+		name := id
+		user := rotation.Users[id]
+		if user != nil && user.MattermostUser != nil {
+			name = user.MattermostUsername()
+		}
+		if shift.Roles[id] != "" {
+			name += " (" + shift.Roles[id] + ")"
+		}
+		usernames = append(usernames, name)
+	}
+	sort.Strings(usernames)
+	if len(usernames) > 0 {
+		description = append(description, "Users: "+strings.Join(usernames, ", "))
+	}
+
+	summary := shift.Markdown()
+	if len(usernames) > 0 {
+		summary += ": " + strings.Join(usernames, ", ")
+	}
+	return &ical.Event{
+		UID:         fmt.Sprintf("%s-%v@%s", rotation.RotationID, shiftNumber, sl.Config.PluginID),
+		Start:       start,
+		End:         end,
+		Summary:     summary,
+		Description: strings.Join(description, "\n"),
+		Tentative:   shift.Revision == 0 || shift.Status == store.ShiftStatusOpen,
+	}, nil
+}
+
+// newCalendarToken returns a random secret token, safe to use in a URL.
+func newCalendarToken() (string, error) {
+	token := make([]byte, 32)
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate calendar token")
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
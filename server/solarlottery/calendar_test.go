@@ -0,0 +1,67 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store/mock_store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+func TestUserCalendar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	calendarStore := mock_store.NewMockCalendarStore(ctrl)
+	calendarStore.EXPECT().LoadCalendarTokenUserID("good-token").AnyTimes().Return("user", nil)
+	calendarStore.EXPECT().LoadCalendarTokenUserID("bad-token").AnyTimes().Return("", store.ErrNotFound)
+
+	user := &User{
+		User:           store.NewUser("user"),
+		MattermostUser: &model.User{Id: "user", Username: "user-name"},
+	}
+	user.Events = []store.Event{
+		{Type: store.EventTypePersonal, Start: "2020-02-01", End: "2020-02-03"},
+	}
+	newSL := func() *solarLottery {
+		return &solarLottery{
+			Logger: &bot.NilLogger{},
+			Config: Config{
+				Config: &config.Config{PluginID: "test-plugin"},
+				Dependencies: &Dependencies{
+					CalendarStore: calendarStore,
+					Logger:        &bot.NilLogger{},
+				},
+			},
+			actingMattermostUserID: "user",
+			actingUser:             user,
+		}
+	}
+
+	for _, token := range []string{"", "bad-token"} {
+		_, err := newSL().UserCalendar(token, time.Now())
+		require.Equal(t, ErrNotAuthorized, errors.Cause(err), token)
+	}
+
+	data, err := newSL().UserCalendar("good-token", time.Now())
+	require.NoError(t, err)
+	ics := string(data)
+	require.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+	require.Contains(t, ics, "X-WR-CALNAME:"+config.ApplicationName+" (user-name)\r\n")
+	require.Contains(t, ics, "DTSTART;VALUE=DATE:20200201\r\n")
+	require.Contains(t, ics, "DTEND;VALUE=DATE:20200203\r\n")
+	require.Contains(t, ics, "SUMMARY:Unavailable\r\n")
+	require.Contains(t, ics, "@test-plugin\r\n")
+}
@@ -20,15 +20,22 @@ type Event struct {
 	EndTime   time.Time
 }
 
+// NewShiftEvent returns the event blocking the shift's users, from the start
+// of the shift to the end of the Grace periods that follow it. Shift events
+// are timed, so that windows shorter than a day block their users too.
 func NewShiftEvent(rotation *Rotation, shiftNumber int, shift *Shift) Event {
+	graceShifts := rotation.Grace
+	if len(rotation.Windows) > 0 {
+		graceShifts *= len(rotation.Windows)
+	}
 	s, _, _ := rotation.ShiftDatesForNumber(shiftNumber)
-	_, e, _ := rotation.ShiftDatesForNumber(shiftNumber + rotation.Grace)
+	_, e, _ := rotation.ShiftDatesForNumber(shiftNumber + graceShifts)
 
 	return Event{
 		Event: store.Event{
 			Type:        store.EventTypeShift,
-			Start:       s.Format(DateFormat),
-			End:         e.Format(DateFormat),
+			Start:       s.UTC().Format(time.RFC3339),
+			End:         e.UTC().Format(time.RFC3339),
 			RotationID:  rotation.RotationID,
 			ShiftNumber: shiftNumber,
 		},
@@ -49,6 +56,15 @@ func NewPersonalEvent(startTime, endTime time.Time) Event {
 	}
 }
 
+// newTimedPersonalEvent returns a personal event for the time of a shift, see
+// NewShiftEvent.
+func newTimedPersonalEvent(startTime, endTime time.Time) Event {
+	event := NewPersonalEvent(startTime, endTime)
+	event.Start = startTime.UTC().Format(time.RFC3339)
+	event.End = endTime.UTC().Format(time.RFC3339)
+	return event
+}
+
 func (event Event) Markdown() string {
 	return fmt.Sprintf("%s: %s to %s",
 		event.Type, event.Start, event.End)
@@ -58,6 +74,7 @@ func (sl *solarLottery) AddEvent(mattermostUsernames string, event Event) error
 	err := sl.Filter(
 		withActingUserExpanded,
 		withMattermostUsersExpanded(mattermostUsernames),
+		withSelfOrAdmin,
 	)
 	if err != nil {
 		return err
@@ -69,10 +86,12 @@ func (sl *solarLottery) AddEvent(mattermostUsernames string, event Event) error
 		"Event":               event,
 	})
 
+	before := usersFields(sl.users)
 	err = sl.addEventToUsers(sl.users, event, true)
 	if err != nil {
 		return err
 	}
+	sl.auditUsers(AuditUserUnavailable, sl.users, before)
 
 	logger.Infof("%s added event %s to %s.",
 		sl.actingUser.Markdown(), event.Markdown(), sl.users.MarkdownWithSkills())
@@ -83,6 +102,7 @@ func (sl *solarLottery) DeleteEvents(mattermostUsernames string, startDate, endD
 	err := sl.Filter(
 		withActingUserExpanded,
 		withMattermostUsersExpanded(mattermostUsernames),
+		withSelfOrAdmin,
 	)
 	if err != nil {
 		return err
@@ -95,23 +115,27 @@ func (sl *solarLottery) DeleteEvents(mattermostUsernames string, startDate, endD
 		"EndDate":             endDate,
 	})
 
+	before := usersFields(sl.users)
 	for _, user := range sl.users {
 		intervalStart, intervalEnd, err := ParseDatePair(startDate, endDate)
 		if err != nil {
 			return err
 		}
 
-		_, err = user.OverlapEvents(intervalStart, intervalEnd, true)
-		if err != nil {
-			return errors.WithMessagef(err, "failed to remove events from %s to %s", startDate, endDate)
-		}
-
-		_, err = sl.storeUserWelcomeNew(user)
+		_, err = sl.storeUserWelcomeNew(user, func(user *User) error {
+			_, err := user.OverlapEvents(intervalStart, intervalEnd, true)
+			if err != nil {
+				return errors.WithMessagef(err, "failed to remove events from %s to %s", startDate, endDate)
+			}
+			return nil
+		})
 		if err != nil {
 			return errors.WithMessagef(err, "failed to update user %s", user.Markdown())
 		}
 	}
 
+	sl.auditUsers(AuditUserDeleteEvents, sl.users, before)
+
 	logger.Infof("%s deleted events from %s to %s from users %s.",
 		sl.actingUser.Markdown(), startDate, endDate, sl.users.MarkdownWithSkills())
 	return nil
@@ -132,6 +156,23 @@ func ParseDatePair(start, end string) (time.Time, time.Time, error) {
 	return s, e, nil
 }
 
+// ParseEventTimes returns the start and end of a stored event, and whether it
+// is dated rather than timed. Shift events, and the personal events of
+// declined shifts, are stored as time.RFC3339 timestamps, other personal
+// events, and the shift events stored before them, as dates.
+func ParseEventTimes(event store.Event) (time.Time, time.Time, bool, error) {
+	start, startErr := time.Parse(time.RFC3339, event.Start)
+	end, endErr := time.Parse(time.RFC3339, event.End)
+	if startErr != nil || endErr != nil {
+		start, end, err := ParseDatePair(event.Start, event.End)
+		return start, end, true, err
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, false, errors.Errorf("event start %v after end %v", start, end)
+	}
+	return start, end, false, nil
+}
+
 type eventSorter struct {
 	events []store.Event
 	by     func(p1, p2 store.Event) bool
@@ -126,3 +126,130 @@ func withMattermostUsersExpanded(mattermostUsernames string) func(sl *solarLotte
 		return nil
 	}
 }
+
+// ErrNotAuthorized is returned when the acting user is not allowed to do
+// what they tried.
+var ErrNotAuthorized = errors.New("not authorized")
+
+// isActingUserAdmin returns true for the plugin admins, and for the bot, which
+// runs the autopilot.
+func (sl *solarLottery) isActingUserAdmin() (bool, error) {
+	if sl.Config.Config != nil && sl.actingMattermostUserID == sl.Config.BotUserID {
+		return true, nil
+	}
+	return sl.PluginAPI.IsPluginAdmin(sl.actingMattermostUserID)
+}
+
+func withActingUserAdmin(sl *solarLottery) error {
+	isAdmin, err := sl.isActingUserAdmin()
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return errors.WithMessagef(ErrNotAuthorized, "%s is not a plugin admin", sl.actingUserMarkdown())
+	}
+	return nil
+}
+
+// withRotationManager requires the acting user to be an owner of the
+// rotation, or a plugin admin.
+func withRotationManager(rotation *Rotation) func(sl *solarLottery) error {
+	return func(sl *solarLottery) error {
+		if rotation.Owners[sl.actingMattermostUserID] != "" {
+			return nil
+		}
+		isAdmin, err := sl.isActingUserAdmin()
+		if err != nil {
+			return err
+		}
+		if !isAdmin {
+			return errors.WithMessagef(ErrNotAuthorized, "%s is not an owner of rotation %s, nor a plugin admin",
+				sl.actingUserMarkdown(), rotation.Markdown())
+		}
+		return nil
+	}
+}
+
+// withSkillManager requires the acting user to be a plugin admin, or an owner
+// of a rotation that is not archived, for setting users' skill levels, which
+// are not tied to any one rotation.
+func withSkillManager(sl *solarLottery) error {
+	isAdmin, err := sl.isActingUserAdmin()
+	if err != nil {
+		return err
+	}
+	if isAdmin {
+		return nil
+	}
+	err = sl.Filter(withKnownRotations)
+	if err != nil {
+		return err
+	}
+	for rotationID := range sl.knownRotations {
+		rotation, err := sl.RotationStore.LoadRotation(rotationID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				continue
+			}
+			return err
+		}
+		if !rotation.IsArchived && rotation.Owners[sl.actingMattermostUserID] != "" {
+			return nil
+		}
+	}
+	return errors.WithMessagef(ErrNotAuthorized, "%s is not an owner of a rotation, nor a plugin admin",
+		sl.actingUserMarkdown())
+}
+
+// withSelfOrRotationManager lets users act on themselves, and the rotation
+// managers on anyone. Must follow withMattermostUsersExpanded.
+func withSelfOrRotationManager(rotation *Rotation) func(sl *solarLottery) error {
+	return func(sl *solarLottery) error {
+		if sl.isSelf() {
+			return nil
+		}
+		return withRotationManager(rotation)(sl)
+	}
+}
+
+// withSelfOrAdmin lets users act on themselves, and the plugin admins on
+// anyone. Must follow withMattermostUsersExpanded.
+func withSelfOrAdmin(sl *solarLottery) error {
+	if sl.isSelf() {
+		return nil
+	}
+	return withActingUserAdmin(sl)
+}
+
+// withCalendarToken authenticates the acting user by their calendar token,
+// for the feed requests made by calendar apps without a Mattermost session.
+func withCalendarToken(token string) func(sl *solarLottery) error {
+	return func(sl *solarLottery) error {
+		if token == "" {
+			return errors.WithMessage(ErrNotAuthorized, "calendar token is required")
+		}
+		mattermostUserID, err := sl.CalendarStore.LoadCalendarTokenUserID(token)
+		if err == store.ErrNotFound {
+			return errors.WithMessage(ErrNotAuthorized, "invalid calendar token")
+		}
+		if err != nil {
+			return err
+		}
+		if mattermostUserID != sl.actingMattermostUserID {
+			sl.actingMattermostUserID = mattermostUserID
+			sl.actingUser = nil
+		}
+		return nil
+	}
+}
+
+func (sl *solarLottery) isSelf() bool {
+	return len(sl.users) == 1 && sl.users[sl.actingMattermostUserID] != nil
+}
+
+func (sl *solarLottery) actingUserMarkdown() string {
+	if sl.actingUser != nil {
+		return sl.actingUser.Markdown()
+	}
+	return sl.actingMattermostUserID
+}
@@ -4,6 +4,7 @@
 package solarlottery
 
 import (
+	"math/rand"
 	"time"
 
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill"
@@ -12,18 +13,20 @@ import (
 )
 
 type Forecaster interface {
-	Guess(rotation *Rotation, startingShiftNumber, numShifts int) ([]*Shift, error)
-	ForecastRotation(rotation *Rotation, startingShiftNumber, numShifts, sampleSize int) (*Forecast, error)
-	ForecastUser(mattermostUsername string, rotation *Rotation, numShifts, sampleSize int, now time.Time) ([]float64, error)
+	Guess(rotation *Rotation, startingShiftNumber, numShifts int, seed int64) ([]*Shift, error)
+	ForecastRotation(rotation *Rotation, startingShiftNumber, numShifts, sampleSize int, seed int64) (*Forecast, error)
+	ForecastUser(mattermostUsername string, rotation *Rotation, numShifts, sampleSize int, now time.Time, seed int64) ([]float64, error)
 }
 
 type Forecast struct {
 	StartingShift                int
 	NumShifts                    int
 	SampleSize                   int
+	Seed                         int64
 	CountErrInsufficientForNeeds int
 	CountErrInsufficientForSize  int
 	CountErrSizeExceeded         int
+	CountErrSearchTruncated      int
 	NeedErrCounts                map[string]int
 	ShiftErrCounts               []int
 
@@ -34,7 +37,7 @@ type Forecast struct {
 	UserCounts      map[string]int
 }
 
-func (sl *solarLottery) ForecastRotation(rotation *Rotation, startingShiftNumber, numShifts, sampleSize int) (*Forecast, error) {
+func (sl *solarLottery) ForecastRotation(rotation *Rotation, startingShiftNumber, numShifts, sampleSize int, seed int64) (*Forecast, error) {
 	err := sl.Filter(
 		withActingUserExpanded,
 		withRotationExpanded(rotation),
@@ -48,25 +51,29 @@ func (sl *solarLottery) ForecastRotation(rotation *Rotation, startingShiftNumber
 		"NumShifts":           numShifts,
 		"StartingShiftNumber": startingShiftNumber,
 		"RotationID":          rotation.RotationID,
+		"Seed":                seed,
 	})
 
 	f := &Forecast{
 		StartingShift:   startingShiftNumber,
 		NumShifts:       numShifts,
 		SampleSize:      sampleSize,
+		Seed:            seed,
 		NeedErrCounts:   map[string]int{},
 		ShiftErrCounts:  make([]int, numShifts),
 		UserShiftCounts: map[string][]int{},
 		UserCounts:      map[string]int{},
 	}
 
+	// Each sample gets its own seed, derived from the forecast's.
+	seeds := rand.New(rand.NewSource(seed))
 GUESS:
 	for i := 0; i < sampleSize; i++ {
 		var shifts []*Shift
 		// Guess' logs are too verbose - suppress
 		prevLogger := sl.Logger
 		sl.Logger = &bot.NilLogger{}
-		shifts, err = sl.Guess(rotation, startingShiftNumber, numShifts)
+		shifts, err = sl.Guess(rotation, startingShiftNumber, numShifts, seeds.Int63())
 		sl.Logger = prevLogger
 		var aerr *autofill.Error
 		if err != nil {
@@ -89,6 +96,9 @@ GUESS:
 
 			case autofill.ErrSizeExceeded:
 				f.CountErrSizeExceeded++
+
+			case autofill.ErrSearchTruncated:
+				f.CountErrSearchTruncated++
 			}
 
 			f.ShiftErrCounts[aerr.ShiftNumber]++
@@ -112,7 +122,7 @@ GUESS:
 	return f, nil
 }
 
-func (sl *solarLottery) ForecastUser(mattermostUsername string, rotation *Rotation, numShifts, sampleSize int, now time.Time) ([]float64, error) {
+func (sl *solarLottery) ForecastUser(mattermostUsername string, rotation *Rotation, numShifts, sampleSize int, now time.Time, seed int64) ([]float64, error) {
 	err := sl.Filter(
 		withActingUserExpanded,
 		withMattermostUsersExpanded(mattermostUsername),
@@ -147,12 +157,13 @@ func (sl *solarLottery) ForecastUser(mattermostUsername string, rotation *Rotati
 
 	shiftCounts := make([]float64, numShifts)
 
+	seeds := rand.New(rand.NewSource(seed))
 GUESS:
 	for i := 0; i < sampleSize; i++ {
 		var shifts []*Shift
 		prevLogger := sl.Logger
 		sl.Logger = &bot.NilLogger{}
-		shifts, err = sl.Guess(rotation, shiftNumber, numShifts)
+		shifts, err = sl.Guess(rotation, shiftNumber, numShifts, seeds.Int63())
 		sl.Logger = prevLogger
 		if err != nil {
 			continue GUESS
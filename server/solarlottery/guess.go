@@ -4,12 +4,27 @@
 package solarlottery
 
 import (
+	"math/rand"
+	"time"
+
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
 	"github.com/pkg/errors"
 )
 
-func (sl *solarLottery) Guess(rotation *Rotation, startingShiftNumber int, numShifts int) ([]*Shift, error) {
+// NewSeed returns a seed for Guess, when one is not explicitly provided.
+func NewSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// shiftRand returns the source of randomness for filling a shift, derived
+// from the guess seed and the shift number, so that the fill of any single
+// shift can be replayed with the same seed.
+func shiftRand(seed int64, shiftNumber int) *rand.Rand {
+	return rand.New(rand.NewSource(seed + int64(shiftNumber)))
+}
+
+func (sl *solarLottery) Guess(rotation *Rotation, startingShiftNumber int, numShifts int, seed int64) ([]*Shift, error) {
 	err := sl.Filter(
 		withActingUserExpanded,
 		withRotationExpanded(rotation),
@@ -23,6 +38,7 @@ func (sl *solarLottery) Guess(rotation *Rotation, startingShiftNumber int, numSh
 		"NumShifts":      numShifts,
 		"ShiftNumber":    startingShiftNumber,
 		"RotationID":     rotation.RotationID,
+		"Seed":           seed,
 	})
 	rotation = rotation.Clone(true)
 
@@ -45,12 +61,13 @@ func (sl *solarLottery) Guess(rotation *Rotation, startingShiftNumber int, numSh
 				return nil, errors.Errorf("unsupported rotation type %s", rotation.Type)
 			}
 			var added UserMap
-			added, err = autofiller.FillShift(rotation, shiftNumber, shift, logger)
+			var roles map[string]string
+			added, roles, err = rotation.ForShift(shift).autofill(autofiller, shiftNumber, shift, shiftRand(seed, shiftNumber), logger)
 			if err != nil {
 				return nil, err
 			}
 
-			_, err = sl.joinShift(rotation, shiftNumber, shift, added, false)
+			_, err = sl.joinShift(rotation, shiftNumber, shift, added, roles, false)
 			if err != nil {
 				return nil, err
 			}
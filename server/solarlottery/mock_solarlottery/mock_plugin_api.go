@@ -48,6 +48,22 @@ func (mr *MockPluginAPIMockRecorder) Clean() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clean", reflect.TypeOf((*MockPluginAPI)(nil).Clean))
 }
 
+// GetFile mocks base method
+func (m *MockPluginAPI) GetFile(arg0 string) (*model.FileInfo, []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFile", arg0)
+	ret0, _ := ret[0].(*model.FileInfo)
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetFile indicates an expected call of GetFile
+func (mr *MockPluginAPIMockRecorder) GetFile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockPluginAPI)(nil).GetFile), arg0)
+}
+
 // GetMattermostUser mocks base method
 func (m *MockPluginAPI) GetMattermostUser(arg0 string) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -23,7 +23,7 @@ func UsersQualifiedForNeed(users UserMap, need *store.Need) UserMap {
 }
 
 func UnmetNeeds(needs store.Needs, users UserMap) store.Needs {
-	work := append(store.Needs{}, needs...)
+	work := needs.Clone()
 	for i, need := range work {
 		for _, user := range users {
 			if IsUserQualifiedForNeed(user, need) {
@@ -4,7 +4,11 @@
 package solarlottery
 
 import (
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
@@ -17,11 +21,23 @@ type Period struct {
 var _ pflag.Value = (*Period)(nil)
 
 const (
-	EveryWeek     = "1w"
-	EveryTwoWeeks = "2w"
-	EveryMonth    = "1m"
+	EveryDay         = "1d"
+	EveryBusinessDay = "1bd"
+	EveryWeek        = "1w"
+	EveryTwoWeeks    = "2w"
+	EveryMonth       = "1m"
 )
 
+// Period units, a period is a number followed by a unit, e.g. `3d` or `4w`.
+const (
+	periodDay         = "d"
+	periodBusinessDay = "bd"
+	periodWeek        = "w"
+	periodMonth       = "m"
+)
+
+var periodRegexp = regexp.MustCompile(`^([0-9]+)(bd|d|w|m)$`)
+
 func (p *Period) String() string {
 	return p.value
 }
@@ -32,14 +48,90 @@ func (p *Period) Type() string {
 
 func (p *Period) Set(in string) error {
 	switch strings.ToLower(in) {
-	case EveryWeek, "w", "week":
+	case EveryDay, "d", "day", "daily":
+		p.value = EveryDay
+	case EveryBusinessDay, "bd", "business-day", "weekday", "weekdays":
+		p.value = EveryBusinessDay
+	case EveryWeek, "w", "week", "weekly":
 		p.value = EveryWeek
 	case EveryTwoWeeks, "2weeks", "biweekly", "bi-weekly":
 		p.value = EveryTwoWeeks
-	case EveryMonth, "m", "month":
+	case EveryMonth, "m", "month", "monthly":
 		p.value = EveryMonth
 	default:
-		return errors.Errorf("period must be `%s`, `%s` or `%s`", EveryWeek, EveryTwoWeeks, EveryMonth)
+		n, unit, err := parsePeriod(strings.ToLower(in))
+		if err != nil {
+			return errors.Errorf("period must be a number of days `Nd`, business days `Nbd`, weeks `Nw` or months `Nm`, e.g. `%s`, `3d`, `%s` or `%s`",
+				EveryDay, EveryTwoWeeks, EveryMonth)
+		}
+		p.value = fmt.Sprintf("%v%s", n, unit)
 	}
 	return nil
 }
+
+func parsePeriod(in string) (int, string, error) {
+	match := periodRegexp.FindStringSubmatch(in)
+	if match == nil {
+		return 0, "", errors.Errorf("invalid period %q", in)
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, "", err
+	}
+	if n < 1 {
+		return 0, "", errors.Errorf("invalid period %q", in)
+	}
+	return n, match[2], nil
+}
+
+// date returns t's calendar date as midnight UTC, so that the number of days
+// between dates can be computed regardless of DST.
+func date(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(date(to).Sub(date(from)) / DayDuration)
+}
+
+func isBusinessDay(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// nextBusinessDay returns t if it is a business day, otherwise the following
+// Monday.
+func nextBusinessDay(t time.Time) time.Time {
+	for !isBusinessDay(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// addBusinessDays adds n business days to t, which must be a business day.
+func addBusinessDays(t time.Time, n int) time.Time {
+	t = t.AddDate(0, 0, 7*(n/5))
+	for i := 0; i < n%5; i++ {
+		t = t.AddDate(0, 0, 1)
+		t = nextBusinessDay(t)
+	}
+	return t
+}
+
+// businessDaysBetween returns the number of business days in [from, to),
+// from must be a business day.
+func businessDaysBetween(from, to time.Time) int {
+	days := daysBetween(from, to)
+	if days <= 0 {
+		return 0
+	}
+	n := 5 * (days / 7)
+	t := from.AddDate(0, 0, 7*(days/7))
+	for i := 0; i < days%7; i++ {
+		if isBusinessDay(t) {
+			n++
+		}
+		t = t.AddDate(0, 0, 1)
+	}
+	return n
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils"
 )
 
 type Rotation struct {
@@ -24,7 +25,7 @@ func (rotation *Rotation) init(*solarLottery) error {
 		rotation.Users = UserMap{}
 	}
 	if rotation.StartTime.IsZero() {
-		start, err := time.Parse(DateFormat, rotation.Start)
+		start, err := ParseStartTime(rotation.Start, rotation.TimeZone, rotation.HandoffTime)
 		if err != nil {
 			return err
 		}
@@ -33,6 +34,31 @@ func (rotation *Rotation) init(*solarLottery) error {
 	return nil
 }
 
+// ParseStartTime returns the time the rotation's first shift starts, on the
+// start date, at the hand-off time of day in the time zone.
+func ParseStartTime(startDate, timeZone, handoffTime string) (time.Time, error) {
+	loc := time.UTC
+	if timeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(timeZone)
+		if err != nil {
+			return time.Time{}, errors.Errorf("invalid time zone %q", timeZone)
+		}
+	}
+	start, err := time.ParseInLocation(DateFormat, startDate, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if handoffTime != "" {
+		handoff, err := time.Parse(HandoffTimeFormat, handoffTime)
+		if err != nil {
+			return time.Time{}, errors.Errorf("invalid hand-off time %q, must be formatted as %s", handoffTime, HandoffTimeFormat)
+		}
+		start = time.Date(start.Year(), start.Month(), start.Day(), handoff.Hour(), handoff.Minute(), 0, 0, loc)
+	}
+	return start, nil
+}
+
 func (rotation *Rotation) Clone(deep bool) *Rotation {
 	newRotation := *rotation
 	newRotation.Rotation = rotation.Rotation.Clone(deep)
@@ -56,7 +82,7 @@ func (rotation *Rotation) WithUsers(users UserMap) *Rotation {
 // WithStart startDate must be prevalidated; failure to parse it as a Date leads
 // to the value staying unchanged, quietly.
 func (rotation *Rotation) WithStart(startDate string) *Rotation {
-	start, err := time.Parse(DateFormat, startDate)
+	start, err := ParseStartTime(startDate, rotation.TimeZone, rotation.HandoffTime)
 	if err != nil {
 		return rotation
 	}
@@ -69,7 +95,7 @@ func (rotation *Rotation) WithStart(startDate string) *Rotation {
 
 func (sl *solarLottery) ExpandRotation(rotation *Rotation) error {
 	if rotation.StartTime.IsZero() {
-		s, err := time.Parse(DateFormat, rotation.Start)
+		s, err := ParseStartTime(rotation.Start, rotation.TimeZone, rotation.HandoffTime)
 		if err != nil {
 			return err
 		}
@@ -102,11 +128,33 @@ func (rotation *Rotation) Markdown() string {
 func (rotation *Rotation) MarkdownBullets() string {
 	out := fmt.Sprintf("- **%s**\n", rotation.Name)
 	out += fmt.Sprintf("  - ID: `%s`.\n", rotation.RotationID)
+	if rotation.Type != "" {
+		out += fmt.Sprintf("  - Type: **%s**.\n", rotation.Type)
+	}
 	out += fmt.Sprintf("  - Starting: **%s**.\n", rotation.Start)
+	if rotation.TimeZone != "" || rotation.HandoffTime != "" {
+		out += fmt.Sprintf("  - Hand-off: **%s**.\n", rotation.StartTime.Format("15:04 MST"))
+	}
 	out += fmt.Sprintf("  - Period: **%s**.\n", rotation.Period)
 	out += fmt.Sprintf("  - Size: **%v** people.\n", rotation.Size)
 	out += fmt.Sprintf("  - Needs (%v): %s.\n", len(rotation.Needs), rotation.Needs.Markdown())
-	out += fmt.Sprintf("  - Grace: **%v** shifts.\n", rotation.Grace)
+	if len(rotation.Windows) > 0 {
+		out += fmt.Sprintf("  - Windows (%v):\n%s", len(rotation.Windows), utils.Indent(rotation.MarkdownWindows(), "    "))
+	}
+	if len(rotation.Roles) > 0 {
+		out += fmt.Sprintf("  - Roles (%v):\n%s", len(rotation.Roles), utils.Indent(rotation.MarkdownRoles(), "    "))
+	}
+	if len(rotation.Windows) > 0 {
+		out += fmt.Sprintf("  - Grace: **%v** periods.\n", rotation.Grace)
+	} else {
+		out += fmt.Sprintf("  - Grace: **%v** shifts.\n", rotation.Grace)
+	}
+	if rotation.Weighting != "" {
+		out += fmt.Sprintf("  - Weighting: **%s**.\n", rotation.Weighting)
+	}
+	if len(rotation.Owners) > 0 {
+		out += fmt.Sprintf("  - Owners (%v): %s.\n", len(rotation.Owners), rotation.MarkdownOwners())
+	}
 	out += fmt.Sprintf("  - Users (%v): %s.\n", len(rotation.MattermostUserIDs), rotation.Users.MarkdownWithSkills())
 
 	if rotation.Autopilot.On {
@@ -114,6 +162,9 @@ func (rotation *Rotation) MarkdownBullets() string {
 		out += fmt.Sprintf("    - Auto-start: **%v**\n", rotation.Autopilot.StartFinish)
 		out += fmt.Sprintf("    - Auto-fill: **%v**, %v days prior to start\n", rotation.Autopilot.Fill, rotation.Autopilot.FillPrior)
 		out += fmt.Sprintf("    - Notify users in advance: **%v**, %v days prior to transition\n", rotation.Autopilot.Notify, rotation.Autopilot.NotifyPrior)
+		if rotation.Autopilot.AckPrior != 0 {
+			out += fmt.Sprintf("    - Users must acknowledge shifts %v prior to start, escalate to admins %v prior to start\n", rotation.Autopilot.AckPrior, rotation.Autopilot.EscalatePrior)
+		}
 	} else {
 		out += fmt.Sprintf("  - Autopilot: **off**\n")
 	}
@@ -122,61 +173,118 @@ func (rotation *Rotation) MarkdownBullets() string {
 }
 
 func (rotation *Rotation) ChangeNeed(skill string, level Level, newNeed *store.Need) {
-	for i, need := range rotation.Needs {
+	rotation.Needs = changeNeed(rotation.Needs, skill, level, newNeed)
+}
+
+func (rotation *Rotation) DeleteNeed(skill string, level Level) error {
+	needs, ok := deleteNeed(rotation.Needs, skill, level)
+	if !ok {
+		return errors.Errorf("%s is not found in rotation %s", MarkdownSkillLevel(skill, level), rotation.Markdown())
+	}
+	rotation.Needs = needs
+	return nil
+}
+
+func changeNeed(needs store.Needs, skill string, level Level, newNeed *store.Need) store.Needs {
+	for i, need := range needs {
 		if need.Skill == skill && need.Level == int(level) {
-			rotation.Needs[i] = newNeed
-			return
+			needs[i] = newNeed
+			return needs
 		}
 	}
-	rotation.Needs = append(rotation.Needs, newNeed)
+	return append(needs, newNeed)
 }
 
-func (rotation *Rotation) DeleteNeed(skill string, level Level) error {
-	for i, need := range rotation.Needs {
+func deleteNeed(needs store.Needs, skill string, level Level) (store.Needs, bool) {
+	for i, need := range needs {
 		if need.Skill == skill && need.Level == int(level) {
-			newNeeds := append(store.Needs{}, rotation.Needs[:i]...)
-			if i+1 < len(rotation.Needs) {
-				newNeeds = append(newNeeds, rotation.Needs[i+1:]...)
+			newNeeds := append(store.Needs{}, needs[:i]...)
+			if i+1 < len(needs) {
+				newNeeds = append(newNeeds, needs[i+1:]...)
 			}
-			rotation.Needs = newNeeds
-			return nil
+			return newNeeds, true
 		}
 	}
-	return errors.Errorf("%s is not found in rotation %s", MarkdownSkillLevel(skill, level), rotation.Markdown())
+	return needs, false
 }
 
 func (rotation *Rotation) ShiftRef(shiftNumber int) string {
 	return fmt.Sprintf("%s#%v", rotation.Name, shiftNumber)
 }
 
-func (rotation *Rotation) ShiftNumberForTime(t time.Time) (int, error) {
+// periodNumberForTime returns the number of the period t falls in, or -1 if
+// t is before the start.
+func (rotation *Rotation) periodNumberForTime(t time.Time) (int, error) {
 	if t.Before(rotation.StartTime) {
 		return -1, nil
 	}
+	n, unit, err := parsePeriod(rotation.Period)
+	if err != nil {
+		return -1, errors.Errorf("Invalid rotation period value %q", rotation.Period)
+	}
+	day := rotation.handoffDate(t)
+	startDay := date(rotation.StartTime)
+
+	switch unit {
+	case periodDay:
+		return daysBetween(startDay, day) / n, nil
 
-	switch rotation.Period {
-	case EveryWeek:
-		return int(t.Sub(rotation.StartTime) / WeekDuration), nil
-	case EveryTwoWeeks:
-		return int(t.Sub(rotation.StartTime) / (2 * WeekDuration)), nil
-	case EveryMonth:
-		y, m, d := rotation.StartTime.Date()
-		ty, tm, td := t.Date()
-		n := (ty*12 + int(tm)) - (y*12 + int(m))
-		if n <= 0 {
+	case periodWeek:
+		return daysBetween(startDay, day) / (7 * n), nil
+
+	case periodBusinessDay:
+		first := nextBusinessDay(startDay)
+		if day.Before(first) {
 			return -1, nil
 		}
-		if td < d {
-			n--
+		// Weekends belong to the preceding business day's shift.
+		return (businessDaysBetween(first, day.AddDate(0, 0, 1)) - 1) / n, nil
+
+	case periodMonth:
+		y, m, _ := startDay.Date()
+		ty, tm, _ := day.Date()
+		periodNumber := ((ty*12 + int(tm)) - (y*12 + int(m))) / n
+		// Start dates late in the month overflow into the next one, adjust.
+		for periodNumber > 0 && startDay.AddDate(0, periodNumber*n, 0).After(day) {
+			periodNumber--
 		}
-		return n, nil
-	default:
-		return -1, errors.Errorf("Invalid rotation period value %q", rotation.Period)
+		for !startDay.AddDate(0, (periodNumber+1)*n, 0).After(day) {
+			periodNumber++
+		}
+		return periodNumber, nil
+	}
+	return -1, errors.Errorf("Invalid rotation period value %q", rotation.Period)
+}
+
+// handoffDate returns the date of the hand-off that t follows, in the
+// rotation's time zone, as midnight UTC. Before the hand-off time of day, it is
+// the previous day.
+func (rotation *Rotation) handoffDate(t time.Time) time.Time {
+	t = t.In(rotation.StartTime.Location())
+	wallClock := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	handoff := time.Duration(rotation.StartTime.Hour())*time.Hour + time.Duration(rotation.StartTime.Minute())*time.Minute
+	day := date(wallClock.Add(-handoff))
+
+	// The wall clock skips or repeats around DST transitions, so check against
+	// the actual hand-off times.
+	if t.Before(rotation.handoffTime(day)) {
+		return day.AddDate(0, 0, -1)
+	}
+	if next := day.AddDate(0, 0, 1); !t.Before(rotation.handoffTime(next)) {
+		return next
 	}
+	return day
+}
+
+// handoffTime returns the hand-off time on the date (as returned by
+// handoffDate).
+func (rotation *Rotation) handoffTime(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(),
+		rotation.StartTime.Hour(), rotation.StartTime.Minute(), 0, 0, rotation.StartTime.Location())
 }
 
 func (rotation *Rotation) ShiftsForDates(startDate, endDate string) (first, numShifts int, err error) {
-	start, err := time.Parse(DateFormat, startDate)
+	start, err := time.ParseInLocation(DateFormat, startDate, rotation.StartTime.Location())
 	if err != nil {
 		return 0, 0, err
 	}
@@ -184,7 +292,7 @@ func (rotation *Rotation) ShiftsForDates(startDate, endDate string) (first, numS
 	if err != nil {
 		return 0, 0, err
 	}
-	end, err := time.Parse(DateFormat, endDate)
+	end, err := time.ParseInLocation(DateFormat, endDate, rotation.StartTime.Location())
 	if err != nil {
 		return 0, 0, err
 	}
@@ -199,32 +307,37 @@ func (rotation *Rotation) ShiftsForDates(startDate, endDate string) (first, numS
 	return startShiftNumber, endShiftNumber - startShiftNumber + 1, nil
 }
 
-func (rotation *Rotation) ShiftDatesForNumber(shiftNumber int) (time.Time, time.Time, error) {
+func (rotation *Rotation) periodDatesForNumber(periodNumber int) (time.Time, time.Time, error) {
+	n, unit, err := parsePeriod(rotation.Period)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Errorf("Invalid rotation period value %q", rotation.Period)
+	}
+
+	// Count the dates, then hand off at the time of day on them.
+	startDay := date(rotation.StartTime)
 	var begin, end time.Time
-	switch rotation.Period {
-	case EveryWeek:
-		begin = rotation.StartTime.Add(time.Duration(shiftNumber) * WeekDuration)
-		end = begin.Add(WeekDuration)
-
-	case EveryTwoWeeks:
-		begin = rotation.StartTime.Add(time.Duration(shiftNumber) * 2 * WeekDuration)
-		end = begin.Add(2 * WeekDuration)
-
-	case EveryMonth:
-		y, month, d := rotation.StartTime.Date()
-		m := int(month-1) + shiftNumber
-		year := y + m/12
-		month = time.Month((m % 12) + 1)
-		begin = time.Date(year, month, d, 0, 0, 0, 0, rotation.StartTime.Location())
-		m++
-		year = y + m/12
-		month = time.Month((m % 12) + 1)
-		end = time.Date(year, month, d, 0, 0, 0, 0, rotation.StartTime.Location())
+	switch unit {
+	case periodDay:
+		begin = startDay.AddDate(0, 0, periodNumber*n)
+		end = startDay.AddDate(0, 0, (periodNumber+1)*n)
+
+	case periodWeek:
+		begin = startDay.AddDate(0, 0, periodNumber*7*n)
+		end = startDay.AddDate(0, 0, (periodNumber+1)*7*n)
+
+	case periodBusinessDay:
+		first := nextBusinessDay(startDay)
+		begin = addBusinessDays(first, periodNumber*n)
+		end = addBusinessDays(first, (periodNumber+1)*n)
+
+	case periodMonth:
+		begin = startDay.AddDate(0, periodNumber*n, 0)
+		end = startDay.AddDate(0, (periodNumber+1)*n, 0)
 
 	default:
 		return time.Time{}, time.Time{}, errors.Errorf("Invalid rotation period value %q", rotation.Period)
 	}
-	return begin, end, nil
+	return rotation.handoffTime(begin), rotation.handoffTime(end), nil
 }
 
 func (rotation *Rotation) ShiftUsers(shift *Shift) UserMap {
@@ -247,6 +360,9 @@ func (rotation *Rotation) markShiftUsersServed(shiftNumber int, shift *Shift) {
 		u := rotation.Users[mattermostUserID]
 		u.LastServed[rotation.RotationID] = shiftNumber
 	}
+	if rotation.Type == QueueType {
+		rotation.queueToBack(shift.MattermostUserIDs)
+	}
 }
 
 func (rotation *Rotation) markShiftUserServed(user *User, shiftNumber int, shift *Shift) {
@@ -16,6 +16,7 @@ func (sl *solarLottery) JoinRotation(mattermostUsernames string, rotation *Rotat
 	err := sl.Filter(
 		withActingUserExpanded,
 		withMattermostUsersExpanded(mattermostUsernames),
+		withSelfOrRotationManager(rotation),
 	)
 	if err != nil {
 		return nil, err
@@ -40,26 +41,34 @@ func (sl *solarLottery) JoinRotation(mattermostUsernames string, rotation *Rotat
 
 		// A new person may be given some slack - setting LastShiftNumber in the
 		// future guarantees they won't be selected until then.
-		user.LastServed[rotation.RotationID] = shiftNumber
-
-		user, err = sl.storeUserWelcomeNew(user)
+		user, err = sl.storeUserWelcomeNew(user, func(user *User) error {
+			user.LastServed[rotation.RotationID] = shiftNumber
+			return nil
+		})
 		if err != nil {
 			return added, err
 		}
+		added[user.MattermostUserID] = user
+	}
 
+	before := rotationFields(rotation)
+	err = sl.updateRotation(rotation, func(rotation *Rotation) error {
 		if rotation.MattermostUserIDs == nil {
 			rotation.MattermostUserIDs = store.IDMap{}
 		}
-
-		rotation.MattermostUserIDs[user.MattermostUserID] = user.MattermostUserID
-		sl.messageWelcomeToRotation(user, rotation)
-		added[user.MattermostUserID] = user
-	}
-
-	err = sl.RotationStore.StoreRotation(rotation.Rotation)
+		for id := range added {
+			rotation.MattermostUserIDs[id] = id
+			rotation.queueAdd(id)
+		}
+		return nil
+	})
 	if err != nil {
 		return added, errors.WithMessagef(err, "failed to store rotation %s", rotation.RotationID)
 	}
+	sl.audit(AuditRotationJoin, rotation.RotationID, NoShift, added, before, rotationFields(rotation))
+	for _, user := range added {
+		sl.messageWelcomeToRotation(user, rotation)
+	}
 	logger.Infof("%s added %s to %s.",
 		sl.actingUser.Markdown(), added.MarkdownWithSkills(), rotation.Markdown())
 	return added, nil
@@ -69,6 +78,7 @@ func (sl *solarLottery) LeaveRotation(mattermostUsernames string, rotation *Rota
 	err := sl.Filter(
 		withActingUserExpanded,
 		withMattermostUsersExpanded(mattermostUsernames),
+		withSelfOrRotationManager(rotation),
 	)
 	if err != nil {
 		return nil, err
@@ -88,23 +98,34 @@ func (sl *solarLottery) LeaveRotation(mattermostUsernames string, rotation *Rota
 			continue
 		}
 
-		delete(user.LastServed, rotation.RotationID)
-		_, err = sl.storeUserWelcomeNew(user)
+		_, err = sl.storeUserWelcomeNew(user, func(user *User) error {
+			delete(user.LastServed, rotation.RotationID)
+			return nil
+		})
 		if err != nil {
 			return deleted, err
 		}
-		delete(rotation.MattermostUserIDs, user.MattermostUserID)
-		if len(rotation.Users) > 0 {
-			delete(rotation.Users, user.MattermostUserID)
-		}
-		sl.messageLeftRotation(user, rotation)
 		deleted[user.MattermostUserID] = user
 	}
 
-	err = sl.RotationStore.StoreRotation(rotation.Rotation)
+	before := rotationFields(rotation)
+	err = sl.updateRotation(rotation, func(rotation *Rotation) error {
+		for id := range deleted {
+			delete(rotation.MattermostUserIDs, id)
+			rotation.queueDelete(id)
+		}
+		return nil
+	})
 	if err != nil {
 		return deleted, err
 	}
+	sl.audit(AuditRotationLeave, rotation.RotationID, NoShift, deleted, before, rotationFields(rotation))
+	for id, user := range deleted {
+		if len(rotation.Users) > 0 {
+			delete(rotation.Users, id)
+		}
+		sl.messageLeftRotation(user, rotation)
+	}
 
 	logger.Infof("%s removed from %s.", deleted.Markdown(), rotation.Markdown())
 	return deleted, nil
@@ -0,0 +1,103 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+func (sl *solarLottery) AddOwners(mattermostUsernames string, rotation *Rotation) (UserMap, error) {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withMattermostUsersExpanded(mattermostUsernames),
+		withRotationManager(rotation),
+	)
+	if err != nil {
+		return nil, err
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":            "sl.AddOwners",
+		"ActingUsername":      sl.actingUser.MattermostUsername(),
+		"RotationID":          rotation.RotationID,
+		"MattermostUsernames": mattermostUsernames,
+	})
+
+	before := rotationFields(rotation)
+	added := UserMap{}
+	err = sl.updateRotation(rotation, func(rotation *Rotation) error {
+		if rotation.Owners == nil {
+			rotation.Owners = store.IDMap{}
+		}
+		for id, user := range sl.users {
+			if rotation.Owners[id] != "" {
+				continue
+			}
+			rotation.Owners[id] = user.MattermostUsername()
+			added[id] = user
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to store rotation %s", rotation.RotationID)
+	}
+	sl.audit(AuditRotationOwnerAdd, rotation.RotationID, NoShift, added, before, rotationFields(rotation))
+
+	logger.Infof("%s added %s to the owners of %s.",
+		sl.actingUser.Markdown(), added.Markdown(), rotation.Markdown())
+	return added, nil
+}
+
+func (sl *solarLottery) DeleteOwners(mattermostUsernames string, rotation *Rotation) (UserMap, error) {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withMattermostUsersExpanded(mattermostUsernames),
+		withRotationManager(rotation),
+	)
+	if err != nil {
+		return nil, err
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":            "sl.DeleteOwners",
+		"ActingUsername":      sl.actingUser.MattermostUsername(),
+		"RotationID":          rotation.RotationID,
+		"MattermostUsernames": mattermostUsernames,
+	})
+
+	before := rotationFields(rotation)
+	deleted := UserMap{}
+	err = sl.updateRotation(rotation, func(rotation *Rotation) error {
+		for id, user := range sl.users {
+			if rotation.Owners[id] == "" {
+				continue
+			}
+			delete(rotation.Owners, id)
+			deleted[id] = user
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to store rotation %s", rotation.RotationID)
+	}
+	sl.audit(AuditRotationOwnerDelete, rotation.RotationID, NoShift, deleted, before, rotationFields(rotation))
+
+	logger.Infof("%s removed %s from the owners of %s.",
+		sl.actingUser.Markdown(), deleted.Markdown(), rotation.Markdown())
+	return deleted, nil
+}
+
+// MarkdownOwners lists the rotation owners by username.
+func (rotation *Rotation) MarkdownOwners() string {
+	names := []string{}
+	for _, username := range rotation.Owners {
+		names = append(names, "@"+username)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
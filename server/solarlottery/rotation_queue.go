@@ -0,0 +1,146 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+// QueueType is the type of the rotations filled in the order of their queue,
+// see autofill/queue. Only their queue changes as shifts start.
+const QueueType = "queue"
+
+// QueueOrder returns the IDs of the rotation's users in the order they are up
+// next: first as stored in Queue, then those missing from it (e.g. who joined
+// before the rotation had a queue), by when they last served.
+func (rotation *Rotation) QueueOrder() []string {
+	var order []string
+	queued := store.IDMap{}
+	for _, id := range rotation.Queue {
+		if rotation.MattermostUserIDs[id] == "" || queued[id] != "" {
+			continue
+		}
+		order = append(order, id)
+		queued[id] = store.NotEmpty
+	}
+
+	var missing []string
+	for id := range rotation.MattermostUserIDs {
+		if queued[id] == "" {
+			missing = append(missing, id)
+		}
+	}
+	lastServed := func(id string) int {
+		user := rotation.Users[id]
+		if user == nil {
+			return 0
+		}
+		return user.LastServed[rotation.RotationID]
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		li, lj := lastServed(missing[i]), lastServed(missing[j])
+		if li != lj {
+			return li < lj
+		}
+		return missing[i] < missing[j]
+	})
+
+	return append(order, missing...)
+}
+
+// QueueMove moves the user to position in the queue, 1 being the next up.
+func (rotation *Rotation) QueueMove(mattermostUserID string, position int) error {
+	order := rotation.QueueOrder()
+	from := queueIndex(order, mattermostUserID)
+	if from < 0 {
+		return errors.Errorf("%s is not in rotation %s", mattermostUserID, rotation.Markdown())
+	}
+	if position < 1 || position > len(order) {
+		return errors.Errorf("position must be between 1 and %v", len(order))
+	}
+
+	order = append(order[:from], order[from+1:]...)
+	to := position - 1
+	order = append(order[:to], append([]string{mattermostUserID}, order[to:]...)...)
+	rotation.Queue = order
+	return nil
+}
+
+// QueueSwap swaps the positions of two users in the queue.
+func (rotation *Rotation) QueueSwap(mattermostUserID1, mattermostUserID2 string) error {
+	order := rotation.QueueOrder()
+	i, j := queueIndex(order, mattermostUserID1), queueIndex(order, mattermostUserID2)
+	if i < 0 {
+		return errors.Errorf("%s is not in rotation %s", mattermostUserID1, rotation.Markdown())
+	}
+	if j < 0 {
+		return errors.Errorf("%s is not in rotation %s", mattermostUserID2, rotation.Markdown())
+	}
+	order[i], order[j] = order[j], order[i]
+	rotation.Queue = order
+	return nil
+}
+
+// QueueReset discards the explicit order, and re-queues the users by when they
+// last served.
+func (rotation *Rotation) QueueReset() {
+	rotation.Queue = nil
+	rotation.Queue = rotation.QueueOrder()
+}
+
+func (rotation *Rotation) queueAdd(mattermostUserID string) {
+	if queueIndex(rotation.Queue, mattermostUserID) >= 0 {
+		return
+	}
+	rotation.Queue = append(rotation.Queue, mattermostUserID)
+}
+
+func (rotation *Rotation) queueDelete(mattermostUserID string) {
+	i := queueIndex(rotation.Queue, mattermostUserID)
+	if i < 0 {
+		return
+	}
+	rotation.Queue = append(rotation.Queue[:i], rotation.Queue[i+1:]...)
+}
+
+// queueToBack moves the users to the back of the queue, preserving their
+// relative order.
+func (rotation *Rotation) queueToBack(mattermostUserIDs store.IDMap) {
+	var front, back []string
+	for _, id := range rotation.QueueOrder() {
+		if mattermostUserIDs[id] != "" {
+			back = append(back, id)
+		} else {
+			front = append(front, id)
+		}
+	}
+	rotation.Queue = append(front, back...)
+}
+
+func (rotation *Rotation) MarkdownQueue() string {
+	out := ""
+	for i, id := range rotation.QueueOrder() {
+		user := rotation.Users[id]
+		if user == nil {
+			out += fmt.Sprintf("%v. userID `%s`\n", i+1, id)
+			continue
+		}
+		out += fmt.Sprintf("%v. %s\n", i+1, user.MarkdownWithSkills())
+	}
+	return out
+}
+
+func queueIndex(queue []string, mattermostUserID string) int {
+	for i, id := range queue {
+		if id == mattermostUserID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+// testQueueRotation makes a rotation with users a, b, c, d, where d served the
+// longest ago.
+func testQueueRotation(queue ...string) *Rotation {
+	rotation := &Rotation{
+		Rotation: store.NewRotation("test"),
+		Users:    UserMap{},
+	}
+	rotation.RotationID = "test-ID"
+	for i, id := range []string{"a", "b", "c", "d"} {
+		user := &User{User: store.NewUser(id)}
+		user.LastServed[rotation.RotationID] = 4 - i
+		rotation.MattermostUserIDs[id] = id
+		rotation.Users[id] = user
+	}
+	rotation.Queue = queue
+	return rotation
+}
+
+func TestRotationQueue(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		queue       []string
+		updatef     func(*Rotation) error
+		expectError bool
+		expected    []string
+	}{
+		{
+			name:     "by last served",
+			expected: []string{"d", "c", "b", "a"},
+		},
+		{
+			name:     "missing users follow the queue, departed are dropped",
+			queue:    []string{"b", "gone", "a"},
+			expected: []string{"b", "a", "d", "c"},
+		},
+		{
+			name:  "move to front",
+			queue: []string{"a", "b", "c", "d"},
+			updatef: func(rotation *Rotation) error {
+				return rotation.QueueMove("c", 1)
+			},
+			expected: []string{"c", "a", "b", "d"},
+		},
+		{
+			name:  "move to back",
+			queue: []string{"a", "b", "c", "d"},
+			updatef: func(rotation *Rotation) error {
+				return rotation.QueueMove("a", 4)
+			},
+			expected: []string{"b", "c", "d", "a"},
+		},
+		{
+			name:  "move out of range",
+			queue: []string{"a", "b", "c", "d"},
+			updatef: func(rotation *Rotation) error {
+				return rotation.QueueMove("a", 5)
+			},
+			expectError: true,
+		},
+		{
+			name:  "move unknown",
+			queue: []string{"a", "b", "c", "d"},
+			updatef: func(rotation *Rotation) error {
+				return rotation.QueueMove("x", 1)
+			},
+			expectError: true,
+		},
+		{
+			name:  "swap",
+			queue: []string{"a", "b", "c", "d"},
+			updatef: func(rotation *Rotation) error {
+				return rotation.QueueSwap("d", "b")
+			},
+			expected: []string{"a", "d", "c", "b"},
+		},
+		{
+			name:  "reset",
+			queue: []string{"a", "b", "c", "d"},
+			updatef: func(rotation *Rotation) error {
+				rotation.QueueReset()
+				return nil
+			},
+			expected: []string{"d", "c", "b", "a"},
+		},
+		{
+			name:  "served to back",
+			queue: []string{"a", "b", "c", "d"},
+			updatef: func(rotation *Rotation) error {
+				rotation.queueToBack(store.IDMap{"c": store.NotEmpty, "a": store.NotEmpty})
+				return nil
+			},
+			expected: []string{"b", "d", "a", "c"},
+		},
+		{
+			name:  "shift served, queue rotation",
+			queue: []string{"a", "b", "c", "d"},
+			updatef: func(rotation *Rotation) error {
+				rotation.Type = QueueType
+				rotation.markShiftUsersServed(5, &Shift{Shift: store.NewShift("", "", store.IDMap{"a": store.NotEmpty})})
+				return nil
+			},
+			expected: []string{"b", "c", "d", "a"},
+		},
+		{
+			name:  "shift served, other rotation",
+			queue: []string{"a", "b", "c", "d"},
+			updatef: func(rotation *Rotation) error {
+				rotation.Type = "solar-lottery"
+				rotation.markShiftUsersServed(5, &Shift{Shift: store.NewShift("", "", store.IDMap{"a": store.NotEmpty})})
+				return nil
+			},
+			expected: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:  "add and delete",
+			queue: []string{"a", "b"},
+			updatef: func(rotation *Rotation) error {
+				rotation.queueDelete("a")
+				rotation.queueAdd("a")
+				rotation.queueAdd("b")
+				return nil
+			},
+			expected: []string{"b", "a", "d", "c"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rotation := testQueueRotation(tc.queue...)
+			if tc.updatef != nil {
+				err := tc.updatef(rotation)
+				if tc.expectError {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expected, rotation.QueueOrder())
+		})
+	}
+}
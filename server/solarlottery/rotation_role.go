@@ -0,0 +1,242 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+func (rotation *Rotation) FindRole(name string) (*store.ShiftRole, error) {
+	for _, role := range rotation.Roles {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+	return nil, errors.Errorf("role %s is not found in rotation %s", name, rotation.Markdown())
+}
+
+// SetRole adds the role as the last tier, or updates the one with the same
+// name in place.
+func (rotation *Rotation) SetRole(newRole *store.ShiftRole) error {
+	if newRole.Name == "" {
+		return errors.New("role must have a name")
+	}
+	if newRole.Count < 1 {
+		return errors.Errorf("role %s must have at least 1 user", newRole.Name)
+	}
+	for i, role := range rotation.Roles {
+		if role.Name == newRole.Name {
+			if newRole.Needs == nil {
+				newRole.Needs = role.Needs
+			}
+			rotation.Roles[i] = newRole
+			return nil
+		}
+	}
+	rotation.Roles = append(rotation.Roles, newRole)
+	return nil
+}
+
+func (rotation *Rotation) DeleteRole(name string) error {
+	for i, role := range rotation.Roles {
+		if role.Name == name {
+			rotation.Roles = append(rotation.Roles[:i:i], rotation.Roles[i+1:]...)
+			return nil
+		}
+	}
+	return errors.Errorf("role %s is not found in rotation %s", name, rotation.Markdown())
+}
+
+func (rotation *Rotation) ChangeRoleNeed(name, skill string, level Level, newNeed *store.Need) error {
+	role, err := rotation.FindRole(name)
+	if err != nil {
+		return err
+	}
+	role.Needs = changeNeed(role.Needs, skill, level, newNeed)
+	return nil
+}
+
+func (rotation *Rotation) DeleteRoleNeed(name, skill string, level Level) error {
+	role, err := rotation.FindRole(name)
+	if err != nil {
+		return err
+	}
+	needs, ok := deleteNeed(role.Needs, skill, level)
+	if !ok {
+		return errors.Errorf("%s is not found in role %s", MarkdownSkillLevel(skill, level), name)
+	}
+	role.Needs = needs
+	return nil
+}
+
+// rolesSize is the total number of users in a shift with roles.
+func (rotation *Rotation) rolesSize() int {
+	size := 0
+	for _, role := range rotation.Roles {
+		size += role.Count
+	}
+	return size
+}
+
+// checkRoles returns an error if the rotation has roles, and also needs of its
+// own or windows with a size or needs: shifts with roles are filled role by
+// role, with only the roles' counts and needs.
+func (rotation *Rotation) checkRoles() error {
+	if len(rotation.Roles) == 0 {
+		return nil
+	}
+	if len(rotation.Needs) > 0 {
+		return errors.Errorf("rotation %s has roles, its needs must be set on the roles", rotation.Markdown())
+	}
+	for _, window := range rotation.Windows {
+		if window.Size != 0 || len(window.Needs) > 0 {
+			return errors.Errorf("rotation %s has roles, window %s can not have a size or needs", rotation.Markdown(), window.Name)
+		}
+	}
+	return nil
+}
+
+// ShiftUsersByTier returns the shift's users grouped by role, in the order of
+// the rotation's roles. Users without a role, or all of the users if the
+// rotation has no roles, are in the last tier.
+func (rotation *Rotation) ShiftUsersByTier(shift *Shift) []UserMap {
+	var tiers []UserMap
+	assigned := map[string]bool{}
+	for _, role := range rotation.Roles {
+		tier := UserMap{}
+		for id, user := range rotation.ShiftUsers(shift) {
+			if shift.Roles[id] == role.Name {
+				tier[id] = user
+				assigned[id] = true
+			}
+		}
+		tiers = append(tiers, tier)
+	}
+	rest := UserMap{}
+	for id, user := range rotation.ShiftUsers(shift) {
+		if !assigned[id] {
+			rest[id] = user
+		}
+	}
+	if len(rest) > 0 || len(tiers) == 0 {
+		tiers = append(tiers, rest)
+	}
+	return tiers
+}
+
+// unmetRoles returns the needs and capacity unmet by the users assigned to
+// each role in the shift.
+func (rotation *Rotation) unmetRoles(shift *Shift) (store.Needs, int) {
+	var unmetNeeds store.Needs
+	unmetCapacity := 0
+	tiers := rotation.ShiftUsersByTier(shift)
+	for i, role := range rotation.Roles {
+		unmetNeeds = append(unmetNeeds, UnmetNeeds(role.Needs, tiers[i])...)
+		if len(tiers[i]) < role.Count {
+			unmetCapacity += role.Count - len(tiers[i])
+		}
+	}
+	return unmetNeeds, unmetCapacity
+}
+
+// autofill fills the shift using the autofiller. If the rotation has roles,
+// the users already in the shift without a role are first assigned to the
+// roles with room left, then the shift is filled role by role, and the roles
+// of the users are returned.
+func (rotation *Rotation) autofill(autofiller Autofiller, shiftNumber int, shift *Shift, random *rand.Rand, logger bot.Logger) (UserMap, map[string]string, error) {
+	if len(rotation.Roles) == 0 {
+		added, err := autofiller.FillShift(rotation, shiftNumber, shift, random, logger)
+		return added, nil, err
+	}
+
+	assigned := map[string]string{}
+	counts := map[string]int{}
+	var unassigned []string
+	for id := range shift.MattermostUserIDs {
+		if shift.Roles[id] == "" {
+			unassigned = append(unassigned, id)
+			continue
+		}
+		assigned[id] = shift.Roles[id]
+		counts[shift.Roles[id]]++
+	}
+	sort.Strings(unassigned)
+
+	roles := map[string]string{}
+	for _, id := range unassigned {
+		for _, role := range rotation.Roles {
+			if counts[role.Name] < role.Count {
+				assigned[id] = role.Name
+				roles[id] = role.Name
+				counts[role.Name]++
+				break
+			}
+		}
+	}
+
+	added := UserMap{}
+	for _, role := range rotation.Roles {
+		forRole, roleShift := rotation.forRole(role, shift, assigned)
+		roleAdded, err := autofiller.FillShift(forRole, shiftNumber, roleShift, random, logger)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "failed to fill role %s", role.Name)
+		}
+		for id, user := range roleAdded {
+			if roleShift.MattermostUserIDs[id] != "" {
+				continue
+			}
+			added[id] = user
+			assigned[id] = role.Name
+			roles[id] = role.Name
+		}
+	}
+	return added, roles, nil
+}
+
+// forRole returns the rotation and the shift as they apply to filling the
+// role: with the role's count and needs, the users already assigned to the
+// role, and the users not in the shift nor assigned to another role.
+func (rotation *Rotation) forRole(role *store.ShiftRole, shift *Shift, assigned map[string]string) (*Rotation, *Shift) {
+	forRole := *rotation
+	r := *rotation.Rotation
+	forRole.Rotation = &r
+	forRole.Size = role.Count
+	forRole.Needs = role.Needs
+	forRole.Users = UserMap{}
+
+	roleShift := *shift
+	s := *shift.Shift
+	roleShift.Shift = &s
+	roleShift.MattermostUserIDs = store.IDMap{}
+
+	for id, user := range rotation.Users {
+		switch {
+		case assigned[id] == role.Name:
+			roleShift.MattermostUserIDs[id] = store.NotEmpty
+			forRole.Users[id] = user
+		case assigned[id] == "" && shift.MattermostUserIDs[id] == "":
+			forRole.Users[id] = user
+		}
+	}
+	return &forRole, &roleShift
+}
+
+func (rotation *Rotation) MarkdownRoles() string {
+	out := ""
+	for i, role := range rotation.Roles {
+		out += fmt.Sprintf("%v. **%s**: %v users", i+1, role.Name, role.Count)
+		if len(role.Needs) > 0 {
+			out += fmt.Sprintf(", needs %s", role.Needs.Markdown())
+		}
+		out += ".\n"
+	}
+	return out
+}
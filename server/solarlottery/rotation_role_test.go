@@ -0,0 +1,149 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+// inOrderAutofiller fills the shift with the users qualified for all of the
+// rotation's needs, in the order of their IDs.
+type inOrderAutofiller struct{}
+
+func (inOrderAutofiller) FillShift(rotation *Rotation, shiftNumber int, shift *Shift, random *rand.Rand, logger bot.Logger) (UserMap, error) {
+	var ids []string
+	for id := range rotation.Users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	added := UserMap{}
+	for _, id := range ids {
+		if len(shift.MattermostUserIDs)+len(added) >= rotation.Size {
+			break
+		}
+		if shift.MattermostUserIDs[id] != "" {
+			continue
+		}
+		qualified := true
+		for _, need := range rotation.Needs {
+			qualified = qualified && IsUserQualifiedForNeed(rotation.Users[id], need)
+		}
+		if qualified {
+			added[id] = rotation.Users[id]
+		}
+	}
+	return added, nil
+}
+
+func testRoleRotation(t *testing.T) *Rotation {
+	rotation := &Rotation{
+		Rotation: store.NewRotation("test"),
+		Users:    UserMap{},
+	}
+	rotation.Size = 5
+	require.NoError(t, rotation.SetRole(&store.ShiftRole{Name: "primary", Count: 1}))
+	require.NoError(t, rotation.SetRole(&store.ShiftRole{Name: "secondary", Count: 2}))
+	require.NoError(t, rotation.ChangeRoleNeed("primary", "server", Level(3), store.NewNeed("server", 3, 1)))
+	for id, level := range map[string]int{"a": 1, "b": 2, "c": 3, "d": 1} {
+		user := &User{User: store.NewUser(id)}
+		user.SkillLevels["server"] = level
+		rotation.MattermostUserIDs[id] = id
+		rotation.Users[id] = user
+	}
+	return rotation
+}
+
+func TestRotationSetRole(t *testing.T) {
+	rotation := testRoleRotation(t)
+	require.Equal(t, 3, rotation.rolesSize())
+
+	require.Error(t, rotation.SetRole(&store.ShiftRole{Name: "tertiary"}))
+	require.Error(t, rotation.SetRole(&store.ShiftRole{Count: 1}))
+
+	// updates in place, keeping the needs
+	require.NoError(t, rotation.SetRole(&store.ShiftRole{Name: "primary", Count: 2}))
+	require.Equal(t, "primary", rotation.Roles[0].Name)
+	require.Equal(t, 2, rotation.Roles[0].Count)
+	require.Len(t, rotation.Roles[0].Needs, 1)
+
+	require.NoError(t, rotation.DeleteRole("primary"))
+	require.Len(t, rotation.Roles, 1)
+	require.Equal(t, "secondary", rotation.Roles[0].Name)
+	require.Error(t, rotation.DeleteRole("primary"))
+	require.Error(t, rotation.ChangeRoleNeed("primary", "server", Level(1), store.NewNeed("server", 1, 1)))
+}
+
+func TestRotationAutofillRoles(t *testing.T) {
+	rotation := testRoleRotation(t)
+
+	shift := &Shift{Shift: store.NewShift("", "", nil)}
+	forShift := rotation.ForShift(shift)
+	require.Equal(t, 3, forShift.Size)
+	require.Equal(t, 5, rotation.Size)
+
+	added, roles, err := forShift.autofill(inOrderAutofiller{}, 0, shift, nil, &bot.NilLogger{})
+	require.NoError(t, err)
+	require.Equal(t, UserMap{"a": rotation.Users["a"], "b": rotation.Users["b"], "c": rotation.Users["c"]}, added)
+	require.Equal(t, map[string]string{"a": "secondary", "b": "secondary", "c": "primary"}, roles)
+
+	for id := range added {
+		shift.MattermostUserIDs[id] = store.NotEmpty
+	}
+	shift.Roles = roles
+	unmetNeeds, unmetCapacity := rotation.unmetRoles(shift)
+	require.Empty(t, unmetNeeds)
+	require.Equal(t, 0, unmetCapacity)
+
+	tiers := rotation.ShiftUsersByTier(shift)
+	require.Len(t, tiers, 2)
+	require.Equal(t, UserMap{"c": rotation.Users["c"]}, tiers[0])
+	require.Equal(t, UserMap{"a": rotation.Users["a"], "b": rotation.Users["b"]}, tiers[1])
+}
+
+func TestRotationAutofillRolesPartial(t *testing.T) {
+	rotation := testRoleRotation(t)
+
+	// c is already primary, d joined without a role
+	shift := &Shift{Shift: store.NewShift("", "", store.IDMap{"c": store.NotEmpty, "d": store.NotEmpty})}
+	shift.Roles = map[string]string{"c": "primary"}
+	unmetNeeds, unmetCapacity := rotation.unmetRoles(shift)
+	require.Empty(t, unmetNeeds)
+	require.Equal(t, 2, unmetCapacity)
+
+	tiers := rotation.ShiftUsersByTier(shift)
+	require.Len(t, tiers, 3)
+	require.Equal(t, UserMap{"d": rotation.Users["d"]}, tiers[2])
+
+	added, roles, err := rotation.ForShift(shift).autofill(inOrderAutofiller{}, 0, shift, nil, &bot.NilLogger{})
+	require.NoError(t, err)
+	require.Equal(t, UserMap{"a": rotation.Users["a"]}, added)
+	require.Equal(t, map[string]string{"a": "secondary", "d": "secondary"}, roles)
+}
+
+func TestRotationCheckRoles(t *testing.T) {
+	rotation := testRoleRotation(t)
+	require.NoError(t, rotation.checkRoles())
+
+	rotation.ChangeNeed("server", Level(1), store.NewNeed("server", 1, 1))
+	require.Error(t, rotation.checkRoles())
+	require.NoError(t, rotation.DeleteNeed("server", Level(1)))
+
+	require.NoError(t, rotation.SetWindow(&store.ShiftWindow{Name: "day"}))
+	require.NoError(t, rotation.checkRoles())
+	require.NoError(t, rotation.SetWindow(&store.ShiftWindow{Name: "night", Start: 12 * time.Hour, Size: 2}))
+	require.Error(t, rotation.checkRoles())
+
+	// without roles, the window's size applies
+	rotation.Roles = nil
+	require.NoError(t, rotation.checkRoles())
+}
@@ -4,6 +4,7 @@
 package solarlottery
 
 import (
+	"math/rand"
 	"testing"
 	"time"
 
@@ -36,6 +37,14 @@ func TestShiftNumberForTime(t *testing.T) {
 			},
 			date: "2020-01-01",
 			want: 1,
+		}, {
+			name: "happy0m",
+			r: store.Rotation{
+				Period: EveryMonth,
+				Start:  "2019-12-21",
+			},
+			date: "2019-12-25",
+			want: 0,
 		}, {
 			name: "happy1m",
 			r: store.Rotation{
@@ -66,3 +75,148 @@ func TestShiftNumberForTime(t *testing.T) {
 		})
 	}
 }
+
+func TestShiftDatesRoundTrip(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for _, period := range []string{EveryDay, "3d", "10d", EveryBusinessDay, "2bd", "5bd", "7bd", EveryWeek, EveryTwoWeeks, "4w", EveryMonth, "3m"} {
+		for _, start := range []string{"2019-12-21", "2019-12-22", "2020-01-01", "2020-01-31", "2020-02-29", "2020-03-06"} {
+			for _, zone := range [][2]string{
+				{"", ""},
+				{"America/New_York", "10:00"},
+				{"Europe/London", "01:30"},
+				{"Australia/Lord_Howe", "23:45"},
+			} {
+				t.Run(period+"-"+start+"-"+zone[0], func(t *testing.T) {
+					testShiftDatesRoundTrip(t, random, &store.Rotation{
+						Period:      period,
+						Start:       start,
+						TimeZone:    zone[0],
+						HandoffTime: zone[1],
+					})
+				})
+			}
+		}
+	}
+}
+
+func testShiftDatesRoundTrip(t *testing.T, random *rand.Rand, r *store.Rotation) {
+	startTime, err := ParseStartTime(r.Start, r.TimeZone, r.HandoffTime)
+	require.NoError(t, err)
+	rotation := &Rotation{
+		Rotation:  r,
+		StartTime: startTime,
+	}
+
+	prevEnd := time.Time{}
+	for shiftNumber := 0; shiftNumber < 200; shiftNumber++ {
+		begin, end, err := rotation.ShiftDatesForNumber(shiftNumber)
+		require.NoError(t, err)
+		require.True(t, end.After(begin))
+		if shiftNumber > 0 {
+			require.True(t, prevEnd.Equal(begin), "shift %v must start where the previous one ended", shiftNumber)
+		}
+		prevEnd = end
+
+		for _, tt := range []time.Time{
+			begin,
+			end.Add(-time.Second),
+			begin.Add(time.Duration(random.Int63n(int64(end.Sub(begin))))),
+		} {
+			got, err := rotation.ShiftNumberForTime(tt)
+			require.NoError(t, err)
+			require.Equal(t, shiftNumber, got, "time %v", tt)
+		}
+	}
+}
+
+func TestShiftDatesTimeZone(t *testing.T) {
+	// 2020-03-02 is a Monday, DST starts in New York on 2020-03-08.
+	rotation := &Rotation{
+		Rotation: &store.Rotation{
+			Period:      EveryWeek,
+			Start:       "2020-03-02",
+			TimeZone:    "America/New_York",
+			HandoffTime: "10:00",
+		},
+	}
+	require.NoError(t, rotation.init(nil))
+
+	begin, end, err := rotation.ShiftDatesForNumber(0)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2020, 3, 2, 15, 0, 0, 0, time.UTC), begin.UTC())
+	require.Equal(t, time.Date(2020, 3, 9, 14, 0, 0, 0, time.UTC), end.UTC())
+
+	for _, tc := range []struct {
+		t    time.Time
+		want int
+	}{
+		{time.Date(2020, 3, 2, 14, 59, 0, 0, time.UTC), -1},
+		{time.Date(2020, 3, 2, 15, 0, 0, 0, time.UTC), 0},
+		{time.Date(2020, 3, 9, 13, 59, 0, 0, time.UTC), 0},
+		{time.Date(2020, 3, 9, 14, 0, 0, 0, time.UTC), 1},
+	} {
+		got, err := rotation.ShiftNumberForTime(tc.t)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, got, "time %v", tc.t)
+	}
+
+	_, err = ParseStartTime("2020-03-02", "Mars/Olympus_Mons", "")
+	require.Error(t, err)
+	_, err = ParseStartTime("2020-03-02", "", "25:00")
+	require.Error(t, err)
+}
+
+func TestShiftDatesBusinessDays(t *testing.T) {
+	// 2020-01-03 is a Friday
+	startTime, err := time.Parse(DateFormat, "2020-01-03")
+	require.NoError(t, err)
+	rotation := &Rotation{
+		Rotation:  &store.Rotation{Period: EveryBusinessDay, Start: "2020-01-03"},
+		StartTime: startTime,
+	}
+
+	for shiftNumber, expected := range [][2]string{
+		{"2020-01-03", "2020-01-06"},
+		{"2020-01-06", "2020-01-07"},
+		{"2020-01-07", "2020-01-08"},
+		{"2020-01-08", "2020-01-09"},
+		{"2020-01-09", "2020-01-10"},
+		{"2020-01-10", "2020-01-13"},
+	} {
+		begin, end, err := rotation.ShiftDatesForNumber(shiftNumber)
+		require.NoError(t, err)
+		require.Equal(t, expected[0], begin.Format(DateFormat))
+		require.Equal(t, expected[1], end.Format(DateFormat))
+	}
+
+	sunday, _ := time.Parse(DateFormat, "2020-01-05")
+	got, err := rotation.ShiftNumberForTime(sunday)
+	require.NoError(t, err)
+	require.Equal(t, 0, got)
+}
+
+func TestPeriodSet(t *testing.T) {
+	for in, expected := range map[string]string{
+		"1w":       EveryWeek,
+		"week":     EveryWeek,
+		"biweekly": EveryTwoWeeks,
+		"Month":    EveryMonth,
+		"daily":    EveryDay,
+		"weekdays": EveryBusinessDay,
+		"3d":       "3d",
+		"03D":      "3d",
+		"10d":      "10d",
+		"4w":       "4w",
+		"2bd":      "2bd",
+		"6m":       "6m",
+	} {
+		p := Period{}
+		require.NoError(t, p.Set(in), in)
+		require.Equal(t, expected, p.String(), in)
+	}
+
+	for _, in := range []string{"", "0d", "-1w", "3", "1y", "d3", "1.5w"} {
+		p := Period{}
+		require.Error(t, p.Set(in), in)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+// In a rotation with windows, each period is split into len(Windows) shifts,
+// so the shift number is periodNumber*len(Windows) + the window's index.
+
+func (rotation *Rotation) ShiftNumberForTime(t time.Time) (int, error) {
+	periodNumber, err := rotation.periodNumberForTime(t)
+	if err != nil || periodNumber < 0 || len(rotation.Windows) == 0 {
+		return periodNumber, err
+	}
+	begin, _, err := rotation.periodDatesForNumber(periodNumber)
+	if err != nil {
+		return -1, err
+	}
+	w := 0
+	for i, window := range rotation.Windows {
+		if !t.Before(begin.Add(window.Start)) {
+			w = i
+		}
+	}
+	return periodNumber*len(rotation.Windows) + w, nil
+}
+
+func (rotation *Rotation) ShiftDatesForNumber(shiftNumber int) (time.Time, time.Time, error) {
+	if len(rotation.Windows) == 0 {
+		return rotation.periodDatesForNumber(shiftNumber)
+	}
+	periodNumber, w := shiftNumber/len(rotation.Windows), shiftNumber%len(rotation.Windows)
+	begin, end, err := rotation.periodDatesForNumber(periodNumber)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	start := begin.Add(rotation.Windows[w].Start)
+	if w+1 < len(rotation.Windows) {
+		end = begin.Add(rotation.Windows[w+1].Start)
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, errors.Errorf("window %s starts after the end of period %v", rotation.Windows[w].Name, periodNumber)
+	}
+	return start, end, nil
+}
+
+// ShiftWindow returns the window of the shift, or nil if the rotation has no
+// windows.
+func (rotation *Rotation) ShiftWindow(shiftNumber int) *store.ShiftWindow {
+	if len(rotation.Windows) == 0 || shiftNumber < 0 {
+		return nil
+	}
+	return rotation.Windows[shiftNumber%len(rotation.Windows)]
+}
+
+func (rotation *Rotation) windowName(shiftNumber int) string {
+	window := rotation.ShiftWindow(shiftNumber)
+	if window == nil {
+		return ""
+	}
+	return window.Name
+}
+
+// ForShift returns the rotation as it applies to the shift: with the window's
+// size and needs, and only the users qualified for the window's region, plus
+// those already in the shift. With roles, the size is the total of the roles'.
+// Users are shared with the original rotation.
+func (rotation *Rotation) ForShift(shift *Shift) *Rotation {
+	window := rotation.ShiftWindow(shift.ShiftNumber)
+	if window == nil && len(rotation.Roles) == 0 {
+		return rotation
+	}
+	forShift := *rotation
+	r := *rotation.Rotation
+	forShift.Rotation = &r
+	if len(rotation.Roles) > 0 {
+		forShift.Size = rotation.rolesSize()
+	}
+	if window == nil {
+		return &forShift
+	}
+	if window.Size != 0 && len(rotation.Roles) == 0 {
+		forShift.Size = window.Size
+	}
+	if len(window.Needs) > 0 {
+		forShift.Needs = window.Needs
+	}
+	if window.Region != "" {
+		forShift.Users = UserMap{}
+		for id, user := range rotation.Users {
+			if user.SkillLevels[window.Region] > 0 || shift.MattermostUserIDs[id] != "" {
+				forShift.Users[id] = user
+			}
+		}
+	}
+	return &forShift
+}
+
+func (rotation *Rotation) FindWindow(name string) (*store.ShiftWindow, error) {
+	for _, window := range rotation.Windows {
+		if window.Name == name {
+			return window, nil
+		}
+	}
+	return nil, errors.Errorf("window %s is not found in rotation %s", name, rotation.Markdown())
+}
+
+// SetWindow adds the window, or replaces the one with the same name. Changing
+// when the windows start renumbers the rotation's shifts, so it is refused
+// while the rotation has stored shifts, see UpdateRotation.
+func (rotation *Rotation) SetWindow(newWindow *store.ShiftWindow) error {
+	if newWindow.Name == "" {
+		return errors.New("window must have a name")
+	}
+	if newWindow.Start < 0 {
+		return errors.Errorf("window %s can not start before the period", newWindow.Name)
+	}
+	windows := []*store.ShiftWindow{newWindow}
+	for _, window := range rotation.Windows {
+		if window.Name == newWindow.Name {
+			if newWindow.Needs == nil {
+				newWindow.Needs = window.Needs
+			}
+			continue
+		}
+		if window.Start == newWindow.Start {
+			return errors.Errorf("window %s already starts at %v", window.Name, window.Start)
+		}
+		windows = append(windows, window)
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].Start < windows[j].Start
+	})
+	if windows[0].Start != 0 {
+		return errors.Errorf("the first window must start at 0, with the period, add it first")
+	}
+	rotation.Windows = windows
+	return nil
+}
+
+// windowStarts describes the windows and when they start. As long as it does
+// not change, the rotation's shift numbers refer to the same time ranges.
+func (rotation *Rotation) windowStarts() string {
+	out := []string{}
+	for _, window := range rotation.Windows {
+		out = append(out, fmt.Sprintf("%s@%v", window.Name, window.Start))
+	}
+	return strings.Join(out, ",")
+}
+
+// checkNoStoredShifts returns an error if the rotation has stored shifts,
+// that changing its windows would move to other time ranges.
+func (sl *solarLottery) checkNoStoredShifts(rotation *Rotation) error {
+	shiftNumbers, err := sl.ShiftStore.ListShiftNumbers(rotation.RotationID)
+	if err != nil {
+		return err
+	}
+	if len(shiftNumbers) > 0 {
+		return errors.Errorf("rotation %s has %v stored shift(s), changing when its windows start would move them to other dates",
+			rotation.Markdown(), len(shiftNumbers))
+	}
+	return nil
+}
+
+func (rotation *Rotation) DeleteWindow(name string) error {
+	for i, window := range rotation.Windows {
+		if window.Name != name {
+			continue
+		}
+		if i == 0 && len(rotation.Windows) > 1 {
+			return errors.Errorf("window %s starts the period, delete the other windows first", name)
+		}
+		rotation.Windows = append(rotation.Windows[:i:i], rotation.Windows[i+1:]...)
+		return nil
+	}
+	return errors.Errorf("window %s is not found in rotation %s", name, rotation.Markdown())
+}
+
+func (rotation *Rotation) ChangeWindowNeed(name, skill string, level Level, newNeed *store.Need) error {
+	window, err := rotation.FindWindow(name)
+	if err != nil {
+		return err
+	}
+	window.Needs = changeNeed(window.Needs, skill, level, newNeed)
+	return nil
+}
+
+func (rotation *Rotation) DeleteWindowNeed(name, skill string, level Level) error {
+	window, err := rotation.FindWindow(name)
+	if err != nil {
+		return err
+	}
+	needs, ok := deleteNeed(window.Needs, skill, level)
+	if !ok {
+		return errors.Errorf("%s is not found in window %s", MarkdownSkillLevel(skill, level), name)
+	}
+	window.Needs = needs
+	return nil
+}
+
+func (rotation *Rotation) MarkdownWindows() string {
+	out := ""
+	for _, window := range rotation.Windows {
+		out += fmt.Sprintf("- **%s**: starting %v into the period", window.Name, window.Start)
+		if window.Region != "" {
+			out += fmt.Sprintf(", region **%s**", window.Region)
+		}
+		if window.Size != 0 {
+			out += fmt.Sprintf(", size **%v**", window.Size)
+		}
+		if len(window.Needs) > 0 {
+			out += fmt.Sprintf(", needs %s", window.Needs.Markdown())
+		}
+		out += ".\n"
+	}
+	return out
+}
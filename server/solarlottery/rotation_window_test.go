@@ -0,0 +1,158 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+func testWindowRotation(t *testing.T) *Rotation {
+	rotation := &Rotation{
+		Rotation: store.NewRotation("test"),
+		Users:    UserMap{},
+	}
+	rotation.Period = EveryDay
+	rotation.Start = "2020-03-02"
+	rotation.TimeZone = "America/New_York"
+	rotation.HandoffTime = "06:00"
+	rotation.Size = 1
+	require.NoError(t, rotation.SetWindow(&store.ShiftWindow{Name: "AMER", Start: 0, Region: "amer"}))
+	require.NoError(t, rotation.SetWindow(&store.ShiftWindow{Name: "APAC", Start: 8 * time.Hour, Region: "apac", Size: 2}))
+	require.NoError(t, rotation.SetWindow(&store.ShiftWindow{Name: "EMEA", Start: 16 * time.Hour, Region: "emea"}))
+	require.NoError(t, rotation.init(nil))
+	return rotation
+}
+
+func TestRotationWindowDates(t *testing.T) {
+	rotation := testWindowRotation(t)
+
+	for shiftNumber, expected := range []struct {
+		window string
+		begin  time.Time
+		end    time.Time
+	}{
+		{"AMER", time.Date(2020, 3, 2, 11, 0, 0, 0, time.UTC), time.Date(2020, 3, 2, 19, 0, 0, 0, time.UTC)},
+		{"APAC", time.Date(2020, 3, 2, 19, 0, 0, 0, time.UTC), time.Date(2020, 3, 3, 3, 0, 0, 0, time.UTC)},
+		{"EMEA", time.Date(2020, 3, 3, 3, 0, 0, 0, time.UTC), time.Date(2020, 3, 3, 11, 0, 0, 0, time.UTC)},
+		{"AMER", time.Date(2020, 3, 3, 11, 0, 0, 0, time.UTC), time.Date(2020, 3, 3, 19, 0, 0, 0, time.UTC)},
+	} {
+		begin, end, err := rotation.ShiftDatesForNumber(shiftNumber)
+		require.NoError(t, err)
+		require.Equal(t, expected.begin, begin.UTC())
+		require.Equal(t, expected.end, end.UTC())
+		require.Equal(t, expected.window, rotation.ShiftWindow(shiftNumber).Name)
+	}
+
+	testShiftDatesRoundTrip(t, rand.New(rand.NewSource(1)), rotation.Rotation)
+}
+
+func TestRotationWindowLoadShift(t *testing.T) {
+	rotation := testWindowRotation(t)
+	rotation.RotationID = "test-ID"
+	sl := &solarLottery{
+		Logger: &bot.NilLogger{},
+		Config: Config{
+			Dependencies: &Dependencies{
+				ShiftStore: store.NewStore(kvstore.NewMemStore(), &bot.NilLogger{}),
+			},
+		},
+	}
+	made, err := rotation.makeShift(1)
+	require.NoError(t, err)
+	require.NoError(t, sl.ShiftStore.StoreShift(rotation.RotationID, 1, made.Shift))
+
+	// APAC on 2020-03-02 starts at 14:00 in New York.
+	loaded, err := sl.loadShift(rotation, 1)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2020, 3, 2, 19, 0, 0, 0, time.UTC), loaded.StartTime.UTC())
+	require.Equal(t, time.Date(2020, 3, 3, 3, 0, 0, 0, time.UTC), loaded.EndTime.UTC())
+	require.Equal(t, "APAC", loaded.WindowName)
+
+	guessed, created, err := sl.getShiftForGuess(rotation, 1)
+	require.NoError(t, err)
+	require.False(t, created)
+	require.Equal(t, loaded.StartTime, guessed.StartTime)
+	require.Equal(t, loaded.EndTime, guessed.EndTime)
+}
+
+func TestRotationWindowShiftEvent(t *testing.T) {
+	for _, tc := range []struct {
+		grace     int
+		available []bool
+	}{
+		{0, []bool{false, true, true, true, true}},
+		{1, []bool{false, false, false, false, true}},
+	} {
+		t.Run(fmt.Sprintf("grace %v", tc.grace), func(t *testing.T) {
+			rotation := testWindowRotation(t)
+			rotation.RotationID = "test-ID"
+			rotation.Grace = tc.grace
+			user := &User{User: store.NewUser("a")}
+			user.AddEvent(NewShiftEvent(rotation, 0, nil))
+
+			for shiftNumber, expected := range tc.available {
+				start, end, err := rotation.ShiftDatesForNumber(shiftNumber)
+				require.NoError(t, err)
+				available, err := user.IsAvailable(rotation.RotationID, start, end)
+				require.NoError(t, err)
+				require.Equal(t, expected, available, "shift %v", shiftNumber)
+			}
+		})
+	}
+}
+
+func TestRotationWindowForShift(t *testing.T) {
+	rotation := testWindowRotation(t)
+	for id, region := range map[string]string{"a": "amer", "b": "apac", "c": "apac", "d": "emea"} {
+		user := &User{User: store.NewUser(id)}
+		user.SkillLevels[region] = 1
+		rotation.MattermostUserIDs[id] = id
+		rotation.Users[id] = user
+	}
+
+	shift := &Shift{Shift: store.NewShift("", "", nil), ShiftNumber: 4}
+	forShift := rotation.ForShift(shift)
+	require.Equal(t, 2, forShift.Size)
+	require.Equal(t, UserMap{"b": rotation.Users["b"], "c": rotation.Users["c"]}, forShift.Users)
+	require.Equal(t, 1, rotation.Size)
+	require.Len(t, rotation.Users, 4)
+
+	// users already in the shift stay
+	shift = &Shift{Shift: store.NewShift("", "", store.IDMap{"a": store.NotEmpty}), ShiftNumber: 5}
+	forShift = rotation.ForShift(shift)
+	require.Equal(t, 1, forShift.Size)
+	require.Equal(t, UserMap{"a": rotation.Users["a"], "d": rotation.Users["d"]}, forShift.Users)
+}
+
+func TestRotationSetWindow(t *testing.T) {
+	rotation := &Rotation{Rotation: store.NewRotation("test")}
+	require.Error(t, rotation.SetWindow(&store.ShiftWindow{Name: "EMEA", Start: 8 * time.Hour}))
+	require.NoError(t, rotation.SetWindow(&store.ShiftWindow{Name: "APAC"}))
+	require.NoError(t, rotation.SetWindow(&store.ShiftWindow{Name: "EMEA", Start: 8 * time.Hour}))
+	require.Error(t, rotation.SetWindow(&store.ShiftWindow{Name: "AMER", Start: 8 * time.Hour}))
+	require.NoError(t, rotation.SetWindow(&store.ShiftWindow{Name: "AMER", Start: 16 * time.Hour}))
+	require.NoError(t, rotation.ChangeWindowNeed("EMEA", "server", 1, store.NewNeed("server", 1, 1)))
+
+	// update keeps the needs, and the order
+	require.NoError(t, rotation.SetWindow(&store.ShiftWindow{Name: "EMEA", Start: 4 * time.Hour, Size: 3}))
+	require.Equal(t, "EMEA", rotation.Windows[1].Name)
+	require.Equal(t, 3, rotation.Windows[1].Size)
+	require.Len(t, rotation.Windows[1].Needs, 1)
+
+	require.Error(t, rotation.DeleteWindow("APAC"))
+	require.NoError(t, rotation.DeleteWindow("EMEA"))
+	require.NoError(t, rotation.DeleteWindow("AMER"))
+	require.NoError(t, rotation.DeleteWindow("APAC"))
+	require.Empty(t, rotation.Windows)
+	require.Error(t, rotation.DeleteWindow("APAC"))
+}
@@ -18,8 +18,10 @@ var ErrMultipleResults = errors.New("multiple resolts found")
 
 type Rotations interface {
 	AddRotation(*Rotation) error
+	AddOwners(mattermostUsernames string, rotation *Rotation) (UserMap, error)
 	ArchiveRotation(*Rotation) error
 	DebugDeleteRotation(string) error
+	DeleteOwners(mattermostUsernames string, rotation *Rotation) (UserMap, error)
 	LoadKnownRotations() (store.IDMap, error)
 	LoadRotation(string) (*Rotation, error)
 	MakeRotation(rotationName string) (*Rotation, error)
@@ -45,6 +47,12 @@ func (sl *solarLottery) AddRotation(rotation *Rotation) error {
 	if ok {
 		return ErrAlreadyExists
 	}
+	if sl.Dependencies.Autofillers[rotation.Type] == nil {
+		return errors.Errorf("unsupported rotation type %s", rotation.Type)
+	}
+	rotation.Owners = store.IDMap{
+		sl.actingUser.MattermostUserID: sl.actingUser.MattermostUsername(),
+	}
 
 	sl.knownRotations[rotation.RotationID] = rotation.Name
 	err = sl.RotationStore.StoreKnownRotations(sl.knownRotations)
@@ -55,6 +63,7 @@ func (sl *solarLottery) AddRotation(rotation *Rotation) error {
 	if err != nil {
 		return err
 	}
+	sl.audit(AuditRotationAdd, rotation.RotationID, NoShift, nil, nil, rotationFields(rotation))
 	logger.Infof("New rotation %s added", rotation.Markdown())
 	return nil
 }
@@ -98,6 +107,7 @@ func (sl *solarLottery) ResolveRotationName(namePattern string) ([]string, error
 func (sl *solarLottery) ArchiveRotation(rotation *Rotation) error {
 	err := sl.Filter(
 		withActingUserExpanded,
+		withRotationManager(rotation),
 	)
 	if err != nil {
 		return err
@@ -108,9 +118,11 @@ func (sl *solarLottery) ArchiveRotation(rotation *Rotation) error {
 		"RotationID":     rotation.RotationID,
 	})
 
-	rotation.Rotation.IsArchived = true
-
-	err = sl.RotationStore.StoreRotation(rotation.Rotation)
+	before := rotationFields(rotation)
+	err = sl.updateRotation(rotation, func(rotation *Rotation) error {
+		rotation.Rotation.IsArchived = true
+		return nil
+	})
 	if err != nil {
 		return err
 	}
@@ -119,6 +131,7 @@ func (sl *solarLottery) ArchiveRotation(rotation *Rotation) error {
 	if err != nil {
 		return errors.WithMessagef(err, "failed to store rotation %s", rotation.RotationID)
 	}
+	sl.audit(AuditRotationArchive, rotation.RotationID, NoShift, nil, before, rotationFields(rotation))
 
 	logger.Infof("%s archived rotation %s.", sl.actingUser.Markdown(), rotation.Markdown())
 	return nil
@@ -127,6 +140,7 @@ func (sl *solarLottery) ArchiveRotation(rotation *Rotation) error {
 func (sl *solarLottery) DebugDeleteRotation(rotationID string) error {
 	err := sl.Filter(
 		withActingUserExpanded,
+		withActingUserAdmin,
 	)
 	if err != nil {
 		return err
@@ -137,6 +151,21 @@ func (sl *solarLottery) DebugDeleteRotation(rotationID string) error {
 		"RotationID":     rotationID,
 	})
 
+	shiftNumbers, err := sl.ShiftStore.ListShiftNumbers(rotationID)
+	if err != nil {
+		return err
+	}
+	for _, shiftNumber := range shiftNumbers {
+		err = sl.ShiftStore.DeleteShift(rotationID, shiftNumber)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to delete shift %v", shiftNumber)
+		}
+	}
+	err = sl.ShiftStore.DeleteShiftNumbers(rotationID)
+	if err != nil {
+		return err
+	}
+
 	err = sl.RotationStore.DeleteRotation(rotationID)
 	if err != nil {
 		return err
@@ -146,8 +175,9 @@ func (sl *solarLottery) DebugDeleteRotation(rotationID string) error {
 	if err != nil {
 		return errors.WithMessagef(err, "failed to store rotation %s", rotationID)
 	}
+	sl.audit(AuditRotationDebugDelete, rotationID, NoShift, nil, nil, nil)
 
-	logger.Infof("%s deleted rotation %s.", sl.actingUser.Markdown(), rotationID)
+	logger.Infof("%s deleted rotation %s and its %v shifts.", sl.actingUser.Markdown(), rotationID, len(shiftNumbers))
 	return nil
 }
 
@@ -161,10 +191,13 @@ func (sl *solarLottery) LoadRotation(rotationID string) (*Rotation, error) {
 
 	_, ok := sl.knownRotations[rotationID]
 	if !ok {
-		return nil, errors.Errorf("rotationID %s not found", rotationID)
+		return nil, errors.WithMessagef(store.ErrNotFound, "rotationID %s", rotationID)
 	}
 
 	storedRotation, err := sl.RotationStore.LoadRotation(rotationID)
+	if err != nil {
+		return nil, err
+	}
 	rotation := &Rotation{
 		Rotation: storedRotation,
 	}
@@ -200,6 +233,7 @@ func (sl *solarLottery) UpdateRotation(rotation *Rotation, updatef func(*Rotatio
 	err := sl.Filter(
 		withActingUserExpanded,
 		withRotationExpanded(rotation),
+		withRotationManager(rotation),
 	)
 	if err != nil {
 		return err
@@ -210,16 +244,48 @@ func (sl *solarLottery) UpdateRotation(rotation *Rotation, updatef func(*Rotatio
 		"RotationID":     rotation.RotationID,
 	})
 
-	err = updatef(rotation)
-	if err != nil {
-		return err
-	}
-
-	err = sl.RotationStore.StoreRotation(rotation.Rotation)
+	before := rotationFields(rotation)
+	err = sl.updateRotation(rotation, func(rotation *Rotation) error {
+		windowStarts := rotation.windowStarts()
+		err := updatef(rotation)
+		if err != nil {
+			return err
+		}
+		err = rotation.checkRoles()
+		if err != nil {
+			return err
+		}
+		if rotation.windowStarts() != windowStarts {
+			return sl.checkNoStoredShifts(rotation)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
+	sl.audit(AuditRotationUpdate, rotation.RotationID, NoShift, nil, before, rotationFields(rotation))
 
 	logger.Infof("%s updated rotation %s.", sl.actingUser.Markdown(), rotation.Markdown())
 	return nil
 }
+
+// updateRotation applies updatef to the rotation and stores it. If the rotation
+// has been modified concurrently, it is reloaded and updatef is re-applied.
+func (sl *solarLottery) updateRotation(rotation *Rotation, updatef func(*Rotation) error) error {
+	return retryOnConflict(
+		func() error {
+			err := updatef(rotation)
+			if err != nil {
+				return err
+			}
+			return sl.RotationStore.StoreRotation(rotation.Rotation)
+		},
+		func() error {
+			stored, err := sl.RotationStore.LoadRotation(rotation.RotationID)
+			if err != nil {
+				return err
+			}
+			rotation.Rotation = stored
+			return nil
+		})
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+// PathShift is where the shift actions are posted, under config.PathPostAction.
+const PathShift = "/shift"
+
+// Shift actions, set in the post action context.
+const (
+	ShiftActionConfirm     = "confirm"
+	ShiftActionJoin        = "join"
+	ShiftActionLeave       = "leave"
+	ShiftActionSwap        = "swap"
+	ShiftActionUnavailable = "unavailable"
+)
+
+// ShiftAction is what a user does with a button in a shift notification. It
+// applies to the acting user, so it only needs the shift.
+type ShiftAction struct {
+	Action      string
+	RotationID  string
+	ShiftNumber int
+}
+
+func (action ShiftAction) ToContext() map[string]interface{} {
+	return map[string]interface{}{
+		"action":      action.Action,
+		"rotation_id": action.RotationID,
+		"shift":       action.ShiftNumber,
+	}
+}
+
+// ShiftActionFromContext returns the action from a post action context.
+// Numbers are decoded from JSON as float64.
+func ShiftActionFromContext(context map[string]interface{}) (*ShiftAction, error) {
+	action, _ := context["action"].(string)
+	rotationID, _ := context["rotation_id"].(string)
+	shiftNumber, ok := context["shift"].(float64)
+	if action == "" || rotationID == "" || !ok {
+		return nil, errors.New("invalid shift action context")
+	}
+	return &ShiftAction{
+		Action:      action,
+		RotationID:  rotationID,
+		ShiftNumber: int(shiftNumber),
+	}, nil
+}
+
+// ConfirmShift records that the acting user acknowledged being in the shift.
+func (sl *solarLottery) ConfirmShift(rotation *Rotation, shiftNumber int, now time.Time) (*Shift, error) {
+	err := sl.Filter(
+		withActingUserExpanded,
+	)
+	if err != nil {
+		return nil, err
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":       "sl.ConfirmShift",
+		"ActingUsername": sl.actingUser.MattermostUsername(),
+		"RotationID":     rotation.RotationID,
+		"ShiftNumber":    shiftNumber,
+	})
+
+	shift, err := sl.loadShift(rotation, shiftNumber)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to load %s", rotation.ShiftRef(shiftNumber))
+	}
+	before := shiftFields(shift)
+	err = sl.updateShift(rotation, shift, func(shift *Shift) error {
+		if shift.MattermostUserIDs[sl.actingUser.MattermostUserID] == "" {
+			return errors.Errorf("%s is not in %s", sl.actingUser.Markdown(), shift.Markdown())
+		}
+		if shift.Acknowledged == nil {
+			shift.Acknowledged = map[string]time.Time{}
+		}
+		if shift.Acknowledged[sl.actingUser.MattermostUserID].IsZero() {
+			shift.Acknowledged[sl.actingUser.MattermostUserID] = now
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sl.audit(AuditShiftConfirm, rotation.RotationID, shiftNumber, nil, before, shiftFields(shift))
+
+	logger.Infof("%s confirmed %s.", sl.actingUser.Markdown(), shift.Markdown())
+	return shift, nil
+}
+
+// DeclineShift marks the acting user unavailable for the shift's dates, and
+// removes them from the shift.
+func (sl *solarLottery) DeclineShift(rotation *Rotation, shiftNumber int) (*Shift, error) {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withRotationExpanded(rotation),
+	)
+	if err != nil {
+		return nil, err
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":       "sl.DeclineShift",
+		"ActingUsername": sl.actingUser.MattermostUsername(),
+		"RotationID":     rotation.RotationID,
+		"ShiftNumber":    shiftNumber,
+	})
+
+	shift, err := sl.loadShift(rotation, shiftNumber)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to load %s", rotation.ShiftRef(shiftNumber))
+	}
+	if shift.MattermostUserIDs[sl.actingUser.MattermostUserID] == "" {
+		return nil, errors.Errorf("%s is not in %s", sl.actingUser.Markdown(), shift.Markdown())
+	}
+	users := UserMap{sl.actingUser.MattermostUserID: sl.actingUser}
+	deleted, err := sl.leaveShift(rotation, shiftNumber, shift, users, true)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := rotation.ShiftDatesForNumber(shiftNumber)
+	if err != nil {
+		return nil, err
+	}
+	err = sl.addEventToUsers(users, newTimedPersonalEvent(start, end), true)
+	if err != nil {
+		return nil, err
+	}
+
+	sl.messageShiftLeft(deleted, rotation, shift)
+	logger.Infof("%s is unavailable for %s, and left it.", sl.actingUser.Markdown(), shift.Markdown())
+	return shift, nil
+}
+
+// shiftActions are the actions offered to a user in a shift.
+func (sl *solarLottery) shiftActions(shift *Shift, rotation *Rotation) []*model.PostAction {
+	return []*model.PostAction{
+		sl.shiftAction("Confirm", ShiftActionConfirm, rotation, shift),
+		sl.shiftAction("I'm unavailable", ShiftActionUnavailable, rotation, shift),
+		sl.shiftAction("Request swap", ShiftActionSwap, rotation, shift),
+		sl.shiftAction("Leave shift", ShiftActionLeave, rotation, shift),
+	}
+}
+
+func (sl *solarLottery) shiftAction(name, action string, rotation *Rotation, shift *Shift) *model.PostAction {
+	postAction := sl.postAction(name, PathShift, ShiftAction{
+		Action:      action,
+		RotationID:  rotation.RotationID,
+		ShiftNumber: shift.ShiftNumber,
+	}.ToContext())
+	if action == ShiftActionSwap {
+		postAction.Type = model.POST_ACTION_TYPE_SELECT
+		postAction.DataSource = "users"
+	}
+	return postAction
+}
+
+// postAction returns a button that posts the signed context to the path, under
+// config.PathPostAction.
+func (sl *solarLottery) postAction(name, path string, context map[string]interface{}) *model.PostAction {
+	if sl.Config.ActionSigningSecret != "" {
+		err := utils.SignContext(sl.Config.ActionSigningSecret, context)
+		if err != nil {
+			sl.Errorf("failed to sign %s action: %v", name, err)
+		}
+	}
+	return &model.PostAction{
+		Name: name,
+		Type: model.POST_ACTION_TYPE_BUTTON,
+		Integration: &model.PostActionIntegration{
+			URL:     sl.Config.PluginURLPath + config.PathPostAction + path,
+			Context: context,
+		},
+	}
+}
+
+// dmUserWithActions sends the message as an attachment with the actions. The
+// actions are left out if they can not be signed.
+func (sl *solarLottery) dmUserWithActions(user *User, message string, actions ...*model.PostAction) {
+	if sl.Config.ActionSigningSecret == "" {
+		sl.dmUser(user, message)
+		return
+	}
+	sl.Poster.DMWithAttachments(user.MattermostUserID, &model.SlackAttachment{
+		Text:    message,
+		Actions: actions,
+	})
+	sl.Debugf("DM bot to %s with %v actions:\n%s", user.Markdown(), len(actions), message)
+}
@@ -7,11 +7,20 @@ import (
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 )
 
+// ListShifts returns the stored shifts numbered from shiftNumber to
+// shiftNumber+numShifts-1.
 func (sl *solarLottery) ListShifts(rotation *Rotation, shiftNumber, numShifts int) ([]*Shift, error) {
+	shiftNumbers, err := sl.ShiftStore.ListShiftNumbers(rotation.RotationID)
+	if err != nil {
+		return nil, err
+	}
 	shifts := []*Shift{}
-	for i := shiftNumber; i < shiftNumber+numShifts; i++ {
+	for _, i := range shiftNumbers {
+		if i < shiftNumber || i >= shiftNumber+numShifts {
+			continue
+		}
 		var shift *Shift
-		shift, err := sl.loadShift(rotation, i)
+		shift, err = sl.loadShift(rotation, i)
 		if err != nil {
 			if err != store.ErrNotFound {
 				return nil, err
@@ -0,0 +1,38 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+// ReindexShifts rebuilds the index of the rotation's stored shifts, to repair
+// it. The shifts stored before they were indexed are indexed by a store
+// migration on activation. Returns the numbers of the shifts found.
+func (sl *solarLottery) ReindexShifts(rotation *Rotation) ([]int, error) {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withRotationManager(rotation),
+	)
+	if err != nil {
+		return nil, err
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":       "sl.ReindexShifts",
+		"ActingUsername": sl.actingUser.MattermostUsername(),
+		"RotationID":     rotation.RotationID,
+	})
+
+	shiftNumbers, err := sl.ShiftStore.FindShiftNumbers(rotation.RotationID)
+	if err != nil {
+		return nil, err
+	}
+	err = sl.ShiftStore.StoreShiftNumbers(rotation.RotationID, shiftNumbers)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("%s reindexed %v shifts of %s.", sl.actingUser.Markdown(), len(shiftNumbers), rotation.Markdown())
+	return shiftNumbers, nil
+}
@@ -15,10 +15,11 @@ import (
 var ErrShiftMustBeOpen = errors.New("must be `open`")
 var ErrUserAlreadyInShift = errors.New("user is already in shift")
 
-func (sl *solarLottery) JoinShift(mattermostUsernames string, rotation *Rotation, shiftNumber int) (*Shift, UserMap, error) {
+func (sl *solarLottery) JoinShift(mattermostUsernames string, rotation *Rotation, shiftNumber int, role string) (*Shift, UserMap, error) {
 	err := sl.Filter(
 		withActingUserExpanded,
 		withMattermostUsersExpanded(mattermostUsernames),
+		withSelfOrRotationManager(rotation),
 	)
 	if err != nil {
 		return nil, nil, err
@@ -33,13 +34,20 @@ func (sl *solarLottery) JoinShift(mattermostUsernames string, rotation *Rotation
 
 	shift, err := sl.loadShift(rotation, shiftNumber)
 	if err != nil {
-		return nil, nil, errors.Errorf("failed to load shift %v for rotation %s", shiftNumber, rotation.RotationID)
+		return nil, nil, errors.WithMessagef(err, "failed to load shift %v for rotation %s", shiftNumber, rotation.RotationID)
 	}
-	joined, err := sl.joinShift(rotation, shiftNumber, shift, sl.users, true)
-	if err != nil {
-		return nil, nil, err
+	var roles map[string]string
+	if role != "" {
+		_, err = rotation.FindRole(role)
+		if err != nil {
+			return nil, nil, err
+		}
+		roles = map[string]string{}
+		for id := range sl.users {
+			roles[id] = role
+		}
 	}
-	err = sl.ShiftStore.StoreShift(rotation.RotationID, shiftNumber, shift.Shift)
+	joined, err := sl.joinShift(rotation, shiftNumber, shift, sl.users, roles, true)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -54,6 +62,7 @@ func (sl *solarLottery) LeaveShift(mattermostUsernames string, rotation *Rotatio
 	err := sl.Filter(
 		withActingUserExpanded,
 		withMattermostUsersExpanded(mattermostUsernames),
+		withSelfOrRotationManager(rotation),
 	)
 	if err != nil {
 		return nil, nil, err
@@ -68,16 +77,12 @@ func (sl *solarLottery) LeaveShift(mattermostUsernames string, rotation *Rotatio
 
 	shift, err := sl.loadShift(rotation, shiftNumber)
 	if err != nil {
-		return nil, nil, errors.Errorf("failed to load shift %v for rotation %s", shiftNumber, rotation.RotationID)
+		return nil, nil, errors.WithMessagef(err, "failed to load shift %v for rotation %s", shiftNumber, rotation.RotationID)
 	}
 	deleted, err := sl.leaveShift(rotation, shiftNumber, shift, sl.users, true)
 	if err != nil {
 		return nil, nil, err
 	}
-	err = sl.ShiftStore.StoreShift(rotation.RotationID, shiftNumber, shift.Shift)
-	if err != nil {
-		return nil, nil, err
-	}
 
 	sl.messageShiftLeft(deleted, rotation, shift)
 	logger.Infof("%s deleted %s from %s.",
@@ -94,13 +99,7 @@ func (sl *solarLottery) IsShiftReady(rotation *Rotation, shiftNumber int) (shift
 		return nil, false, "", ErrShiftMustBeOpen
 	}
 
-	shiftUsers := rotation.ShiftUsers(shift)
-	unmetNeeds := UnmetNeeds(rotation.Needs, shiftUsers)
-	unmetCapacity := 0
-	if rotation.Size != 0 {
-		unmetCapacity = rotation.Size - len(shift.MattermostUserIDs)
-	}
-
+	unmetNeeds, unmetCapacity := rotation.unmetShift(shift)
 	if len(unmetNeeds) == 0 && unmetCapacity <= 0 {
 		return shift, true, "", nil
 	}
@@ -115,9 +114,24 @@ func (sl *solarLottery) IsShiftReady(rotation *Rotation, shiftNumber int) (shift
 	return shift, false, whyNot, nil
 }
 
+// unmetShift returns the needs and capacity of the shift unmet by its users,
+// taking its window and roles into account.
+func (rotation *Rotation) unmetShift(shift *Shift) (store.Needs, int) {
+	forShift := rotation.ForShift(shift)
+	if len(forShift.Roles) > 0 {
+		return forShift.unmetRoles(shift)
+	}
+	unmetCapacity := 0
+	if forShift.Size != 0 {
+		unmetCapacity = forShift.Size - len(shift.MattermostUserIDs)
+	}
+	return UnmetNeeds(forShift.Needs, forShift.ShiftUsers(shift)), unmetCapacity
+}
+
 func (sl *solarLottery) FillShift(rotation *Rotation, shiftNumber int) (*Shift, UserMap, error) {
 	err := sl.Filter(
 		withActingUserExpanded,
+		withRotationManager(rotation),
 	)
 	if err != nil {
 		return nil, nil, err
@@ -148,49 +162,102 @@ func (sl *solarLottery) FillShift(rotation *Rotation, shiftNumber int) (*Shift,
 	return shift, added, nil
 }
 
-func (sl *solarLottery) joinShift(rotation *Rotation, shiftNumber int, shift *Shift, users UserMap, persist bool) (UserMap, error) {
-	if shift.Status != store.ShiftStatusOpen {
-		return nil, errors.Errorf("can't join a shift with status %s, must be Open", shift.Status)
-	}
+// joinShift adds users to the shift, and assigns the roles to the users in the
+// shift who don't have one yet. If persist is set, the shift and the users'
+// events are stored.
+func (sl *solarLottery) joinShift(rotation *Rotation, shiftNumber int, shift *Shift, users UserMap, roles map[string]string, persist bool) (UserMap, error) {
+	var joined UserMap
+	size := rotation.ForShift(shift).Size
+	join := func(shift *Shift) error {
+		if shift.Status != store.ShiftStatusOpen {
+			return errors.Errorf("can't join a shift with status %s, must be Open", shift.Status)
+		}
 
-	joined := UserMap{}
-	for _, user := range users {
-		if shift.Shift.MattermostUserIDs[user.MattermostUserID] != "" {
-			continue
+		joined = UserMap{}
+		for _, user := range users {
+			if shift.Shift.MattermostUserIDs[user.MattermostUserID] != "" {
+				continue
+			}
+			if len(shift.MattermostUserIDs) >= size {
+				return errors.Errorf("rotation size %v exceeded", size)
+			}
+			shift.Shift.MattermostUserIDs[user.MattermostUserID] = store.NotEmpty
+			joined[user.MattermostUserID] = user
 		}
-		if len(shift.MattermostUserIDs) >= rotation.Size {
-			return nil, errors.Errorf("rotation size %v exceeded", rotation.Size)
+		for id, role := range roles {
+			if shift.MattermostUserIDs[id] == "" || shift.Roles[id] != "" {
+				continue
+			}
+			if shift.Roles == nil {
+				shift.Roles = map[string]string{}
+			}
+			shift.Roles[id] = role
 		}
-		shift.Shift.MattermostUserIDs[user.MattermostUserID] = store.NotEmpty
-		joined[user.MattermostUserID] = user
+		return nil
+	}
+
+	var err error
+	before := shiftFields(shift)
+	if persist {
+		err = sl.updateShift(rotation, shift, join)
+	} else {
+		err = join(shift)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	err := sl.addEventToUsers(joined, NewShiftEvent(rotation, shiftNumber, shift), persist)
+	err = sl.addEventToUsers(joined, NewShiftEvent(rotation, shiftNumber, shift), persist)
 	if err != nil {
 		return nil, err
 	}
+	if persist {
+		sl.audit(AuditShiftJoin, rotation.RotationID, shiftNumber, joined, before, shiftFields(shift))
+	}
 
 	return joined, nil
 }
 
+// leaveShift removes users from the shift. If persist is set, the shift and
+// the users' events are stored.
 func (sl *solarLottery) leaveShift(rotation *Rotation, shiftNumber int, shift *Shift, users UserMap, persist bool) (UserMap, error) {
-	if shift.Status == store.ShiftStatusFinished {
-		return nil, errors.Errorf("can't leave a shift with status %s", store.ShiftStatusFinished)
-	}
+	var deleted UserMap
+	leave := func(shift *Shift) error {
+		if shift.Status == store.ShiftStatusFinished {
+			return errors.Errorf("can't leave a shift with status %s", store.ShiftStatusFinished)
+		}
 
-	deleted := UserMap{}
-	for _, user := range users {
-		if shift.Shift.MattermostUserIDs[user.MattermostUserID] == "" {
-			continue
+		deleted = UserMap{}
+		for _, user := range users {
+			if shift.Shift.MattermostUserIDs[user.MattermostUserID] == "" {
+				continue
+			}
+			delete(shift.Shift.MattermostUserIDs, user.MattermostUserID)
+			delete(shift.Shift.Roles, user.MattermostUserID)
+			delete(shift.Shift.Acknowledged, user.MattermostUserID)
+			deleted[user.MattermostUserID] = user
 		}
-		delete(shift.Shift.MattermostUserIDs, user.MattermostUserID)
-		deleted[user.MattermostUserID] = user
+		return nil
+	}
+
+	var err error
+	before := shiftFields(shift)
+	if persist {
+		err = sl.updateShift(rotation, shift, leave)
+	} else {
+		err = leave(shift)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	err := sl.addEventToUsers(deleted, NewShiftEvent(rotation, shiftNumber, shift), persist)
+	err = sl.addEventToUsers(deleted, NewShiftEvent(rotation, shiftNumber, shift), persist)
 	if err != nil {
 		return nil, err
 	}
+	if persist {
+		sl.audit(AuditShiftLeave, rotation.RotationID, shiftNumber, deleted, before, shiftFields(shift))
+	}
 
 	return deleted, nil
 }
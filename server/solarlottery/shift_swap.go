@@ -0,0 +1,420 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+// NoShift is used for the counterpart's shift in a one-way swap, when the
+// counterpart just takes over the requester's shift.
+const NoShift = -1
+
+// PathSwap is where the swap actions are posted, under config.PathPostAction.
+const PathSwap = "/swap"
+
+// Swap actions, set in the post action context.
+const (
+	SwapActionAccept   = "accept"
+	SwapActionDecline  = "decline"
+	SwapActionWithdraw = "withdraw"
+)
+
+// swapLockTTL is how long a swap may hold its rotation's lock before it is
+// considered abandoned.
+const swapLockTTL = time.Minute
+
+// ShiftSwap is a request from a user in a shift to another user to take their
+// place. If TheirShiftNumber is set, the requester takes the counterpart's
+// place in that shift in return. Swaps are stored while pending, and the
+// actions posted to the users only carry their SwapID, so that a swap is
+// accepted, declined or withdrawn once.
+type ShiftSwap struct {
+	*store.Swap
+}
+
+// ToContext returns the swap as a post action context.
+func (swap *ShiftSwap) ToContext(action string) map[string]interface{} {
+	return map[string]interface{}{
+		"action":  action,
+		"swap_id": swap.SwapID,
+	}
+}
+
+// SwapFromContext returns the swap ID and the action from a post action
+// context.
+func SwapFromContext(context map[string]interface{}) (string, string, error) {
+	action, _ := context["action"].(string)
+	swapID, _ := context["swap_id"].(string)
+	if action == "" || swapID == "" {
+		return "", "", errors.New("invalid swap context")
+	}
+	return swapID, action, nil
+}
+
+func (swap *ShiftSwap) Markdown() string {
+	return fmt.Sprintf("swap `%s`", swap.SwapID)
+}
+
+func (sl *solarLottery) LoadSwap(swapID string) (*ShiftSwap, error) {
+	storedSwap, err := sl.SwapStore.LoadSwap(swapID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "swapID %s", swapID)
+	}
+	return &ShiftSwap{Swap: storedSwap}, nil
+}
+
+func (sl *solarLottery) RequestSwap(rotation *Rotation, shiftNumber int, withUsername string, theirShiftNumber int) (*ShiftSwap, error) {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withRotationExpanded(rotation),
+		withMattermostUsersExpanded(withUsername),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(sl.users) != 1 {
+		return nil, errors.New("must swap with exactly one user")
+	}
+	if sl.Config.ActionSigningSecret == "" {
+		return nil, errors.New("swaps require the action signing secret to be configured")
+	}
+	var with *User
+	for _, user := range sl.users {
+		with = user
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":         "sl.RequestSwap",
+		"ActingUsername":   sl.actingUser.MattermostUsername(),
+		"WithUsername":     with.MattermostUsername(),
+		"RotationID":       rotation.RotationID,
+		"ShiftNumber":      shiftNumber,
+		"TheirShiftNumber": theirShiftNumber,
+	})
+
+	swap := &ShiftSwap{
+		Swap: &store.Swap{
+			PluginVersion:        sl.Config.PluginVersion,
+			SwapID:               model.NewId(),
+			Status:               store.SwapStatusPending,
+			RotationID:           rotation.RotationID,
+			ShiftNumber:          shiftNumber,
+			TheirShiftNumber:     theirShiftNumber,
+			FromMattermostUserID: sl.actingUser.MattermostUserID,
+			WithMattermostUserID: with.MattermostUserID,
+			Requested:            time.Now(),
+		},
+	}
+	shift, theirShift, err := sl.loadSwapShifts(rotation, swap)
+	if err != nil {
+		return nil, err
+	}
+	err = sl.SwapStore.StoreSwap(swap.Swap)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to store %s", swap.Markdown())
+	}
+
+	err = sl.Poster.DMWithAttachments(with.MattermostUserID, sl.swapAttachment(rotation, swap, shift, theirShift))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to message %s", with.Markdown())
+	}
+	sl.dmUserWithActions(sl.actingUser,
+		fmt.Sprintf("You asked %s to swap %s.", with.Markdown(), shift.Markdown()),
+		sl.postAction("Withdraw", PathSwap, swap.ToContext(SwapActionWithdraw)))
+	logger.Infof("%s asked %s to swap %s, %s.",
+		sl.actingUser.Markdown(), with.Markdown(), shift.Markdown(), swap.Markdown())
+	return swap, nil
+}
+
+func (sl *solarLottery) AcceptSwap(rotation *Rotation, swap *ShiftSwap) error {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withRotationExpanded(rotation),
+	)
+	if err != nil {
+		return err
+	}
+	if sl.actingUser.MattermostUserID != swap.WithMattermostUserID {
+		return errors.New("only the user asked to swap can accept it")
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":         "sl.AcceptSwap",
+		"ActingUsername":   sl.actingUser.MattermostUsername(),
+		"RotationID":       rotation.RotationID,
+		"SwapID":           swap.SwapID,
+		"ShiftNumber":      swap.ShiftNumber,
+		"TheirShiftNumber": swap.TheirShiftNumber,
+	})
+
+	var message string
+	err = sl.closeSwap(rotation, swap, store.SwapStatusAccepted, func() error {
+		shift, theirShift, err := sl.loadSwapShifts(rotation, swap)
+		if err != nil {
+			return err
+		}
+		from := rotation.Users[swap.FromMattermostUserID]
+		with := rotation.Users[swap.WithMattermostUserID]
+
+		err = sl.replaceInShift(rotation, shift, from, with)
+		if err != nil {
+			return err
+		}
+		if theirShift != nil {
+			err = sl.replaceInShift(rotation, theirShift, with, from)
+			if err != nil {
+				// Undo the first half, so the swap is all or nothing.
+				undoErr := sl.replaceInShift(rotation, shift, with, from)
+				if undoErr != nil {
+					logger.Errorf("failed to undo swap of %s: %v", shift.Markdown(), undoErr)
+				}
+				return err
+			}
+		}
+
+		message = fmt.Sprintf("%s took over %s from %s", with.Markdown(), shift.Markdown(), from.Markdown())
+		if theirShift != nil {
+			message += fmt.Sprintf(", in exchange for %s", theirShift.Markdown())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sl.dmUser(rotation.Users[swap.FromMattermostUserID], message+".")
+	logger.Infof(message + ".")
+	return nil
+}
+
+func (sl *solarLottery) DeclineSwap(rotation *Rotation, swap *ShiftSwap) error {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withRotationExpanded(rotation),
+	)
+	if err != nil {
+		return err
+	}
+	if sl.actingUser.MattermostUserID != swap.WithMattermostUserID {
+		return errors.New("only the user asked to swap can decline it")
+	}
+	from := rotation.Users[swap.FromMattermostUserID]
+	if from == nil {
+		return errors.Errorf("user %s is not in rotation %s", swap.FromMattermostUserID, rotation.Markdown())
+	}
+	err = sl.closeSwap(rotation, swap, store.SwapStatusDeclined, nil)
+	if err != nil {
+		return err
+	}
+	sl.dmUser(from, fmt.Sprintf("%s declined to swap %s.",
+		sl.actingUser.Markdown(), rotation.ShiftRef(swap.ShiftNumber)))
+	return nil
+}
+
+// WithdrawSwap is used by the requester to cancel a pending swap.
+func (sl *solarLottery) WithdrawSwap(rotation *Rotation, swap *ShiftSwap) error {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withRotationExpanded(rotation),
+	)
+	if err != nil {
+		return err
+	}
+	if sl.actingUser.MattermostUserID != swap.FromMattermostUserID {
+		return errors.New("only the user who asked to swap can withdraw it")
+	}
+	err = sl.closeSwap(rotation, swap, store.SwapStatusWithdrawn, nil)
+	if err != nil {
+		return err
+	}
+	with := rotation.Users[swap.WithMattermostUserID]
+	if with != nil {
+		sl.dmUser(with, fmt.Sprintf("%s withdrew the request to swap %s.",
+			sl.actingUser.Markdown(), rotation.ShiftRef(swap.ShiftNumber)))
+	}
+	return nil
+}
+
+// closeSwap sets the status of the pending swap, after closef (if any) made it
+// so. It holds the rotation's swap lock, so that a swap is only closed once.
+func (sl *solarLottery) closeSwap(rotation *Rotation, swap *ShiftSwap, status string, closef func() error) error {
+	mutex := kvstore.NewMutex(sl.LockStore, "swap-"+rotation.RotationID, swapLockTTL)
+	locked, err := mutex.TryLock()
+	if err != nil {
+		return errors.WithMessage(err, "failed to lock rotation")
+	}
+	if !locked {
+		return errors.New("another swap is in progress, please try again")
+	}
+	defer func() {
+		unlockErr := mutex.Unlock()
+		if unlockErr != nil {
+			sl.Errorf("failed to unlock swaps for %s: %v", rotation.Markdown(), unlockErr)
+		}
+	}()
+
+	stored, err := sl.SwapStore.LoadSwap(swap.SwapID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to load %s", swap.Markdown())
+	}
+	if stored.RotationID != rotation.RotationID {
+		return errors.Errorf("%s is not in rotation %s", swap.Markdown(), rotation.Markdown())
+	}
+	if stored.Status != store.SwapStatusPending {
+		return errors.Errorf("%s is already %s", swap.Markdown(), stored.Status)
+	}
+	swap.Swap = stored
+
+	if closef != nil {
+		err = closef()
+		if err != nil {
+			return err
+		}
+	}
+	swap.Status = status
+	swap.Closed = time.Now()
+	err = sl.SwapStore.StoreSwap(swap.Swap)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to store %s", swap.Markdown())
+	}
+	return nil
+}
+
+// loadSwapShifts loads the shifts of the swap and checks that it can be made:
+// both users are in the rotation, the shifts are open, each user is in their
+// own shift and not in the other one, and the shifts' needs are still met.
+// theirShift is nil for a one-way swap.
+func (sl *solarLottery) loadSwapShifts(rotation *Rotation, swap *ShiftSwap) (shift, theirShift *Shift, err error) {
+	from := rotation.Users[swap.FromMattermostUserID]
+	with := rotation.Users[swap.WithMattermostUserID]
+	if from == nil || with == nil {
+		return nil, nil, errors.Errorf("both users must be in rotation %s", rotation.Markdown())
+	}
+	if from.MattermostUserID == with.MattermostUserID {
+		return nil, nil, errors.New("can't swap with self")
+	}
+
+	check := func(shiftNumber int, out, in *User) (*Shift, error) {
+		shift, err := sl.loadShift(rotation, shiftNumber)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to load %s", rotation.ShiftRef(shiftNumber))
+		}
+		if shift.Status != store.ShiftStatusOpen {
+			return nil, errors.WithMessagef(ErrShiftMustBeOpen, "%s is %s", shift.Markdown(), shift.Status)
+		}
+		if shift.MattermostUserIDs[out.MattermostUserID] == "" {
+			return nil, errors.Errorf("%s is not in %s", out.Markdown(), shift.Markdown())
+		}
+		if shift.MattermostUserIDs[in.MattermostUserID] != "" {
+			return nil, errors.WithMessagef(ErrUserAlreadyInShift, "%s is in %s", in.Markdown(), shift.Markdown())
+		}
+		unmet := rotation.swapUnmetNeeds(shift, out, in)
+		if len(unmet) > 0 {
+			return nil, errors.Errorf("replacing %s with %s in %s would leave needs unmet: %s",
+				out.Markdown(), in.Markdown(), shift.Markdown(), unmet.Markdown())
+		}
+		return shift, nil
+	}
+
+	shift, err = check(swap.ShiftNumber, from, with)
+	if err != nil {
+		return nil, nil, err
+	}
+	if swap.TheirShiftNumber == NoShift {
+		return shift, nil, nil
+	}
+	if swap.TheirShiftNumber == swap.ShiftNumber {
+		return nil, nil, errors.New("can't swap a shift for itself")
+	}
+	theirShift, err = check(swap.TheirShiftNumber, with, from)
+	if err != nil {
+		return nil, nil, err
+	}
+	return shift, theirShift, nil
+}
+
+// swapUnmetNeeds returns the needs of the shift that are met, and would no
+// longer be if out were replaced with in, taking out's role.
+func (rotation *Rotation) swapUnmetNeeds(shift *Shift, out, in *User) store.Needs {
+	before, _ := rotation.unmetShift(shift)
+
+	swapped := *shift
+	s := *shift.Shift
+	swapped.Shift = &s
+	swapped.MattermostUserIDs = store.IDMap{}
+	for id := range shift.MattermostUserIDs {
+		swapped.MattermostUserIDs[id] = store.NotEmpty
+	}
+	delete(swapped.MattermostUserIDs, out.MattermostUserID)
+	swapped.MattermostUserIDs[in.MattermostUserID] = store.NotEmpty
+	swapped.Roles = map[string]string{}
+	for id, role := range shift.Roles {
+		swapped.Roles[id] = role
+	}
+	delete(swapped.Roles, out.MattermostUserID)
+	if shift.Roles[out.MattermostUserID] != "" {
+		swapped.Roles[in.MattermostUserID] = shift.Roles[out.MattermostUserID]
+	}
+	after, _ := rotation.unmetShift(&swapped)
+
+	var unmet store.Needs
+	for _, need := range after {
+		worse := true
+		for _, was := range before {
+			if was.Skill == need.Skill && was.Level == need.Level && was.Min >= need.Min {
+				worse = false
+				break
+			}
+		}
+		if worse {
+			unmet = append(unmet, need)
+		}
+	}
+	return unmet
+}
+
+// replaceInShift replaces out with in, who takes out's role.
+func (sl *solarLottery) replaceInShift(rotation *Rotation, shift *Shift, out, in *User) error {
+	var roles map[string]string
+	if shift.Roles[out.MattermostUserID] != "" {
+		roles = map[string]string{in.MattermostUserID: shift.Roles[out.MattermostUserID]}
+	}
+	_, err := sl.leaveShift(rotation, shift.ShiftNumber, shift, UserMap{out.MattermostUserID: out}, true)
+	if err != nil {
+		return err
+	}
+	_, err = sl.joinShift(rotation, shift.ShiftNumber, shift, UserMap{in.MattermostUserID: in}, roles, true)
+	if err != nil {
+		// Put out back, so the shift is left as it was.
+		_, undoErr := sl.joinShift(rotation, shift.ShiftNumber, shift, UserMap{out.MattermostUserID: out}, nil, true)
+		if undoErr != nil {
+			sl.Errorf("failed to put %s back into %s: %v", out.Markdown(), shift.Markdown(), undoErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (sl *solarLottery) swapAttachment(rotation *Rotation, swap *ShiftSwap, shift, theirShift *Shift) *model.SlackAttachment {
+	text := fmt.Sprintf("%s asks you to take over %s, %s to %s",
+		sl.actingUser.Markdown(), shift.Markdown(), shift.Start, shift.End)
+	if theirShift != nil {
+		text += fmt.Sprintf(", in exchange for your %s, %s to %s",
+			theirShift.Markdown(), theirShift.Start, theirShift.End)
+	}
+	return &model.SlackAttachment{
+		Title: "Shift swap request",
+		Text:  text + ".",
+		Actions: []*model.PostAction{
+			sl.postAction("Accept", PathSwap, swap.ToContext(SwapActionAccept)),
+			sl.postAction("Decline", PathSwap, swap.ToContext(SwapActionDecline)),
+		},
+	}
+}
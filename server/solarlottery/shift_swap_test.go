@@ -0,0 +1,81 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+func TestShiftSwapContext(t *testing.T) {
+	swap := &ShiftSwap{
+		Swap: &store.Swap{
+			SwapID:               "test-swap-ID",
+			RotationID:           "test-rotation-ID",
+			ShiftNumber:          3,
+			TheirShiftNumber:     NoShift,
+			FromMattermostUserID: "a",
+			WithMattermostUserID: "b",
+		},
+	}
+
+	// The context makes a round trip through JSON in the post's props.
+	data, err := json.Marshal(swap.ToContext(SwapActionAccept))
+	require.NoError(t, err)
+	context := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &context))
+
+	swapID, action, err := SwapFromContext(context)
+	require.NoError(t, err)
+	require.Equal(t, SwapActionAccept, action)
+	require.Equal(t, "test-swap-ID", swapID)
+
+	delete(context, "swap_id")
+	_, _, err = SwapFromContext(context)
+	require.Error(t, err)
+}
+
+func TestShiftSwapUnmetNeeds(t *testing.T) {
+	rotation := &Rotation{
+		Rotation: store.NewRotation("test"),
+		Users:    UserMap{},
+	}
+	rotation.Size = 2
+	rotation.Needs = store.Needs{store.NewNeed("server", 2, 1), store.NewNeed("webapp", 1, 2)}
+	for id, skills := range map[string]store.IntMap{
+		"a": {"server": 2},
+		"b": {"server": 1},
+		"c": {"server": 3},
+		"d": {"webapp": 1},
+	} {
+		user := &User{User: store.NewUser(id)}
+		user.SkillLevels = skills
+		rotation.MattermostUserIDs[id] = id
+		rotation.Users[id] = user
+	}
+	shift := &Shift{Shift: store.NewShift("", "", store.IDMap{"a": store.NotEmpty, "b": store.NotEmpty})}
+
+	for name, tc := range map[string]struct {
+		out, in  string
+		expected store.Needs
+	}{
+		"keeps server":             {out: "a", in: "c"},
+		"loses server":             {out: "a", in: "d", expected: store.Needs{store.NewNeed("server", 2, 1)}},
+		"webapp was unmet already": {out: "b", in: "c"},
+		"helps webapp":             {out: "b", in: "d"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			unmet := rotation.swapUnmetNeeds(shift, rotation.Users[tc.out], rotation.Users[tc.in])
+			require.Equal(t, tc.expected, unmet)
+		})
+	}
+
+	// the needs of the rotation are not modified
+	require.Equal(t, store.Needs{store.NewNeed("server", 2, 1), store.NewNeed("webapp", 1, 2)}, rotation.Needs)
+	require.Len(t, shift.MattermostUserIDs, 2)
+}
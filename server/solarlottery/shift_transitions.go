@@ -14,6 +14,7 @@ var ErrAlreadyExists = errors.New("already exists")
 func (sl *solarLottery) OpenShift(rotation *Rotation, shiftNumber int) (*Shift, error) {
 	err := sl.Filter(
 		withActingUserExpanded,
+		withRotationManager(rotation),
 	)
 	if err != nil {
 		return nil, err
@@ -40,10 +41,19 @@ func (sl *solarLottery) OpenShift(rotation *Rotation, shiftNumber int) (*Shift,
 	shift.Status = store.ShiftStatusOpen
 
 	err = sl.ShiftStore.StoreShift(rotation.RotationID, shiftNumber, shift.Shift)
+	if store.IsConflict(err) {
+		// Opened concurrently by someone else.
+		shift, err = sl.loadShift(rotation, shiftNumber)
+		if err != nil {
+			return nil, err
+		}
+		return shift, ErrAlreadyExists
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	sl.audit(AuditShiftOpen, rotation.RotationID, shiftNumber, nil, nil, shiftFields(shift))
 	sl.messageShiftOpened(rotation, shift)
 	logger.Infof("%s opened %s.", sl.actingUser.Markdown(), shift.Markdown())
 	return shift, nil
@@ -53,6 +63,7 @@ func (sl *solarLottery) StartShift(rotation *Rotation, shiftNumber int) (*Shift,
 	err := sl.Filter(
 		withActingUserExpanded,
 		withRotationExpanded(rotation),
+		withRotationManager(rotation),
 	)
 	if err != nil {
 		return nil, err
@@ -76,6 +87,7 @@ func (sl *solarLottery) StartShift(rotation *Rotation, shiftNumber int) (*Shift,
 func (sl *solarLottery) DebugDeleteShift(rotation *Rotation, shiftNumber int) error {
 	err := sl.Filter(
 		withActingUserExpanded,
+		withActingUserAdmin,
 	)
 	if err != nil {
 		return err
@@ -91,6 +103,7 @@ func (sl *solarLottery) DebugDeleteShift(rotation *Rotation, shiftNumber int) er
 	if err != nil {
 		return err
 	}
+	sl.audit(AuditShiftDebugDelete, rotation.RotationID, shiftNumber, nil, nil, nil)
 
 	logger.Infof("%s deleted shift %v in %s.", sl.actingUser.Markdown(), shiftNumber, rotation.Markdown())
 	return nil
@@ -99,6 +112,7 @@ func (sl *solarLottery) DebugDeleteShift(rotation *Rotation, shiftNumber int) er
 func (sl *solarLottery) FinishShift(rotation *Rotation, shiftNumber int) (*Shift, error) {
 	err := sl.Filter(
 		withActingUserExpanded,
+		withRotationManager(rotation),
 	)
 	if err != nil {
 		return nil, err
@@ -127,23 +141,37 @@ func (sl *solarLottery) startShift(rotation *Rotation, shiftNumber int) (*Shift,
 	if shift.Status == store.ShiftStatusStarted {
 		return shift, errors.New("already started")
 	}
-	if shift.Status != store.ShiftStatusOpen {
-		return nil, errors.Errorf("can't start a shift which is %s, must be open", shift.Status)
-	}
 
-	shift.Status = store.ShiftStatusStarted
+	before := shiftFields(shift)
+	err = sl.updateShift(rotation, shift, func(shift *Shift) error {
+		if shift.Status != store.ShiftStatusOpen {
+			return errors.Errorf("can't start a shift which is %s, must be open", shift.Status)
+		}
+		shift.Status = store.ShiftStatusStarted
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sl.audit(AuditShiftStart, rotation.RotationID, shiftNumber, rotation.ShiftUsers(shift), before, shiftFields(shift))
 
 	for _, user := range rotation.ShiftUsers(shift) {
-		rotation.markShiftUserServed(user, shiftNumber, shift)
-		_, err = sl.storeUserWelcomeNew(user)
+		_, err = sl.storeUserWelcomeNew(user, func(user *User) error {
+			rotation.markShiftUserServed(user, shiftNumber, shift)
+			return nil
+		})
 		if err != nil {
 			return nil, err
 		}
 	}
-
-	err = sl.ShiftStore.StoreShift(rotation.RotationID, shiftNumber, shift.Shift)
-	if err != nil {
-		return nil, err
+	if rotation.Type == QueueType {
+		err = sl.updateRotation(rotation, func(rotation *Rotation) error {
+			rotation.queueToBack(shift.MattermostUserIDs)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	sl.messageShiftStarted(rotation, shift)
@@ -158,17 +186,42 @@ func (sl *solarLottery) finishShift(rotation *Rotation, shiftNumber int) (*Shift
 	if shift.Status == store.ShiftStatusFinished {
 		return shift, nil
 	}
-	if shift.Status != store.ShiftStatusStarted {
-		return nil, errors.Errorf("can't finish a shift which is %s, must be started", shift.Status)
-	}
 
-	shift.Status = store.ShiftStatusFinished
-	err = sl.ShiftStore.StoreShift(rotation.RotationID, shiftNumber, shift.Shift)
+	before := shiftFields(shift)
+	err = sl.updateShift(rotation, shift, func(shift *Shift) error {
+		if shift.Status != store.ShiftStatusStarted {
+			return errors.Errorf("can't finish a shift which is %s, must be started", shift.Status)
+		}
+		shift.Status = store.ShiftStatusFinished
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	sl.audit(AuditShiftFinish, rotation.RotationID, shiftNumber, rotation.ShiftUsers(shift), before, shiftFields(shift))
 
 	sl.messageShiftFinished(rotation, shift)
 
 	return shift, nil
 }
+
+// updateShift applies updatef to the shift and stores it. If the shift has been
+// modified concurrently, it is reloaded and updatef is re-applied.
+func (sl *solarLottery) updateShift(rotation *Rotation, shift *Shift, updatef func(*Shift) error) error {
+	return retryOnConflict(
+		func() error {
+			err := updatef(shift)
+			if err != nil {
+				return err
+			}
+			return sl.ShiftStore.StoreShift(rotation.RotationID, shift.ShiftNumber, shift.Shift)
+		},
+		func() error {
+			stored, err := sl.ShiftStore.LoadShift(rotation.RotationID, shift.ShiftNumber)
+			if err != nil {
+				return err
+			}
+			shift.Shift = stored
+			return nil
+		})
+}
@@ -14,6 +14,7 @@ import (
 const DayDuration = time.Hour * 24
 const WeekDuration = DayDuration * 7
 const DateFormat = "2006-01-02"
+const HandoffTimeFormat = "15:04"
 
 type Shifts interface {
 	ListShifts(*Rotation, int, int) ([]*Shift, error)
@@ -23,6 +24,14 @@ type Shifts interface {
 	DebugDeleteShift(*Rotation, int) error
 	FillShift(*Rotation, int) (*Shift, UserMap, error)
 	IsShiftReady(rotation *Rotation, shiftNumber int) (shift *Shift, ready bool, whyNot string, err error)
+	RequestSwap(rotation *Rotation, shiftNumber int, withUsername string, theirShiftNumber int) (*ShiftSwap, error)
+	LoadSwap(swapID string) (*ShiftSwap, error)
+	AcceptSwap(*Rotation, *ShiftSwap) error
+	DeclineSwap(*Rotation, *ShiftSwap) error
+	WithdrawSwap(*Rotation, *ShiftSwap) error
+	ConfirmShift(rotation *Rotation, shiftNumber int, now time.Time) (*Shift, error)
+	DeclineShift(*Rotation, int) (*Shift, error)
+	ReindexShifts(rotation *Rotation) ([]int, error)
 }
 
 type Shift struct {
@@ -32,6 +41,7 @@ type Shift struct {
 
 	ShiftNumber  int
 	RotationName string
+	WindowName   string
 }
 
 func (shift *Shift) Clone(deep bool) *Shift {
@@ -45,14 +55,41 @@ func (shift *Shift) Clone(deep bool) *Shift {
 func (shift Shift) MarkdownBullets(rotation *Rotation) string {
 	out := fmt.Sprintf("- %s\n", shift.Markdown())
 	out += fmt.Sprintf("  - Status: **%s**\n", shift.Status)
+	if shift.Autopilot.Seed != 0 {
+		out += fmt.Sprintf("  - Filled with seed: `%v`\n", shift.Autopilot.Seed)
+	}
 	out += fmt.Sprintf("  - Users: **%v**\n", len(shift.MattermostUserIDs))
-	for _, user := range rotation.ShiftUsers(&shift) {
-		out += fmt.Sprintf("    - %s\n", user.MarkdownWithSkills())
+	for _, tier := range rotation.ShiftUsersByTier(&shift) {
+		for _, user := range tier {
+			acknowledged := ""
+			if !shift.Acknowledged[user.MattermostUserID].IsZero() {
+				acknowledged = ", acknowledged"
+			}
+			if shift.Roles[user.MattermostUserID] != "" {
+				out += fmt.Sprintf("    - **%s**: %s%s\n", shift.Roles[user.MattermostUserID], user.MarkdownWithSkills(), acknowledged)
+			} else {
+				out += fmt.Sprintf("    - %s%s\n", user.MarkdownWithSkills(), acknowledged)
+			}
+		}
 	}
 	return out
 }
 
+// Unacknowledged returns the users in the shift who have not acknowledged it.
+func (shift *Shift) Unacknowledged(rotation *Rotation) UserMap {
+	users := UserMap{}
+	for id, user := range rotation.ShiftUsers(shift) {
+		if shift.Acknowledged[id].IsZero() {
+			users[id] = user
+		}
+	}
+	return users
+}
+
 func (shift Shift) Markdown() string {
+	if shift.WindowName != "" {
+		return fmt.Sprintf("%s#%v (%s)", shift.RotationName, shift.ShiftNumber, shift.WindowName)
+	}
 	return fmt.Sprintf("%s#%v", shift.RotationName, shift.ShiftNumber)
 }
 
@@ -66,6 +103,7 @@ func (rotation *Rotation) makeShift(shiftNumber int) (*Shift, error) {
 		StartTime:    start,
 		EndTime:      end,
 		RotationName: rotation.Name,
+		WindowName:   rotation.windowName(shiftNumber),
 		ShiftNumber:  shiftNumber,
 	}, nil
 }
@@ -80,14 +118,6 @@ func (sl *solarLottery) loadShift(rotation *Rotation, shiftNumber int) (*Shift,
 		return nil, err
 	}
 	shift.Shift = s
-
-	shift.StartTime, shift.EndTime, err = ParseDatePair(s.Start, s.End)
-	if err != nil {
-		return nil, err
-	}
-
-	shift.RotationName = rotation.Name
-	shift.ShiftNumber = shiftNumber
 	return shift, nil
 }
 
@@ -104,7 +134,9 @@ func (sl *solarLottery) getShiftForGuess(rotation *Rotation, shiftNumber int) (*
 	switch err {
 	case nil:
 		shift = &Shift{
-			Shift: storedShift,
+			Shift:     storedShift,
+			StartTime: start,
+			EndTime:   end,
 		}
 
 	case store.ErrNotFound:
@@ -124,6 +156,7 @@ func (sl *solarLottery) getShiftForGuess(rotation *Rotation, shiftNumber int) (*
 	}
 
 	shift.RotationName = rotation.Name
+	shift.WindowName = rotation.windowName(shiftNumber)
 	shift.ShiftNumber = shiftNumber
 	return shift, created, nil
 }
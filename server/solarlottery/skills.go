@@ -10,6 +10,7 @@ import (
 
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/fields"
 )
 
 type Skills interface {
@@ -54,6 +55,8 @@ func (sl *solarLottery) AddSkill(skillName string) error {
 		return err
 	}
 
+	sl.audit(AuditSkillAdd, "", NoShift, nil, nil, fields.Fields{"Skill": fields.NewStringValue(skillName)})
+
 	logger.Infof("%s added skill %s.", sl.actingUser.Markdown(), skillName)
 	return nil
 }
@@ -62,6 +65,7 @@ func (sl *solarLottery) DeleteSkill(skillName string) error {
 	err := sl.Filter(
 		withKnownSkills,
 		withActingUserExpanded,
+		withActingUserAdmin,
 	)
 	if err != nil {
 		return err
@@ -86,6 +90,8 @@ func (sl *solarLottery) DeleteSkill(skillName string) error {
 	if err != nil {
 		return err
 	}
+	sl.audit(AuditSkillDelete, "", NoShift, nil, fields.Fields{"Skill": fields.NewStringValue(skillName)}, nil)
+
 	logger.Infof("%s deleted skill %s.", sl.actingUser.Markdown(), skillName)
 	return nil
 }
@@ -4,17 +4,24 @@
 package solarlottery
 
 import (
+	"math/rand"
+
 	"github.com/mattermost/mattermost-server/v5/model"
 
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
 )
 
 type SolarLottery interface {
 	bot.Logger
 	PluginAPI
 
+	Admin
+	Audit
+	Backups
+	Calendars
 	Expander
 	Forecaster
 	Autopilot
@@ -26,7 +33,9 @@ type SolarLottery interface {
 }
 
 type Autofiller interface {
-	FillShift(rotation *Rotation, shiftNumber int, shift *Shift, logger bot.Logger) (UserMap, error)
+	// FillShift must use random as its only source of randomness, so that a
+	// fill can be reproduced.
+	FillShift(rotation *Rotation, shiftNumber int, shift *Shift, random *rand.Rand, logger bot.Logger) (UserMap, error)
 }
 
 type Expander interface {
@@ -39,6 +48,7 @@ type PluginAPI interface {
 	GetMattermostUser(mattermostUserID string) (*model.User, error)
 	GetMattermostUserByUsername(mattermostUsername string) (*model.User, error)
 	IsPluginAdmin(mattermostUserID string) (bool, error)
+	GetFile(fileID string) (*model.FileInfo, []byte, error)
 	UpdateStoredConfig(f func(*config.Config))
 	Clean() error
 }
@@ -47,11 +57,16 @@ type PluginAPI interface {
 type Dependencies struct {
 	Autofillers map[string]Autofiller
 	PluginAPI
+	AuditStore    store.AuditStore
+	CalendarStore store.CalendarStore
+	// LockStore holds the cluster-wide locks, see kvstore.Mutex.
+	LockStore     kvstore.KVStore
 	Logger        bot.Logger
 	Poster        bot.Poster
 	RotationStore store.RotationStore
 	ShiftStore    store.ShiftStore
 	SkillsStore   store.SkillsStore
+	SwapStore     store.SwapStore
 	UserStore     store.UserStore
 }
 
@@ -82,7 +97,21 @@ type solarLottery struct {
 
 var _ SolarLottery = (*solarLottery)(nil)
 
+// New returns the API acting as mattermostUserID. It is meant to serve one
+// request: the users and Mattermost profiles it loads are cached until it is
+// discarded.
 func New(apiConfig Config, mattermostUserID string) SolarLottery {
+	if apiConfig.Dependencies != nil {
+		deps := *apiConfig.Dependencies
+		if deps.UserStore != nil {
+			deps.UserStore = newCachedUserStore(deps.UserStore)
+		}
+		if deps.PluginAPI != nil {
+			deps.PluginAPI = newCachedPluginAPI(deps.PluginAPI)
+		}
+		apiConfig.Dependencies = &deps
+	}
+
 	return &solarLottery{
 		Logger: apiConfig.Logger.With(bot.LogContext{
 			"MattermostUserID": mattermostUserID,
@@ -91,3 +120,23 @@ func New(apiConfig Config, mattermostUserID string) SolarLottery {
 		actingMattermostUserID: mattermostUserID,
 	}
 }
+
+// maxConflictRetries is how many times an update is re-applied to a freshly
+// loaded record if the record has been modified concurrently.
+const maxConflictRetries = 3
+
+// retryOnConflict calls update, and if it fails with a store.ConflictError,
+// calls reload and tries again. The last ConflictError is returned once the
+// retries are exhausted.
+func retryOnConflict(update, reload func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := update()
+		if !store.IsConflict(err) || attempt >= maxConflictRetries {
+			return err
+		}
+		err = reload()
+		if err != nil {
+			return err
+		}
+	}
+}
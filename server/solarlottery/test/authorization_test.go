@@ -0,0 +1,55 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store/mock_store"
+)
+
+func TestUpdateRotationAuthorization(t *testing.T) {
+	for name, tc := range map[string]struct {
+		owners           store.IDMap
+		isAdmin          bool
+		expectAuthorized bool
+	}{
+		"owner":        {owners: store.IDMap{"acting-user-ID": "acting-user"}, expectAuthorized: true},
+		"plugin admin": {isAdmin: true, expectAuthorized: true},
+		"other owner":  {owners: store.IDMap{"other-user-ID": "other-user"}},
+		"no owners":    {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			rotation := GetTestRotation().WithStart("2020-01-16")
+			rotation.Owners = tc.owners
+
+			rotationStore := mock_store.NewMockRotationStore(ctrl)
+			if tc.expectAuthorized {
+				rotationStore.EXPECT().StoreRotation(gomock.Any()).Return(nil)
+			}
+
+			err := solarLotteryForUpdate(ctrl, rotationStore, tc.isAdmin).UpdateRotation(rotation, func(rotation *sl.Rotation) error {
+				rotation.Size = 3
+				return nil
+			})
+			if tc.expectAuthorized {
+				require.NoError(t, err)
+				require.Equal(t, 3, rotation.Size)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, sl.ErrNotAuthorized, errors.Cause(err))
+				require.NotEqual(t, 3, rotation.Size)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/mock_solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store/mock_store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+func solarLotteryForUpdate(ctrl *gomock.Controller, rotationStore store.RotationStore, isAdmin bool) sl.SolarLottery {
+	actingUser := store.NewUser("acting-user-ID")
+	actingUser.PluginVersion = "test"
+	userStore := mock_store.NewMockUserStore(ctrl)
+	userStore.EXPECT().LoadUser(actingUser.MattermostUserID).AnyTimes().Return(actingUser, nil)
+
+	pluginAPI := mock_solarlottery.NewMockPluginAPI(ctrl)
+	pluginAPI.EXPECT().GetMattermostUser(actingUser.MattermostUserID).AnyTimes().Return(
+		&model.User{Id: actingUser.MattermostUserID, Username: "acting-user"}, nil)
+	pluginAPI.EXPECT().IsPluginAdmin(actingUser.MattermostUserID).AnyTimes().Return(isAdmin, nil)
+
+	auditStore := mock_store.NewMockAuditStore(ctrl)
+	auditStore.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	return sl.New(sl.Config{
+		Dependencies: &sl.Dependencies{
+			AuditStore:    auditStore,
+			UserStore:     userStore,
+			RotationStore: rotationStore,
+			PluginAPI:     pluginAPI,
+			Logger:        &bot.NilLogger{},
+		},
+		Config: &config.Config{},
+	}, actingUser.MattermostUserID)
+}
+
+func TestUpdateRotationRetriesOnConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rotation := GetTestRotation().WithStart("2020-01-16")
+	rotation.Revision = 1
+
+	// Someone else renamed the rotation since it was loaded.
+	stored := rotation.Rotation.Clone(true)
+	stored.Name = "renamed"
+	stored.Revision = 2
+
+	rotationStore := mock_store.NewMockRotationStore(ctrl)
+	gomock.InOrder(
+		rotationStore.EXPECT().StoreRotation(gomock.Any()).Return(
+			&store.ConflictError{Type: "rotation", ID: rotation.RotationID}),
+		rotationStore.EXPECT().LoadRotation(rotation.RotationID).Return(stored, nil),
+		rotationStore.EXPECT().StoreRotation(gomock.Any()).DoAndReturn(
+			func(r *store.Rotation) error {
+				require.Equal(t, 2, r.Revision)
+				r.Revision++
+				return nil
+			}),
+	)
+
+	updates := 0
+	err := solarLotteryForUpdate(ctrl, rotationStore, true).UpdateRotation(rotation, func(rotation *sl.Rotation) error {
+		updates++
+		rotation.Size = 3
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, updates)
+	require.Equal(t, "renamed", rotation.Name)
+	require.Equal(t, 3, rotation.Size)
+	require.Equal(t, 3, rotation.Revision)
+}
+
+func TestUpdateRotationConflictRetriesExhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rotation := GetTestRotation().WithStart("2020-01-16")
+
+	rotationStore := mock_store.NewMockRotationStore(ctrl)
+	rotationStore.EXPECT().StoreRotation(gomock.Any()).Times(4).Return(
+		&store.ConflictError{Type: "rotation", ID: rotation.RotationID})
+	rotationStore.EXPECT().LoadRotation(rotation.RotationID).Times(3).DoAndReturn(
+		func(string) (*store.Rotation, error) {
+			return rotation.Rotation.Clone(true), nil
+		})
+
+	err := solarLotteryForUpdate(ctrl, rotationStore, true).UpdateRotation(rotation, func(*sl.Rotation) error {
+		return nil
+	})
+	require.Error(t, err)
+	require.True(t, store.IsConflict(err))
+}
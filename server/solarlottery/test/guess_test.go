@@ -101,7 +101,7 @@ func TestPrepareShiftHappy(t *testing.T) {
 	rotation = rotation.WithStart("2020-01-16")
 
 	sl := solarLotteryForGuess(t, ctrl, rotation, AllUsers())
-	shifts, err := sl.Guess(rotation, 0, 1)
+	shifts, err := sl.Guess(rotation, 0, 1, 1)
 
 	require.Nil(t, err)
 	assert.Len(t, shifts, 1)
@@ -125,7 +125,7 @@ func TestPrepareShiftEvenDistribution(t *testing.T) {
 
 	sampleSize := 200
 	counters := store.IntMap{}
-	shifts, err := sl.Guess(rotation, 3, len(AllUsers())*sampleSize)
+	shifts, err := sl.Guess(rotation, 3, len(AllUsers())*sampleSize, 1)
 	require.Nil(t, err)
 	require.Len(t, shifts, len(AllUsers())*sampleSize)
 
@@ -142,3 +142,34 @@ func TestPrepareShiftEvenDistribution(t *testing.T) {
 		assert.Less(t, c, sampleSize*110/100, k)
 	}
 }
+
+func TestGuessReplayWithSeed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rotation := GetTestRotation()
+	rotation.Size = 2
+	rotation.Needs = store.Needs{
+		NeedWebapp_L1_Min1(),
+	}
+	rotation = rotation.WithUsers(AllUsers())
+	rotation = rotation.WithStart("2020-01-16")
+
+	sl := solarLotteryForGuess(t, ctrl, rotation, AllUsers())
+
+	guess := func(start, numShifts int, seed int64) []store.IDMap {
+		shifts, err := sl.Guess(rotation, start, numShifts, seed)
+		require.NoError(t, err)
+		var ids []store.IDMap
+		for _, shift := range shifts {
+			ids = append(ids, shift.MattermostUserIDs)
+		}
+		return ids
+	}
+
+	first := guess(0, 10, 42)
+	require.Equal(t, first, guess(0, 10, 42))
+
+	// The first shift of a guess can be replayed on its own.
+	require.Equal(t, first[:1], guess(0, 1, 42))
+}
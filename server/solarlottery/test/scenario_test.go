@@ -0,0 +1,483 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	sl "github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/solarlottery/autofill/solarlottery"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot/mock_bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+// scenarioPluginAPI is a fake sl.PluginAPI with a fixed set of users, named
+// after their IDs.
+type scenarioPluginAPI struct {
+	users  map[string]*model.User
+	admins map[string]bool
+}
+
+var _ sl.PluginAPI = (*scenarioPluginAPI)(nil)
+
+func (api *scenarioPluginAPI) GetMattermostUser(mattermostUserID string) (*model.User, error) {
+	user := api.users[mattermostUserID]
+	if user == nil {
+		return nil, store.ErrNotFound
+	}
+	return user, nil
+}
+
+func (api *scenarioPluginAPI) GetMattermostUserByUsername(mattermostUsername string) (*model.User, error) {
+	for _, user := range api.users {
+		if user.Username == mattermostUsername {
+			return user, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (api *scenarioPluginAPI) IsPluginAdmin(mattermostUserID string) (bool, error) {
+	return api.admins[mattermostUserID], nil
+}
+
+func (api *scenarioPluginAPI) GetFile(fileID string) (*model.FileInfo, []byte, error) {
+	return nil, nil, errors.New("not supported")
+}
+
+func (api *scenarioPluginAPI) UpdateStoredConfig(f func(*config.Config)) {}
+
+func (api *scenarioPluginAPI) Clean() error {
+	return nil
+}
+
+// scenario runs multi-step flows end to end, on an in-memory store shared by
+// the consecutive requests.
+type scenario struct {
+	config sl.Config
+	store  store.Store
+}
+
+func newScenario(t testing.TB, ctrl *gomock.Controller, admin string, usernames ...string) *scenario {
+	api := &scenarioPluginAPI{
+		users:  map[string]*model.User{},
+		admins: map[string]bool{admin: true},
+	}
+	for _, name := range append([]string{admin}, usernames...) {
+		api.users[name] = &model.User{Id: name, Username: name}
+	}
+
+	poster := mock_bot.NewMockPoster(ctrl)
+	poster.EXPECT().DM(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	poster.EXPECT().DMWithAttachments(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	logger := &bot.NilLogger{}
+	kv := kvstore.NewMemStore()
+	s := store.NewStore(kv, logger)
+	return &scenario{
+		store: s,
+		config: sl.Config{
+			Config: &config.Config{
+				PluginID:      "test-plugin",
+				PluginVersion: "test",
+				BotUserID:     "bot",
+			},
+			Dependencies: &sl.Dependencies{
+				Autofillers: map[string]sl.Autofiller{
+					"":                solarlottery.New(logger), // default
+					solarlottery.Type: solarlottery.New(logger),
+				},
+				AuditStore:    s,
+				CalendarStore: s,
+				LockStore:     kvstore.NewHashedKeyStore(kv, store.LockKeyPrefix),
+				RotationStore: s,
+				ShiftStore:    s,
+				SkillsStore:   s,
+				SwapStore:     s,
+				UserStore:     s,
+				Logger:        logger,
+				Poster:        poster,
+				PluginAPI:     api,
+			},
+		},
+	}
+}
+
+// as starts a new request by the user.
+func (s *scenario) as(mattermostUserID string) sl.SolarLottery {
+	return sl.New(s.config, mattermostUserID)
+}
+
+func TestScenarioFillAndStartShift(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newScenario(t, ctrl, "admin", "u1", "u2", "u3", "owner")
+	start := time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, s.as("admin").Qualify("@u1,@u2", "webapp", sl.Intermediate))
+	// users can not rate their own skills, only the admins and the rotation
+	// owners can
+	err := s.as("u3").Qualify("", "server", sl.Expert)
+	require.Equal(t, sl.ErrNotAuthorized, errors.Cause(err))
+	err = s.as("owner").Qualify("@u3", "server", sl.Beginner)
+	require.Equal(t, sl.ErrNotAuthorized, errors.Cause(err))
+
+	rotation, err := s.as("owner").MakeRotation("test")
+	require.NoError(t, err)
+	rotation.Period = sl.EveryWeek
+	rotation.Start = start.Format(sl.DateFormat)
+	rotation.Size = 2
+	rotation.Needs = store.Needs{store.NewNeed("webapp", int(sl.Intermediate), 1)}
+	require.NoError(t, s.as("owner").AddRotation(rotation))
+	rotationID := rotation.RotationID
+
+	load := func() *sl.Rotation {
+		rotation, err := s.as("owner").LoadRotation(rotationID)
+		require.NoError(t, err)
+		return rotation
+	}
+	require.Equal(t, store.IDMap{"owner": "owner"}, load().Owners)
+	require.NoError(t, s.as("owner").Qualify("@u3", "server", sl.Beginner))
+
+	// users join themselves, the owner can add others
+	_, err = s.as("u3").JoinRotation("", load(), start)
+	require.NoError(t, err)
+	_, err = s.as("u3").JoinRotation("@u1", load(), start)
+	require.Equal(t, sl.ErrNotAuthorized, errors.Cause(err))
+	_, err = s.as("owner").JoinRotation("@u1,@u2", load(), start)
+	require.NoError(t, err)
+	require.Len(t, load().MattermostUserIDs, 3)
+
+	_, err = s.as("u1").OpenShift(load(), 0)
+	require.Equal(t, sl.ErrNotAuthorized, errors.Cause(err))
+	_, err = s.as("owner").OpenShift(load(), 0)
+	require.NoError(t, err)
+	shift, added, err := s.as("owner").FillShift(load(), 0)
+	require.NoError(t, err)
+	require.Len(t, added, 2)
+	require.Len(t, shift.MattermostUserIDs, 2)
+	require.True(t, shift.MattermostUserIDs["u1"] != "" || shift.MattermostUserIDs["u2"] != "",
+		"needs an intermediate webapp user")
+	_, err = s.as("owner").StartShift(load(), 0)
+	require.NoError(t, err)
+
+	shifts, err := s.as("u1").ListShifts(load(), 0, 5)
+	require.NoError(t, err)
+	require.Len(t, shifts, 1)
+	require.Equal(t, store.ShiftStatusStarted, shifts[0].Status)
+
+	// the users in the shift have it in their events
+	for id := range shift.MattermostUserIDs {
+		user, err := s.store.LoadUser(id)
+		require.NoError(t, err)
+		require.Len(t, user.Events, 1)
+		require.Equal(t, rotationID, user.Events[0].RotationID)
+	}
+
+	// all of it is in the audit log, newest first
+	entries, err := s.as("owner").ListAudit(load(), "", time.Time{}, 0)
+	require.NoError(t, err)
+	actions := []string{}
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	require.Equal(t, []string{
+		sl.AuditShiftStart,
+		sl.AuditShiftJoin,
+		sl.AuditShiftOpen,
+		sl.AuditRotationJoin,
+		sl.AuditRotationJoin,
+		sl.AuditRotationAdd,
+	}, actions)
+
+	backup, err := s.as("admin").ExportBackup(start)
+	require.NoError(t, err)
+	require.NoError(t, backup.Validate())
+	require.Len(t, backup.Rotations, 1)
+	require.Len(t, backup.Shifts, 1)
+
+	// archived rotations are backed up, and restored, with their shifts
+	require.NoError(t, s.as("owner").ArchiveRotation(load()))
+	backup, err = s.as("admin").ExportBackup(start)
+	require.NoError(t, err)
+	require.NoError(t, backup.Validate())
+	require.Len(t, backup.Rotations, 1)
+	require.True(t, backup.Rotations[0].IsArchived)
+	require.Len(t, backup.Shifts, 1)
+	require.NoError(t, s.as("admin").ImportBackup(backup, sl.ImportReplace))
+	archived, err := s.store.LoadRotation(rotationID)
+	require.NoError(t, err)
+	require.True(t, archived.IsArchived)
+	_, err = s.store.LoadShift(rotationID, shift.ShiftNumber)
+	require.NoError(t, err)
+}
+
+func TestScenarioWindowsWithStoredShifts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newScenario(t, ctrl, "admin", "owner")
+
+	rotation, err := s.as("owner").MakeRotation("test")
+	require.NoError(t, err)
+	rotation.Period = sl.EveryDay
+	rotation.Start = "2020-01-06"
+	rotation.Size = 1
+	require.NoError(t, s.as("owner").AddRotation(rotation))
+	load := func() *sl.Rotation {
+		rotation, err = s.as("owner").LoadRotation(rotation.RotationID)
+		require.NoError(t, err)
+		return rotation
+	}
+	setWindow := func(window *store.ShiftWindow) error {
+		return s.as("owner").UpdateRotation(load(), func(rotation *sl.Rotation) error {
+			return rotation.SetWindow(window)
+		})
+	}
+
+	require.NoError(t, setWindow(&store.ShiftWindow{Name: "AMER", Start: 0}))
+	require.NoError(t, setWindow(&store.ShiftWindow{Name: "EMEA", Start: 8 * time.Hour}))
+	_, err = s.as("owner").OpenShift(load(), 3)
+	require.NoError(t, err)
+
+	// shift 3 is EMEA on Jan 7, more windows would move it
+	err = setWindow(&store.ShiftWindow{Name: "APAC", Start: 16 * time.Hour})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "has 1 stored shift(s)")
+	err = setWindow(&store.ShiftWindow{Name: "EMEA", Start: 9 * time.Hour})
+	require.Error(t, err)
+	err = s.as("owner").UpdateRotation(load(), func(rotation *sl.Rotation) error {
+		return rotation.DeleteWindow("EMEA")
+	})
+	require.Error(t, err)
+	require.Len(t, load().Windows, 2)
+	require.Equal(t, 8*time.Hour, load().Windows[1].Start)
+
+	// changes that keep the shifts where they are
+	require.NoError(t, setWindow(&store.ShiftWindow{Name: "EMEA", Start: 8 * time.Hour, Size: 2}))
+	err = s.as("owner").UpdateRotation(load(), func(rotation *sl.Rotation) error {
+		return rotation.ChangeWindowNeed("EMEA", "server", sl.Beginner, store.NewNeed("server", int(sl.Beginner), 1))
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, load().Windows[1].Size)
+	require.Len(t, load().Windows[1].Needs, 1)
+}
+
+func TestScenarioRotationCalendar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newScenario(t, ctrl, "admin", "u1", "u2", "owner")
+	start := time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, s.as("admin").Qualify("@u1", "server", sl.Beginner))
+	rotation, err := s.as("owner").MakeRotation("test")
+	require.NoError(t, err)
+	rotation.Period = sl.EveryWeek
+	rotation.Start = start.Format(sl.DateFormat)
+	rotation.Size = 1
+	rotation.Needs = store.Needs{store.NewNeed("server", int(sl.Beginner), 1)}
+	require.NoError(t, s.as("owner").AddRotation(rotation))
+	load := func() *sl.Rotation {
+		rotation, err = s.as("owner").LoadRotation(rotation.RotationID)
+		require.NoError(t, err)
+		return rotation
+	}
+	_, err = s.as("owner").JoinRotation("@u1,@u2", load(), start)
+	require.NoError(t, err)
+	_, err = s.as("owner").OpenShift(load(), 0)
+	require.NoError(t, err)
+	_, _, err = s.as("owner").FillShift(load(), 0)
+	require.NoError(t, err)
+	_, err = s.as("owner").StartShift(load(), 0)
+	require.NoError(t, err)
+
+	token, err := s.as("u2").GetCalendarToken(false)
+	require.NoError(t, err)
+	// shift 2 is the current one, shift 1 was never stored
+	now := start.Add(15 * 24 * time.Hour)
+	calendar := func() string {
+		data, err := s.as("u2").RotationCalendar(token, rotation.RotationID, now)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	ics := calendar()
+	require.Equal(t, 1+sl.CalendarShifts, strings.Count(ics, "BEGIN:VEVENT"))
+	require.Equal(t, sl.CalendarShifts, strings.Count(ics, "STATUS:TENTATIVE"))
+	require.Contains(t, ics, "DTSTART:20200106T000000Z\r\n")
+	require.NotContains(t, ics, "DTSTART:20200113T000000Z\r\n")
+	require.Equal(t, 1+sl.CalendarShifts, strings.Count(ics, "Users: u1\r\n"))
+
+	// understaffed, the shifts can not be guessed but are still listed
+	err = s.as("owner").UpdateRotation(load(), func(rotation *sl.Rotation) error {
+		rotation.Size = 2
+		rotation.Needs = store.Needs{store.NewNeed("server", int(sl.Beginner), 2)}
+		return nil
+	})
+	require.NoError(t, err)
+	ics = calendar()
+	require.Equal(t, 1+sl.CalendarShifts, strings.Count(ics, "BEGIN:VEVENT"))
+	require.Equal(t, sl.CalendarShifts, strings.Count(ics, "STATUS:TENTATIVE"))
+	require.Equal(t, 1, strings.Count(ics, "Users: u1\r\n"))
+}
+
+func TestScenarioAutopilotAudit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newScenario(t, ctrl, "admin", "u1", "owner")
+	start := time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	rotation, err := s.as("owner").MakeRotation("test")
+	require.NoError(t, err)
+	rotation.Period = sl.EveryWeek
+	rotation.Start = "2020-01-06"
+	rotation.Size = 1
+	require.NoError(t, s.as("owner").AddRotation(rotation))
+	load := func() *sl.Rotation {
+		rotation, err = s.as("owner").LoadRotation(rotation.RotationID)
+		require.NoError(t, err)
+		return rotation
+	}
+	_, err = s.as("owner").JoinRotation("@u1", load(), start)
+	require.NoError(t, err)
+	err = s.as("owner").UpdateRotation(load(), func(rotation *sl.Rotation) error {
+		rotation.Autopilot.On = true
+		rotation.Autopilot.StartFinish = true
+		rotation.Autopilot.Fill = true
+		rotation.Autopilot.FillPrior = 3 * sl.DayDuration
+		return nil
+	})
+	require.NoError(t, err)
+
+	// each run only audits the changes it makes
+	run := func(now time.Time) []string {
+		require.NoError(t, s.as("admin").AutopilotRotation(load(), now))
+		entries, err := s.as("owner").ListAudit(load(), "", time.Time{}, 0)
+		require.NoError(t, err)
+		actions := []string{}
+		for _, entry := range entries {
+			actions = append(actions, entry.Action)
+		}
+		return actions
+	}
+	actions := run(start.Add(time.Hour))
+	require.Equal(t, []string{
+		sl.AuditShiftStart,
+		sl.AuditShiftJoin,
+		sl.AuditShiftOpen,
+		sl.AuditRotationUpdate,
+		sl.AuditRotationJoin,
+		sl.AuditRotationAdd,
+	}, actions)
+	require.Equal(t, actions, run(start.Add(2*time.Hour)))
+}
+
+func TestScenarioDeclineShift(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newScenario(t, ctrl, "admin", "u1", "owner")
+
+	rotation, err := s.as("owner").MakeRotation("test")
+	require.NoError(t, err)
+	rotation.Period = sl.EveryWeek
+	rotation.Start = "2020-03-02"
+	rotation.TimeZone = "America/New_York"
+	rotation.HandoffTime = "18:00"
+	rotation.Size = 1
+	require.NoError(t, s.as("owner").AddRotation(rotation))
+	load := func() *sl.Rotation {
+		rotation, err = s.as("owner").LoadRotation(rotation.RotationID)
+		require.NoError(t, err)
+		return rotation
+	}
+	_, err = s.as("owner").JoinRotation("@u1", load(), time.Time{})
+	require.NoError(t, err)
+	_, err = s.as("owner").OpenShift(load(), 1)
+	require.NoError(t, err)
+	_, _, err = s.as("owner").JoinShift("@u1", load(), 1, "")
+	require.NoError(t, err)
+
+	// u1 is unavailable for the time of the shift, not its dates at midnight UTC
+	_, err = s.as("u1").DeclineShift(load(), 1)
+	require.NoError(t, err)
+	u1, err := s.store.LoadUser("u1")
+	require.NoError(t, err)
+	var unavailable []store.Event
+	for _, event := range u1.Events {
+		if event.Type == store.EventTypePersonal {
+			unavailable = append(unavailable, event)
+		}
+	}
+	require.Len(t, unavailable, 1)
+	require.Equal(t, "2020-03-09T22:00:00Z", unavailable[0].Start)
+	require.Equal(t, "2020-03-16T22:00:00Z", unavailable[0].End)
+}
+
+func TestScenarioSwapOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	s := newScenario(t, ctrl, "admin", "u1", "u2", "owner")
+	s.config.ActionSigningSecret = "test-secret"
+
+	rotation, err := s.as("owner").MakeRotation("test")
+	require.NoError(t, err)
+	rotation.Period = sl.EveryWeek
+	rotation.Start = "2020-01-06"
+	rotation.Size = 1
+	require.NoError(t, s.as("owner").AddRotation(rotation))
+	load := func() *sl.Rotation {
+		rotation, err = s.as("owner").LoadRotation(rotation.RotationID)
+		require.NoError(t, err)
+		return rotation
+	}
+	inShift := func() store.IDMap {
+		shift, err := s.store.LoadShift(rotation.RotationID, 0)
+		require.NoError(t, err)
+		return shift.MattermostUserIDs
+	}
+	_, err = s.as("owner").JoinRotation("@u1,@u2", load(), time.Time{})
+	require.NoError(t, err)
+	_, err = s.as("owner").OpenShift(load(), 0)
+	require.NoError(t, err)
+	_, _, err = s.as("owner").JoinShift("@u1", load(), 0, "")
+	require.NoError(t, err)
+
+	// accepted once, the swap can not be replayed or declined
+	swap, err := s.as("u1").RequestSwap(load(), 0, "u2", sl.NoShift)
+	require.NoError(t, err)
+	require.Equal(t, store.SwapStatusPending, swap.Status)
+	pending, err := s.as("u2").LoadSwap(swap.SwapID)
+	require.NoError(t, err)
+	require.NoError(t, s.as("u2").AcceptSwap(load(), pending))
+	require.Equal(t, store.IDMap{"u2": store.NotEmpty}, inShift())
+	err = s.as("u2").AcceptSwap(load(), pending)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "already accepted")
+	require.Error(t, s.as("u2").DeclineSwap(load(), pending))
+
+	// withdrawn, the swap can no longer be accepted
+	swap, err = s.as("u2").RequestSwap(load(), 0, "u1", sl.NoShift)
+	require.NoError(t, err)
+	require.Error(t, s.as("u1").WithdrawSwap(load(), swap))
+	require.NoError(t, s.as("u2").WithdrawSwap(load(), swap))
+	err = s.as("u1").AcceptSwap(load(), swap)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "already withdrawn")
+	require.Equal(t, store.IDMap{"u2": store.NotEmpty}, inShift())
+
+	stored, err := s.store.LoadSwap(swap.SwapID)
+	require.NoError(t, err)
+	require.Equal(t, store.SwapStatusWithdrawn, stored.Status)
+	require.False(t, stored.Closed.IsZero())
+}
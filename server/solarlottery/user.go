@@ -5,6 +5,7 @@ package solarlottery
 
 import (
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/ical"
 )
 
 type Users interface {
@@ -21,9 +23,11 @@ type Users interface {
 
 	AddEvent(mattermostUsernames string, event Event) error
 	DeleteEvents(mattermostUsernames string, startDate, endDate string) error
+	ImportUnavailable(mattermostUsernames string, r io.Reader, now time.Time, dryRun bool) (UserMap, map[string][]Event, []*ical.Skipped, error)
+	LoadUploadedFile(fileID string) ([]byte, error)
 	Disqualify(mattermostUsernames, skillName string) error
 	JoinRotation(mattermostUsernames string, rotation *Rotation, starting time.Time) (added UserMap, err error)
-	JoinShift(mattermostUsernames string, rotation *Rotation, shiftNumber int) (*Shift, UserMap, error)
+	JoinShift(mattermostUsernames string, rotation *Rotation, shiftNumber int, role string) (*Shift, UserMap, error)
 	LeaveShift(mattermostUsernames string, rotation *Rotation, shiftNumber int) (*Shift, UserMap, error)
 	LeaveRotation(mattermostUsernames string, rotation *Rotation) (deleted UserMap, err error)
 	Qualify(mattermostUsernames, skillName string, level Level) error
@@ -124,7 +128,7 @@ func (user *User) AddEvent(event Event) {
 func (user *User) OverlapEvents(intervalStart, intervalEnd time.Time, remove bool) ([]store.Event, error) {
 	var found, updated []store.Event
 	for _, event := range user.Events {
-		s, e, err := ParseDatePair(event.Start, event.End)
+		s, e, _, err := ParseEventTimes(event)
 		if err != nil {
 			return nil, err
 		}
@@ -151,13 +155,31 @@ func (user *User) OverlapEvents(intervalStart, intervalEnd time.Time, remove boo
 	return found, nil
 }
 
+// IsAvailable returns false if the user has a personal event, or a shift in the
+// same rotation, overlapping the interval.
+func (user *User) IsAvailable(rotationID string, intervalStart, intervalEnd time.Time) (bool, error) {
+	overlappingEvents, err := user.OverlapEvents(intervalStart, intervalEnd, false)
+	if err != nil {
+		return false, err
+	}
+	for _, event := range overlappingEvents {
+		// Unavailable events apply to all rotations, Shift events apply
+		// only to the rotation from which they come.
+		if event.Type == store.EventTypePersonal ||
+			(event.Type == store.EventTypeShift && event.RotationID == rotationID) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (sl *solarLottery) loadOrMakeStoredUser(mattermostUserID string) (*User, bool, error) {
 	storedUser, err := sl.UserStore.LoadUser(mattermostUserID)
 	var user *User
 	if err == store.ErrNotFound {
 		user, err = sl.storeUserWelcomeNew(&User{
 			User: store.NewUser(mattermostUserID),
-		})
+		}, nil)
 		return user, true, err
 	}
 	if err != nil {
@@ -166,18 +188,38 @@ func (sl *solarLottery) loadOrMakeStoredUser(mattermostUserID string) (*User, bo
 	return &User{User: storedUser}, false, nil
 }
 
-// storeUserWelcomeNew checks if the user being stored is new, and welcomes the user.
+// storeUserWelcomeNew applies updatef (if any) to the user and stores it. If the
+// user has been modified concurrently, it is reloaded and updatef is
+// re-applied. If the user being stored is new, it welcomes the user.
 // note that it can be used inside of filters, so it must not use filters itself,
 //  nor assume that any runtime values have been filled.
-func (sl *solarLottery) storeUserWelcomeNew(orig *User) (*User, error) {
-	user := orig.Clone()
-	user.PluginVersion = sl.Config.PluginVersion
-	err := sl.UserStore.StoreUser(user.User)
+func (sl *solarLottery) storeUserWelcomeNew(user *User, updatef func(*User) error) (*User, error) {
+	isNew := user.PluginVersion == ""
+	err := retryOnConflict(
+		func() error {
+			if updatef != nil {
+				err := updatef(user)
+				if err != nil {
+					return err
+				}
+			}
+			user.PluginVersion = sl.Config.PluginVersion
+			return sl.UserStore.StoreUser(user.User)
+		},
+		func() error {
+			stored, err := sl.UserStore.LoadUser(user.MattermostUserID)
+			if err != nil {
+				return err
+			}
+			user.User = stored
+			isNew = stored.PluginVersion == ""
+			return nil
+		})
 	if err != nil {
 		return nil, err
 	}
 
-	if orig.PluginVersion == "" {
+	if isNew {
 		sl.messageWelcomeNewUser(user)
 	}
 
@@ -191,17 +233,18 @@ func (sl *solarLottery) updateUserSkill(user *User, skillName string, level Leve
 		return nil
 	}
 
-	if level == 0 {
-		_, ok := user.SkillLevels[skillName]
-		if !ok {
-			return errors.Errorf("%s does not have skill %s", user.Markdown(), skillName)
+	user, err := sl.storeUserWelcomeNew(user, func(user *User) error {
+		if level == 0 {
+			_, ok := user.SkillLevels[skillName]
+			if !ok {
+				return errors.Errorf("%s does not have skill %s", user.Markdown(), skillName)
+			}
+			delete(user.SkillLevels, skillName)
+		} else {
+			user.SkillLevels[skillName] = int(level)
 		}
-		delete(user.SkillLevels, skillName)
-	} else {
-		user.SkillLevels[skillName] = int(level)
-	}
-
-	user, err := sl.storeUserWelcomeNew(user)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
@@ -69,6 +69,7 @@ func (sl *solarLottery) Qualify(mattermostUsernames, skillName string, level Lev
 	err := sl.Filter(
 		withActingUserExpanded,
 		withMattermostUsersExpanded(mattermostUsernames),
+		withSkillManager,
 	)
 	if err != nil {
 		return err
@@ -86,12 +87,14 @@ func (sl *solarLottery) Qualify(mattermostUsernames, skillName string, level Lev
 		return err
 	}
 
+	before := usersFields(sl.users)
 	for _, user := range sl.users {
 		err = sl.updateUserSkill(user, skillName, level)
 		if err != nil {
 			return err
 		}
 	}
+	sl.auditUsers(AuditUserQualify, sl.users, before)
 
 	logger.Infof("%s added skill %s to %s.",
 		sl.actingUser.Markdown(), MarkdownSkillLevel(skillName, level), sl.users.MarkdownWithSkills())
@@ -103,6 +106,7 @@ func (sl *solarLottery) Disqualify(mattermostUsernames, skillName string) error
 		withActingUserExpanded,
 		withMattermostUsersExpanded(mattermostUsernames),
 		withValidSkillName(skillName),
+		withSelfOrAdmin,
 	)
 	if err != nil {
 		return err
@@ -114,12 +118,14 @@ func (sl *solarLottery) Disqualify(mattermostUsernames, skillName string) error
 		"Skill":               skillName,
 	})
 
+	before := usersFields(sl.users)
 	for _, user := range sl.users {
 		err = sl.updateUserSkill(user, skillName, 0)
 		if err != nil {
 			return err
 		}
 	}
+	sl.auditUsers(AuditUserDisqualify, sl.users, before)
 
 	logger.Infof("%s removed skill %s from %s.",
 		sl.actingUser.Markdown(), skillName, sl.users.MarkdownWithSkills())
@@ -0,0 +1,148 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"sync"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+// cachedUserStore is a read-through cache of the stored users, for the
+// lifetime of one solarLottery, i.e. one command or one autopilot run.
+// It hands out copies, so that a user modified by the caller is only seen
+// by others once it has been stored.
+type cachedUserStore struct {
+	store.UserStore
+
+	lock  sync.Mutex
+	users map[string]*store.User
+}
+
+var _ store.UserStore = (*cachedUserStore)(nil)
+
+func newCachedUserStore(userStore store.UserStore) *cachedUserStore {
+	return &cachedUserStore{
+		UserStore: userStore,
+		users:     map[string]*store.User{},
+	}
+}
+
+func (s *cachedUserStore) LoadUser(mattermostUserID string) (*store.User, error) {
+	s.lock.Lock()
+	cached := s.users[mattermostUserID]
+	s.lock.Unlock()
+	if cached != nil {
+		return cached.Clone(), nil
+	}
+
+	user, err := s.UserStore.LoadUser(mattermostUserID)
+	if err != nil {
+		return nil, err
+	}
+	s.cache(user)
+	return user, nil
+}
+
+func (s *cachedUserStore) StoreUser(user *store.User) error {
+	err := s.UserStore.StoreUser(user)
+	if err != nil {
+		// The stored user may have changed, e.g. on a ConflictError, so the
+		// retry must load it from the store.
+		s.evict(user.MattermostUserID)
+		return err
+	}
+	s.cache(user)
+	return nil
+}
+
+func (s *cachedUserStore) DeleteUser(mattermostUserID string) error {
+	s.evict(mattermostUserID)
+	return s.UserStore.DeleteUser(mattermostUserID)
+}
+
+func (s *cachedUserStore) LoadAllUsers() ([]*store.User, error) {
+	users, err := s.UserStore.LoadAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		s.cache(user)
+	}
+	return users, nil
+}
+
+func (s *cachedUserStore) cache(user *store.User) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.users[user.MattermostUserID] = user.Clone()
+}
+
+func (s *cachedUserStore) evict(mattermostUserID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.users, mattermostUserID)
+}
+
+// cachedPluginAPI caches the Mattermost user profiles for the lifetime of
+// one solarLottery. Profiles are not modified by the plugin, so there is
+// nothing to invalidate.
+type cachedPluginAPI struct {
+	PluginAPI
+
+	lock       sync.Mutex
+	byID       map[string]*model.User
+	byUsername map[string]*model.User
+}
+
+var _ PluginAPI = (*cachedPluginAPI)(nil)
+
+func newCachedPluginAPI(api PluginAPI) *cachedPluginAPI {
+	return &cachedPluginAPI{
+		PluginAPI:  api,
+		byID:       map[string]*model.User{},
+		byUsername: map[string]*model.User{},
+	}
+}
+
+func (api *cachedPluginAPI) GetMattermostUser(mattermostUserID string) (*model.User, error) {
+	api.lock.Lock()
+	cached := api.byID[mattermostUserID]
+	api.lock.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	mattermostUser, err := api.PluginAPI.GetMattermostUser(mattermostUserID)
+	if err != nil {
+		return nil, err
+	}
+	api.cache(mattermostUser)
+	return mattermostUser, nil
+}
+
+func (api *cachedPluginAPI) GetMattermostUserByUsername(mattermostUsername string) (*model.User, error) {
+	api.lock.Lock()
+	cached := api.byUsername[mattermostUsername]
+	api.lock.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	mattermostUser, err := api.PluginAPI.GetMattermostUserByUsername(mattermostUsername)
+	if err != nil {
+		return nil, err
+	}
+	api.cache(mattermostUser)
+	return mattermostUser, nil
+}
+
+func (api *cachedPluginAPI) cache(mattermostUser *model.User) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+	api.byID[mattermostUser.Id] = mattermostUser
+	api.byUsername[mattermostUser.Username] = mattermostUser
+}
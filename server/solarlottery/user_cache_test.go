@@ -0,0 +1,168 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+// countingUserStore counts the users loaded from the underlying store.
+type countingUserStore struct {
+	store.UserStore
+	loads int
+}
+
+func (s *countingUserStore) LoadUser(mattermostUserID string) (*store.User, error) {
+	s.loads++
+	return s.UserStore.LoadUser(mattermostUserID)
+}
+
+// countingPluginAPI is a fake PluginAPI that knows every user, and counts
+// the profiles it is asked for.
+type countingPluginAPI struct {
+	PluginAPI
+	gets int
+}
+
+func (api *countingPluginAPI) GetMattermostUser(mattermostUserID string) (*model.User, error) {
+	api.gets++
+	return &model.User{Id: mattermostUserID, Username: "name-" + mattermostUserID}, nil
+}
+
+func (api *countingPluginAPI) GetMattermostUserByUsername(mattermostUsername string) (*model.User, error) {
+	api.gets++
+	return &model.User{Id: "id-" + mattermostUsername, Username: mattermostUsername}, nil
+}
+
+func TestCachedUserStore(t *testing.T) {
+	backing := store.NewStore(kvstore.NewMemStore(), &bot.NilLogger{})
+	require.NoError(t, backing.StoreUser(store.NewUser("u1")))
+	counting := &countingUserStore{UserStore: backing}
+	cache := newCachedUserStore(counting)
+
+	u1, err := cache.LoadUser("u1")
+	require.NoError(t, err)
+	require.Equal(t, 1, u1.Revision)
+	_, err = cache.LoadUser("u1")
+	require.NoError(t, err)
+	require.Equal(t, 1, counting.loads)
+
+	// changes are not seen until stored
+	u1.SkillLevels["webapp"] = 2
+	loaded, err := cache.LoadUser("u1")
+	require.NoError(t, err)
+	require.Empty(t, loaded.SkillLevels)
+
+	require.NoError(t, cache.StoreUser(u1))
+	loaded, err = cache.LoadUser("u1")
+	require.NoError(t, err)
+	require.Equal(t, 2, loaded.Revision)
+	require.Equal(t, store.IntMap{"webapp": 2}, loaded.SkillLevels)
+	require.Equal(t, 1, counting.loads)
+
+	// modified by another request, the conflict evicts the stale copy
+	other, err := backing.LoadUser("u1")
+	require.NoError(t, err)
+	require.NoError(t, backing.StoreUser(other))
+	err = cache.StoreUser(loaded)
+	require.True(t, store.IsConflict(err))
+	loaded, err = cache.LoadUser("u1")
+	require.NoError(t, err)
+	require.Equal(t, 3, loaded.Revision)
+	require.Equal(t, 2, counting.loads)
+
+	require.NoError(t, cache.DeleteUser("u1"))
+	_, err = cache.LoadUser("u1")
+	require.Equal(t, store.ErrNotFound, err)
+}
+
+func TestCachedPluginAPI(t *testing.T) {
+	counting := &countingPluginAPI{}
+	api := newCachedPluginAPI(counting)
+
+	byName, err := api.GetMattermostUserByUsername("test")
+	require.NoError(t, err)
+	byID, err := api.GetMattermostUser("id-test")
+	require.NoError(t, err)
+	require.Equal(t, byName, byID)
+	_, err = api.GetMattermostUserByUsername("test")
+	require.NoError(t, err)
+	require.Equal(t, 1, counting.gets)
+}
+
+// BenchmarkExpandRotation expands a 200-member rotation 10 times per request,
+// as the filters and messages of a command do, with and without the request
+// cache.
+func BenchmarkExpandRotation(b *testing.B) {
+	const (
+		numMembers = 200
+		numExpands = 10
+	)
+
+	backing := store.NewStore(kvstore.NewMemStore(), &bot.NilLogger{})
+	storedRotation := store.NewRotation("test")
+	storedRotation.Start = "2020-01-06"
+	for i := 0; i < numMembers; i++ {
+		user := store.NewUser(fmt.Sprintf("user%v", i))
+		user.SkillLevels = store.IntMap{"webapp": 2, "server": 1}
+		user.LastServed = store.IntMap{"test": i % 10}
+		err := backing.StoreUser(user)
+		if err != nil {
+			b.Fatal(err)
+		}
+		storedRotation.MattermostUserIDs[user.MattermostUserID] = user.MattermostUserID
+	}
+
+	for _, tc := range []struct {
+		name   string
+		cached bool
+	}{
+		{name: "uncached"},
+		{name: "cached", cached: true},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			userStore := &countingUserStore{UserStore: backing}
+			api := &countingPluginAPI{}
+			apiConfig := Config{
+				Config: &config.Config{},
+				Dependencies: &Dependencies{
+					Logger:    &bot.NilLogger{},
+					PluginAPI: api,
+					UserStore: userStore,
+				},
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				sl := &solarLottery{Logger: &bot.NilLogger{}, Config: apiConfig}
+				if tc.cached {
+					sl = New(apiConfig, "").(*solarLottery)
+				}
+				for n := 0; n < numExpands; n++ {
+					rotation := &Rotation{Rotation: storedRotation.Clone(false)}
+					err := sl.ExpandRotation(rotation)
+					if err != nil {
+						b.Fatal(errors.WithMessage(err, "failed to expand rotation"))
+					}
+					if len(rotation.Users) != numMembers {
+						b.Fatalf("expanded %v users", len(rotation.Users))
+					}
+				}
+			}
+			b.ReportMetric(float64(userStore.loads)/float64(b.N), "loads/op")
+			b.ReportMetric(float64(api.gets)/float64(b.N), "profiles/op")
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/ical"
+)
+
+// ImportHorizon is how far ahead of today the events of an imported calendar
+// are added, recurring events are expanded up to it.
+const ImportHorizon = 366 * DayDuration
+
+// LoadUploadedFile returns the content of a file uploaded by the acting user,
+// or by anyone for the plugin admins.
+func (sl *solarLottery) LoadUploadedFile(fileID string) ([]byte, error) {
+	err := sl.Filter(
+		withActingUserExpanded,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	info, data, err := sl.PluginAPI.GetFile(fileID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to load file %s", fileID)
+	}
+	if info.CreatorId != sl.actingMattermostUserID {
+		err = withActingUserAdmin(sl)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "file %s was not uploaded by %s", fileID, sl.actingUser.Markdown())
+		}
+	}
+	return data, nil
+}
+
+// ImportUnavailable adds the events of an iCalendar file, expanding the
+// recurring ones, to the users' personal events, from the start of today
+// through ImportHorizon. The days already covered by one of a user's personal
+// events are skipped. With dryRun nothing is stored. It returns the events
+// that were, or would be added, by user ID, and the calendar events that were
+// skipped because their recurrence is not supported.
+func (sl *solarLottery) ImportUnavailable(mattermostUsernames string, r io.Reader, now time.Time, dryRun bool) (UserMap, map[string][]Event, []*ical.Skipped, error) {
+	err := sl.Filter(
+		withActingUserExpanded,
+		withMattermostUsersExpanded(mattermostUsernames),
+		withSelfOrAdmin,
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	logger := sl.Logger.Timed().With(bot.LogContext{
+		"Location":            "sl.ImportUnavailable",
+		"ActingUsername":      sl.actingUser.MattermostUsername(),
+		"MattermostUsernames": mattermostUsernames,
+		"DryRun":              dryRun,
+	})
+
+	calendarEvents, skipped, err := ical.Parse(r)
+	if err != nil {
+		return nil, nil, nil, errors.WithMessage(err, "failed to parse calendar")
+	}
+	y, m, d := now.Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	var events []Event
+	for _, calendarEvent := range ical.Expand(calendarEvents, from, from.Add(ImportHorizon)) {
+		events = append(events, calendarPersonalEvent(calendarEvent))
+	}
+
+	added := map[string][]Event{}
+	addEvents := func(user *User) error {
+		added[user.MattermostUserID] = nil
+		for _, event := range events {
+			if user.hasPersonalEvent(event) {
+				continue
+			}
+			user.AddEvent(event)
+			added[user.MattermostUserID] = append(added[user.MattermostUserID], event)
+		}
+		return nil
+	}
+
+	if dryRun {
+		for _, user := range sl.users {
+			_ = addEvents(user.Clone())
+		}
+		logger.Debugf("%s previewed the import of %v calendar events for %s, %v skipped.",
+			sl.actingUser.Markdown(), len(events), sl.users.Markdown(), len(skipped))
+		return sl.users, added, skipped, nil
+	}
+
+	before := usersFields(sl.users)
+	for _, user := range sl.users {
+		_, err = sl.storeUserWelcomeNew(user, addEvents)
+		if err != nil {
+			return nil, nil, nil, errors.WithMessagef(err, "failed to update user %s", user.Markdown())
+		}
+	}
+	sl.auditUsers(AuditUserImport, sl.users, before)
+
+	logger.Infof("%s imported %v calendar events for %s, %v skipped.",
+		sl.actingUser.Markdown(), len(events), sl.users.Markdown(), len(skipped))
+	return sl.users, added, skipped, nil
+}
+
+// calendarPersonalEvent returns the personal event for the days a calendar
+// event covers, in the event's own time zone.
+func calendarPersonalEvent(calendarEvent *ical.Event) Event {
+	date := func(t time.Time) time.Time {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	start := date(calendarEvent.Start)
+	end := date(calendarEvent.End)
+	if !calendarEvent.AllDay {
+		y, m, d := calendarEvent.End.Date()
+		if calendarEvent.End.After(time.Date(y, m, d, 0, 0, 0, 0, calendarEvent.End.Location())) {
+			// ends during the day, which is then unavailable too
+			end = end.Add(DayDuration)
+		}
+	}
+	if !end.After(start) {
+		end = start.Add(DayDuration)
+	}
+	return NewPersonalEvent(start, end)
+}
+
+// hasPersonalEvent returns true if one of the user's personal events covers
+// the event's days.
+func (user *User) hasPersonalEvent(event Event) bool {
+	for _, existing := range user.Events {
+		if existing.Type != store.EventTypePersonal {
+			continue
+		}
+		start, end, _, err := ParseEventTimes(existing)
+		if err == nil && !start.After(event.StartTime) && !end.Before(event.EndTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkdownImportedEvents lists the events imported for each user.
+func MarkdownImportedEvents(users UserMap, added map[string][]Event) string {
+	out := ""
+	for id, user := range users {
+		events := added[id]
+		if len(events) == 0 {
+			out += fmt.Sprintf("- %s: nothing new\n", user.Markdown())
+			continue
+		}
+		out += fmt.Sprintf("- %s: %v events\n", user.Markdown(), len(events))
+		for _, event := range events {
+			out += fmt.Sprintf("  - %s\n", event.Markdown())
+		}
+	}
+	return out
+}
+
+// MarkdownSkippedCalendarEvents lists the calendar events that were not
+// imported.
+func MarkdownSkippedCalendarEvents(skipped []*ical.Skipped) string {
+	out := ""
+	for _, event := range skipped {
+		summary := event.Summary
+		if summary == "" {
+			summary = event.UID
+		}
+		out += fmt.Sprintf("- %s, from %s: %s\n", summary, event.Start.Format(DateFormat), event.Reason)
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store/mock_store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+)
+
+const testImportCalendar = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:past
+DTSTART;VALUE=DATE:20191220
+DTEND;VALUE=DATE:20191224
+END:VEVENT
+BEGIN:VEVENT
+UID:pto
+DTSTART;VALUE=DATE:20200203
+DTEND;VALUE=DATE:20200206
+END:VEVENT
+BEGIN:VEVENT
+UID:fridays
+DTSTART:20200103T220000Z
+DTEND:20200104T020000Z
+RRULE:FREQ=WEEKLY;COUNT=2
+END:VEVENT
+BEGIN:VEVENT
+UID:first-monday
+DTSTART;VALUE=DATE:20200106
+RRULE:FREQ=MONTHLY;BYDAY=1MO
+SUMMARY:Offsite
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestImportUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	newSL := func(userStore store.UserStore) (*solarLottery, *User) {
+		user := &User{
+			User:           store.NewUser("user"),
+			MattermostUser: &model.User{Id: "user", Username: "user-name"},
+		}
+		user.PluginVersion = "test"
+		user.Events = []store.Event{
+			{Type: store.EventTypePersonal, Start: "2020-02-01", End: "2020-02-08"},
+		}
+		auditStore := mock_store.NewMockAuditStore(ctrl)
+		auditStore.EXPECT().AppendAuditEntry(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+		return &solarLottery{
+			Logger: &bot.NilLogger{},
+			Config: Config{
+				Config: &config.Config{PluginVersion: "test"},
+				Dependencies: &Dependencies{
+					AuditStore: auditStore,
+					UserStore:  userStore,
+					Logger:     &bot.NilLogger{},
+				},
+			},
+			actingMattermostUserID: "user",
+			actingUser:             user,
+		}, user
+	}
+	expected := []Event{
+		NewPersonalEvent(time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)),
+		NewPersonalEvent(time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 12, 0, 0, 0, 0, time.UTC)),
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		sl, user := newSL(mock_store.NewMockUserStore(ctrl))
+		_, added, skipped, err := sl.ImportUnavailable("", strings.NewReader(testImportCalendar), now, true)
+		require.NoError(t, err)
+		require.Equal(t, expected, added["user"])
+		require.Len(t, user.Events, 1)
+		require.Equal(t, "- Offsite, from 2020-01-06: unsupported recurrence day \"1MO\"\n",
+			MarkdownSkippedCalendarEvents(skipped))
+	})
+
+	t.Run("import", func(t *testing.T) {
+		userStore := mock_store.NewMockUserStore(ctrl)
+		userStore.EXPECT().StoreUser(gomock.Any()).Times(1).Return(nil)
+		sl, user := newSL(userStore)
+		_, added, skipped, err := sl.ImportUnavailable("", strings.NewReader(testImportCalendar), now, false)
+		require.NoError(t, err)
+		require.Len(t, skipped, 1)
+		require.Equal(t, expected, added["user"])
+		require.Equal(t, []string{"2020-01-03", "2020-01-10", "2020-02-01"}, []string{
+			user.Events[0].Start, user.Events[1].Start, user.Events[2].Start})
+
+		// importing again adds nothing
+		userStore.EXPECT().StoreUser(gomock.Any()).Times(1).Return(nil)
+		_, added, _, err = sl.ImportUnavailable("", strings.NewReader(testImportCalendar), now, false)
+		require.NoError(t, err)
+		require.Empty(t, added["user"])
+		require.Len(t, user.Events, 3)
+	})
+}
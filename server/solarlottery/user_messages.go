@@ -5,6 +5,7 @@ package solarlottery
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/config"
 )
@@ -14,6 +15,18 @@ func (sl *solarLottery) dmUser(user *User, message string) {
 	sl.Debugf("DM bot to %s:\n%s", user.Markdown(), message)
 }
 
+// dmAdmins sends the message to the plugin admins, see bot.BotConfig.
+func (sl *solarLottery) dmAdmins(message string) {
+	for _, id := range strings.Split(sl.Config.AdminUserIDs, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		sl.Poster.DM(id, "%s", message)
+	}
+	sl.Debugf("DM bot to admins:\n%s", message)
+}
+
 func (sl *solarLottery) messageWelcomeNewUser(user *User) {
 	sl.ExpandUser(user)
 
@@ -74,42 +87,80 @@ func (sl *solarLottery) messageShiftOpened(rotation *Rotation, shift *Shift) {
 	sl.ExpandRotation(rotation)
 
 	for _, user := range rotation.Users {
-		sl.dmUser(user,
+		sl.dmUserWithActions(user,
 			fmt.Sprintf("%s opened %s.\n"+
 				"Use `/%s shift join -r %s -s %v` if you would like to participate.\n",
 				sl.actingUser.Markdown(),
 				shift.Markdown(),
 				config.CommandTrigger,
 				rotation.Name,
-				shift.ShiftNumber))
+				shift.ShiftNumber),
+			sl.shiftAction("Join shift", ShiftActionJoin, rotation, shift))
 	}
 }
 
+// messageShiftStarted notifies the users of every tier, the first tier, i.e.
+// the primary role, first. The users in the later tiers are asked to step in
+// on escalation, see messageShiftBackupsCalled.
 func (sl *solarLottery) messageShiftStarted(rotation *Rotation, shift *Shift) {
 	sl.ExpandRotation(rotation)
 
-	for _, user := range rotation.ShiftUsers(shift) {
-		sl.dmUser(user,
-			fmt.Sprintf("###### Your %s started!\n"+
-				"%s started %s.\n\nTODO runbook URL/channel",
-				shift.Markdown(),
-				sl.actingUser.Markdown(),
-				shift.Markdown()))
+	for _, tier := range rotation.ShiftUsersByTier(shift) {
+		for _, user := range tier {
+			sl.dmUser(user,
+				fmt.Sprintf("###### Your %s started!\n"+
+					"%s started %s%s.\n\nTODO runbook URL/channel",
+					shift.Markdown(),
+					sl.actingUser.Markdown(),
+					shift.Markdown(),
+					markdownShiftRole(shift, user)))
+		}
 	}
 }
 
 func (sl *solarLottery) messageShiftWillStart(rotation *Rotation, shift *Shift) {
 	sl.ExpandRotation(rotation)
 
-	for _, user := range rotation.ShiftUsers(shift) {
+	for _, tier := range rotation.ShiftUsersByTier(shift) {
+		for _, user := range tier {
+			sl.dmUserWithActions(user,
+				fmt.Sprintf("Your %s will start on %s%s\n\nTODO runbook URL/channel",
+					shift.Markdown(),
+					shift.Start,
+					markdownShiftRole(shift, user)),
+				sl.shiftActions(shift, rotation)...)
+		}
+	}
+}
 
-		sl.dmUser(user,
-			fmt.Sprintf("Your %s will start on %s\n\nTODO runbook URL/channel",
-				shift.Markdown(),
-				shift.Start))
+// messageShiftBackupsCalled notifies the users in the later tiers of the
+// shift that the users of the first tier have not acknowledged it.
+func (sl *solarLottery) messageShiftBackupsCalled(rotation *Rotation, shift *Shift, unacknowledged UserMap) {
+	sl.ExpandRotation(rotation)
+	sl.ExpandUserMap(unacknowledged)
+
+	for _, tier := range rotation.ShiftUsersByTier(shift)[1:] {
+		for _, user := range tier {
+			sl.dmUserWithActions(user,
+				fmt.Sprintf("###### %s starts on %s, not acknowledged by %s\n"+
+					"Please be ready to step in%s.",
+					shift.Markdown(),
+					shift.Start,
+					unacknowledged.Markdown(),
+					markdownShiftRole(shift, user)),
+				sl.shiftActions(shift, rotation)...)
+		}
 	}
 }
 
+func markdownShiftRole(shift *Shift, user *User) string {
+	role := shift.Roles[user.MattermostUserID]
+	if role == "" {
+		return ""
+	}
+	return fmt.Sprintf(", your role is **%s**", role)
+}
+
 func (sl *solarLottery) messageShiftFinished(rotation *Rotation, shift *Shift) {
 	sl.ExpandRotation(rotation)
 
@@ -151,10 +202,11 @@ func (sl *solarLottery) messageShiftJoined(joined UserMap, rotation *Rotation, s
 	}
 
 	for _, user := range joined {
-		sl.dmUser(user,
+		sl.dmUserWithActions(user,
 			fmt.Sprintf("%s joined you into %s",
 				sl.actingUser.Markdown(),
-				shift.Markdown()))
+				shift.Markdown()),
+			sl.shiftActions(shift, rotation)...)
 	}
 }
 
@@ -180,3 +232,27 @@ func (sl *solarLottery) messageShiftLeft(deleted UserMap, rotation *Rotation, sh
 				shift.Markdown()))
 	}
 }
+
+func (sl *solarLottery) messageShiftAckReminder(rotation *Rotation, shift *Shift, users UserMap) {
+	sl.ExpandRotation(rotation)
+
+	for _, user := range users {
+		sl.dmUserWithActions(user,
+			fmt.Sprintf("Please confirm you are in %s, starting on %s%s.",
+				shift.Markdown(),
+				shift.Start,
+				markdownShiftRole(shift, user)),
+			sl.shiftActions(shift, rotation)...)
+	}
+}
+
+func (sl *solarLottery) messageShiftEscalated(rotation *Rotation, shift *Shift, users UserMap) {
+	sl.ExpandRotation(rotation)
+	sl.ExpandUserMap(users)
+
+	sl.dmAdmins(fmt.Sprintf("###### %s starts on %s, not acknowledged by %s\n%s",
+		shift.Markdown(),
+		shift.Start,
+		users.Markdown(),
+		shift.MarkdownBullets(rotation)))
+}
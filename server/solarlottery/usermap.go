@@ -62,13 +62,17 @@ func (m UserMap) IDMap() store.IDMap {
 
 func (sl *solarLottery) addEventToUsers(users UserMap, event Event, persist bool) error {
 	for _, user := range users {
-		user.AddEvent(event)
+		if !persist {
+			user.AddEvent(event)
+			continue
+		}
 
-		if persist {
-			_, err := sl.storeUserWelcomeNew(user)
-			if err != nil {
-				return errors.WithMessagef(err, "failed to update user %s", user.Markdown())
-			}
+		_, err := sl.storeUserWelcomeNew(user, func(user *User) error {
+			user.AddEvent(event)
+			return nil
+		})
+		if err != nil {
+			return errors.WithMessagef(err, "failed to update user %s", user.Markdown())
 		}
 	}
 	return nil
@@ -0,0 +1,155 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+// Weighting is the name of the strategy the solar-lottery autofiller uses to
+// weigh the users' chances of being picked for a shift.
+type Weighting struct {
+	value string
+}
+
+var _ pflag.Value = (*Weighting)(nil)
+
+const (
+	// WeightingExponential (default) doubles the weight for each shift since the user last served.
+	WeightingExponential = "exponential"
+	// WeightingLinear grows the weight by 1 for each shift since the user last served.
+	WeightingLinear = "linear"
+	// WeightingFlat gives all available users the same chance.
+	WeightingFlat = "flat"
+	// WeightingCapped is exponential, but stops growing after a few shifts.
+	WeightingCapped = "capped"
+	// WeightingFairShare favors the users who have served the fewest shifts in the rotation.
+	WeightingFairShare = "fair-share"
+)
+
+func (w *Weighting) String() string {
+	return w.value
+}
+
+func (w *Weighting) Type() string {
+	return "weighting"
+}
+
+func (w *Weighting) Set(in string) error {
+	switch strings.ToLower(in) {
+	case WeightingExponential, "exp":
+		w.value = WeightingExponential
+	case WeightingLinear:
+		w.value = WeightingLinear
+	case WeightingFlat, "uniform":
+		w.value = WeightingFlat
+	case WeightingCapped:
+		w.value = WeightingCapped
+	case WeightingFairShare, "fairshare", "fair":
+		w.value = WeightingFairShare
+	default:
+		return errors.Errorf("weighting must be `%s`, `%s`, `%s`, `%s` or `%s`",
+			WeightingExponential, WeightingLinear, WeightingFlat, WeightingCapped, WeightingFairShare)
+	}
+	return nil
+}
+
+// NotYetWeight is used for users who are not to serve yet. It is a non-0 but
+// very low number, to prevent the user from serving other than if all have 0
+// weights.
+const NotYetWeight = 1e-12
+
+// cappedWeightShifts is the number of shifts after which the capped weight
+// stops growing.
+const cappedWeightShifts = 8
+
+// WeightFunc returns the weight of the user's chance to be picked for the
+// shift shiftNumber of the rotation.
+type WeightFunc func(user *User, rotationID string, shiftNumber int) float64
+
+var weightFuncs = map[string]WeightFunc{
+	"":                   userWeightExponential,
+	WeightingExponential: userWeightExponential,
+	WeightingLinear:      userWeightLinear,
+	WeightingFlat:        userWeightFlat,
+	WeightingCapped:      userWeightCapped,
+	WeightingFairShare:   userWeightFairShare,
+}
+
+// GetWeightFunc returns the WeightFunc for the rotation's Weighting, empty
+// being the default.
+func GetWeightFunc(weighting string) (WeightFunc, error) {
+	f := weightFuncs[weighting]
+	if f == nil {
+		return nil, errors.Errorf("unknown weighting %s", weighting)
+	}
+	return f, nil
+}
+
+// sinceServed returns the number of shifts since the user last served, or -1
+// if the user is not to serve yet.
+func sinceServed(user *User, rotationID string, shiftNumber int) int {
+	lastServed := user.LastServed[rotationID]
+	if lastServed > shiftNumber {
+		return -1
+	}
+	return shiftNumber - lastServed
+}
+
+func userWeightExponential(user *User, rotationID string, shiftNumber int) float64 {
+	since := sinceServed(user, rotationID, shiftNumber)
+	if since < 0 {
+		return NotYetWeight
+	}
+	return math.Pow(2.0, float64(since))
+}
+
+func userWeightLinear(user *User, rotationID string, shiftNumber int) float64 {
+	since := sinceServed(user, rotationID, shiftNumber)
+	if since < 0 {
+		return NotYetWeight
+	}
+	return float64(since + 1)
+}
+
+func userWeightFlat(user *User, rotationID string, shiftNumber int) float64 {
+	if sinceServed(user, rotationID, shiftNumber) < 0 {
+		return NotYetWeight
+	}
+	return 1
+}
+
+func userWeightCapped(user *User, rotationID string, shiftNumber int) float64 {
+	since := sinceServed(user, rotationID, shiftNumber)
+	if since < 0 {
+		return NotYetWeight
+	}
+	if since > cappedWeightShifts {
+		since = cappedWeightShifts
+	}
+	return math.Pow(2.0, float64(since))
+}
+
+// userWeightFairShare halves the weight for each shift the user has served in
+// the rotation before this one.
+func userWeightFairShare(user *User, rotationID string, shiftNumber int) float64 {
+	if sinceServed(user, rotationID, shiftNumber) < 0 {
+		return NotYetWeight
+	}
+	served := 0
+	for _, event := range user.Events {
+		if event.Type == store.EventTypeShift &&
+			event.RotationID == rotationID &&
+			event.ShiftNumber < shiftNumber {
+			served++
+		}
+	}
+	return math.Pow(2.0, float64(-served))
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package solarlottery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+func TestWeightings(t *testing.T) {
+	const rotationID = "test-rotation-ID"
+	user := func() *User {
+		return &User{User: store.NewUser("test")}
+	}
+	servedTwice := user().WithLastServed(rotationID, 2)
+	servedTwice.Events = []store.Event{
+		{Type: store.EventTypeShift, RotationID: rotationID, ShiftNumber: 1},
+		{Type: store.EventTypeShift, RotationID: rotationID, ShiftNumber: 2},
+		{Type: store.EventTypeShift, RotationID: "other", ShiftNumber: 3},
+		{Type: store.EventTypePersonal},
+	}
+
+	for _, tc := range []struct {
+		weighting      string
+		user           *User
+		shiftNumber    int
+		expectedWeight float64
+	}{
+		{"", user().WithLastServed(rotationID, 1), 5, 16},
+		{WeightingExponential, user().WithLastServed(rotationID, 1), 5, 16},
+		{WeightingExponential, user().WithLastServed(rotationID, 6), 5, NotYetWeight},
+		{WeightingLinear, user().WithLastServed(rotationID, 1), 5, 5},
+		{WeightingLinear, user().WithLastServed(rotationID, 5), 5, 1},
+		{WeightingLinear, user().WithLastServed(rotationID, 6), 5, NotYetWeight},
+		{WeightingFlat, user().WithLastServed(rotationID, 1), 5, 1},
+		{WeightingFlat, user().WithLastServed(rotationID, 6), 5, NotYetWeight},
+		{WeightingCapped, user().WithLastServed(rotationID, 1), 5, 16},
+		{WeightingCapped, user().WithLastServed(rotationID, 1), 100, 256},
+		{WeightingFairShare, user(), 5, 1},
+		{WeightingFairShare, servedTwice, 5, 0.25},
+		{WeightingFairShare, servedTwice, 2, 0.5},
+	} {
+		t.Run(tc.weighting, func(t *testing.T) {
+			weightF, err := GetWeightFunc(tc.weighting)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedWeight, weightF(tc.user, rotationID, tc.shiftNumber))
+		})
+	}
+}
+
+func TestUnknownWeighting(t *testing.T) {
+	_, err := GetWeightFunc("bogus")
+	require.Error(t, err)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+// AuditStore keeps append-only logs of audit entries. Each log is stored as a
+// head record, and pages of up to AuditPageSize entries, oldest first.
+type AuditStore interface {
+	AppendAuditEntry(logKey string, entry *AuditEntry) error
+	LoadAuditLog(logKey string) (*AuditLog, error)
+	LoadAuditPage(logKey string, page int) (*AuditPage, error)
+}
+
+const AuditPageSize = 50
+
+// auditAppendAttempts is how many times appending is tried when the log is
+// being appended to concurrently.
+const auditAppendAttempts = 5
+
+// AuditEntry records a state-changing operation.
+type AuditEntry struct {
+	Time                   time.Time
+	ActingMattermostUserID string
+	ActingUsername         string
+	Action                 string
+
+	// RotationID and ShiftNumber identify what was changed, ShiftNumber is -1
+	// when not applicable.
+	RotationID  string `json:",omitempty"`
+	ShiftNumber int
+
+	// MattermostUsers are the users the operation was applied to, by ID, with
+	// their usernames.
+	MattermostUsers IDMap `json:",omitempty"`
+
+	Changes []AuditChange `json:",omitempty"`
+}
+
+// AuditChange is a field that was added, updated or deleted by the operation.
+type AuditChange struct {
+	Field  string
+	Before []string `json:",omitempty"`
+	After  []string `json:",omitempty"`
+}
+
+// AuditLog is the head record of a log, Pages is the number of its pages. The
+// last page is the one being appended to.
+type AuditLog struct {
+	Revision int
+	Pages    int
+}
+
+type AuditPage struct {
+	Revision int
+	Entries  []*AuditEntry
+}
+
+// RotationAuditLogKey is the key of the log of the changes to a rotation and
+// its shifts.
+func RotationAuditLogKey(rotationID string) string {
+	return "rotation-" + rotationID
+}
+
+// UserAuditLogKey is the key of the log of the changes made by, or applied to
+// a user.
+func UserAuditLogKey(mattermostUserID string) string {
+	return "user-" + mattermostUserID
+}
+
+func auditPageKey(logKey string, page int) string {
+	return fmt.Sprintf("%s-%v", logKey, page)
+}
+
+func (s *pluginStore) AppendAuditEntry(logKey string, entry *AuditEntry) error {
+	var err error
+	for i := 0; i < auditAppendAttempts; i++ {
+		err = s.appendAuditEntry(logKey, entry)
+		if !IsConflict(err) {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	s.Logger.With(bot.LogContext{
+		"AuditEntry": entry,
+	}).Debugf("store: Appended audit entry to %s", logKey)
+	return nil
+}
+
+func (s *pluginStore) appendAuditEntry(logKey string, entry *AuditEntry) error {
+	log, err := s.LoadAuditLog(logKey)
+	if err != nil {
+		return err
+	}
+	page := &AuditPage{}
+	if log.Pages > 0 {
+		page, err = s.LoadAuditPage(logKey, log.Pages-1)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		if err == ErrNotFound {
+			page = &AuditPage{}
+		}
+	}
+
+	if log.Pages == 0 || len(page.Entries) >= AuditPageSize {
+		log.Pages++
+		err = storeRevisionJSON(s.auditKV, "audit log", logKey, &log.Revision, log)
+		if err != nil {
+			return err
+		}
+		page = &AuditPage{}
+	}
+
+	page.Entries = append(page.Entries, entry)
+	return storeRevisionJSON(s.auditKV, "audit log page", auditPageKey(logKey, log.Pages-1), &page.Revision, page)
+}
+
+// LoadAuditLog returns an empty log if there is none yet.
+func (s *pluginStore) LoadAuditLog(logKey string) (*AuditLog, error) {
+	log := &AuditLog{}
+	err := kvstore.LoadJSON(s.auditKV, logKey, log)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	return log, nil
+}
+
+func (s *pluginStore) LoadAuditPage(logKey string, page int) (*AuditPage, error) {
+	auditPage := &AuditPage{}
+	err := kvstore.LoadJSON(s.auditKV, auditPageKey(logKey, page), auditPage)
+	if err != nil {
+		return nil, err
+	}
+	return auditPage, nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+// CalendarStore keeps the users' secret calendar tokens, that authenticate the
+// calendar feed requests made by calendar apps.
+type CalendarStore interface {
+	LoadCalendarToken(mattermostUserID string) (string, error)
+	LoadCalendarTokenUserID(token string) (string, error)
+	StoreCalendarToken(mattermostUserID, token string) error
+	DeleteCalendarToken(mattermostUserID string) error
+}
+
+func calendarUserKey(mattermostUserID string) string {
+	return "user-" + mattermostUserID
+}
+
+func calendarTokenKey(token string) string {
+	return "token-" + token
+}
+
+func (s *pluginStore) LoadCalendarToken(mattermostUserID string) (string, error) {
+	data, err := s.calendarKV.Load(calendarUserKey(mattermostUserID))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (s *pluginStore) LoadCalendarTokenUserID(token string) (string, error) {
+	data, err := s.calendarKV.Load(calendarTokenKey(token))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// StoreCalendarToken replaces the user's token, the previous one no longer
+// works.
+func (s *pluginStore) StoreCalendarToken(mattermostUserID, token string) error {
+	err := s.DeleteCalendarToken(mattermostUserID)
+	if err != nil {
+		return err
+	}
+	err = s.calendarKV.Store(calendarTokenKey(token), []byte(mattermostUserID))
+	if err != nil {
+		return err
+	}
+	err = s.calendarKV.Store(calendarUserKey(mattermostUserID), []byte(token))
+	if err != nil {
+		return err
+	}
+	s.Logger.Debugf("store: Stored calendar token for %s", mattermostUserID)
+	return nil
+}
+
+func (s *pluginStore) DeleteCalendarToken(mattermostUserID string) error {
+	token, err := s.LoadCalendarToken(mattermostUserID)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = s.calendarKV.Delete(calendarTokenKey(token))
+	if err != nil {
+		return err
+	}
+	err = s.calendarKV.Delete(calendarUserKey(mattermostUserID))
+	if err != nil {
+		return err
+	}
+	s.Logger.Debugf("store: Deleted calendar token for %s", mattermostUserID)
+	return nil
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store_test
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store/storetest"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore/kvstoretest"
+)
+
+func TestMemStore(t *testing.T) {
+	storetest.TestStore(t, func() store.Store {
+		return store.NewStore(kvstore.NewMemStore(), &bot.NilLogger{})
+	})
+}
+
+func TestPluginStore(t *testing.T) {
+	storetest.TestStore(t, func() store.Store {
+		return store.NewPluginStore(kvstoretest.NewPluginAPI(), &bot.NilLogger{})
+	})
+}
@@ -0,0 +1,312 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+// SchemaVersionKey stores the SchemaVersion, i.e. which of the Migrations
+// have been applied to the stored data.
+const SchemaVersionKey = "schema_version"
+
+// maxMigrateAttempts is how many times a record is re-loaded and migrated
+// again when it is concurrently modified.
+const maxMigrateAttempts = 5
+
+type MigrationStore interface {
+	LoadSchemaVersion() (*SchemaVersion, error)
+	Migrate(pluginVersion string) ([]*Migration, error)
+}
+
+type SchemaVersion struct {
+	// Migration is the number of the last migration applied to all records,
+	// and PluginVersion the version of the plugin that applied it.
+	Migration     int
+	PluginVersion string
+}
+
+// Migration upgrades the records stored under Prefixes, written by older
+// versions of the plugin. Migrate is given a record as decoded JSON (with
+// json.Number numbers), changes it in place, and returns true if it did.
+// A migration that needs more than one record at a time sets Run instead,
+// which is given the keys of all the records stored under Prefixes, and
+// returns the number of records it has changed.
+//
+// Migrations must be idempotent: if the plugin is stopped while migrating,
+// the migration is applied again to all records on the next activation,
+// including those it has already upgraded.
+type Migration struct {
+	Number      int
+	Description string
+	Prefixes    []string
+	Migrate     func(record map[string]interface{}) bool
+	Run         func(kv kvstore.KVStore, keys []string, logger bot.Logger) (int, error)
+}
+
+// Migrations is the registry of the data migrations, in order. Numbers start
+// at 1 and must be contiguous; never renumber or remove a migration that has
+// been released, add a new one instead.
+var Migrations = []*Migration{
+	{
+		Number:      1,
+		Description: "Initialize the user skill levels, last served shifts and events stored as null",
+		Prefixes:    []string{UserKeyPrefix},
+		Migrate: func(record map[string]interface{}) bool {
+			changed := false
+			for _, name := range []string{"SkillLevels", "LastServed"} {
+				if record[name] == nil {
+					record[name] = map[string]interface{}{}
+					changed = true
+				}
+			}
+			if record["Events"] == nil {
+				record["Events"] = []interface{}{}
+				changed = true
+			}
+			return changed
+		},
+	},
+	{
+		Number:      2,
+		Description: "Set the type of rotations added without one to solar-lottery",
+		Prefixes:    []string{RotationKeyPrefix},
+		Migrate: func(record map[string]interface{}) bool {
+			if t, _ := record["Type"].(string); t != "" {
+				return false
+			}
+			// the default autofiller, see autofill/solarlottery.Type
+			record["Type"] = "solar-lottery"
+			return true
+		},
+	},
+	{
+		Number:      3,
+		Description: "Index the shifts stored before the shift index was added",
+		Prefixes:    []string{RotationKeyPrefix, ShiftKeyPrefix},
+		Run:         indexShifts,
+	},
+}
+
+// maxIndexedShiftNumber is how far the shift numbers of each rotation are
+// looked up by matchShiftNumbers.
+const maxIndexedShiftNumber = 100000
+
+// matchShiftNumbers returns the numbers of the rotation's shifts whose keys are
+// in shiftKeys, in order, and removes them from shiftKeys. The shift keys are
+// hashed, so they are matched against the keys of the rotation's shifts, by
+// number, until all are found.
+func matchShiftNumbers(rotationID string, shiftKeys map[string]bool) []int {
+	found := []int{}
+	for n := 0; n < maxIndexedShiftNumber && len(shiftKeys) > 0; n++ {
+		shiftKey := kvstore.HashKey(ShiftKeyPrefix, fmt.Sprintf("%v-%v", rotationID, n))
+		if shiftKeys[shiftKey] {
+			found = append(found, n)
+			delete(shiftKeys, shiftKey)
+		}
+	}
+	return found
+}
+
+// indexShifts adds the stored shifts of all rotations, archived included, to
+// the rotations' shift indexes.
+func indexShifts(kv kvstore.KVStore, keys []string, logger bot.Logger) (int, error) {
+	shiftKeys := map[string]bool{}
+	var rotationKeys []string
+	for _, key := range keys {
+		switch {
+		case strings.HasPrefix(key, ShiftKeyPrefix):
+			shiftKeys[key] = true
+		case strings.HasPrefix(key, RotationKeyPrefix):
+			rotationKeys = append(rotationKeys, key)
+		}
+	}
+	if len(shiftKeys) == 0 {
+		return 0, nil
+	}
+
+	s := NewStore(kv, logger)
+	changed := 0
+	for _, key := range rotationKeys {
+		rotation := &Rotation{}
+		err := kvstore.LoadJSON(kv, key, rotation)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return changed, err
+		}
+
+		found := matchShiftNumbers(rotation.RotationID, shiftKeys)
+		if len(found) == 0 {
+			continue
+		}
+
+		indexed, err := s.ListShiftNumbers(rotation.RotationID)
+		if err != nil {
+			return changed, err
+		}
+		err = s.StoreShiftNumbers(rotation.RotationID, append(indexed, found...))
+		if err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	if len(shiftKeys) > 0 {
+		logger.Warnf("store: %v stored shifts do not belong to a known rotation, not indexed", len(shiftKeys))
+	}
+	return changed, nil
+}
+
+func (s *pluginStore) LoadSchemaVersion() (*SchemaVersion, error) {
+	schema := &SchemaVersion{}
+	err := kvstore.LoadJSON(s.basicKV, SchemaVersionKey, schema)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Migrate applies the Migrations that have not yet been applied to the stored
+// data, and returns them. The records it changes are stamped with
+// pluginVersion. It is to be called on activation, by one server at a time.
+func (s *pluginStore) Migrate(pluginVersion string) ([]*Migration, error) {
+	return migrate(s.basicKV, Migrations, pluginVersion, s.Logger)
+}
+
+func migrate(kv kvstore.KVStore, migrations []*Migration, pluginVersion string, logger bot.Logger) ([]*Migration, error) {
+	schema := &SchemaVersion{}
+	err := kvstore.LoadJSON(kv, SchemaVersionKey, schema)
+	if err != nil && err != ErrNotFound {
+		return nil, errors.WithMessage(err, "failed to load schema version")
+	}
+	if schema.Migration > len(migrations) {
+		logger.Warnf("store: data has been migrated by plugin version %s to schema %v, this version only knows %v migrations",
+			schema.PluginVersion, schema.Migration, len(migrations))
+		return nil, nil
+	}
+	pending := migrations[schema.Migration:]
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	keys, err := kv.Keys()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to list keys")
+	}
+
+	applied := []*Migration{}
+	for i, m := range pending {
+		if m.Number != schema.Migration+1 {
+			return applied, errors.Errorf("migration %v is out of order, expected %v", m.Number, schema.Migration+1)
+		}
+		var matched []string
+		for _, key := range keys {
+			if hasAnyPrefix(key, m.Prefixes) {
+				matched = append(matched, key)
+			}
+		}
+		changed := 0
+		if m.Run != nil {
+			changed, err = m.Run(kv, matched, logger)
+			if err != nil {
+				return applied, errors.WithMessagef(err, "migration %v failed", m.Number)
+			}
+		} else {
+			for _, key := range matched {
+				var ok bool
+				ok, err = migrateRecord(kv, key, m, pluginVersion)
+				if err != nil {
+					return applied, errors.WithMessagef(err, "migration %v failed on %s", m.Number, key)
+				}
+				if ok {
+					changed++
+				}
+			}
+		}
+
+		// Record the progress after each migration, so that an interrupted
+		// run resumes from the first migration not completed.
+		schema.Migration = m.Number
+		schema.PluginVersion = pluginVersion
+		err = kvstore.StoreJSON(kv, SchemaVersionKey, schema)
+		if err != nil {
+			return applied, errors.WithMessage(err, "failed to store schema version")
+		}
+		applied = append(applied, pending[i])
+		logger.Infof("store: Applied migration %v %q to %v records", m.Number, m.Description, changed)
+	}
+	return applied, nil
+}
+
+// migrateRecord applies m to the record stored under key. A changed record
+// is stored with its Revision incremented, so that whoever has loaded it
+// before it was migrated gets a ConflictError when storing it.
+func migrateRecord(kv kvstore.KVStore, key string, m *Migration, pluginVersion string) (bool, error) {
+	for attempt := 0; attempt < maxMigrateAttempts; attempt++ {
+		data, err := kv.Load(key)
+		if err == ErrNotFound {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		record := map[string]interface{}{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&record)
+		if err != nil {
+			return false, err
+		}
+		if !m.Migrate(record) {
+			return false, nil
+		}
+
+		if rev, ok := record["Revision"].(json.Number); ok {
+			var n int64
+			n, err = rev.Int64()
+			if err != nil {
+				return false, err
+			}
+			record["Revision"] = n + 1
+		} else {
+			record["Revision"] = 1
+		}
+		record["PluginVersion"] = pluginVersion
+		newData, err := json.Marshal(record)
+		if err != nil {
+			return false, err
+		}
+
+		stored, err := kv.CompareAndSet(key, data, newData)
+		if err != nil {
+			return false, err
+		}
+		if stored {
+			return true, nil
+		}
+	}
+	return false, &ConflictError{
+		Type: "record",
+		ID:   key,
+	}
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
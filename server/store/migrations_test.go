@@ -0,0 +1,228 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+func newTestPluginStore(kv kvstore.KVStore) *pluginStore {
+	return NewStore(kv, &bot.NilLogger{}).(*pluginStore)
+}
+
+// dumpKV returns all the values in kv.
+func dumpKV(t *testing.T, kv kvstore.KVStore) map[string]string {
+	keys, err := kv.Keys()
+	require.NoError(t, err)
+	m := map[string]string{}
+	for _, key := range keys {
+		data, err := kv.Load(key)
+		require.NoError(t, err)
+		m[key] = string(data)
+	}
+	return m
+}
+
+// loadFixtures stores the records in testdata/migrations/<version>, as
+// written by that version of the plugin. The files are named
+// <user|rotation|shift>-<ID>.json.
+func loadFixtures(t *testing.T, kv kvstore.KVStore, version string) {
+	prefixes := map[string]string{
+		"user":     UserKeyPrefix,
+		"rotation": RotationKeyPrefix,
+		"shift":    ShiftKeyPrefix,
+	}
+	paths, err := filepath.Glob(filepath.Join("testdata", "migrations", version, "*.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		parts := strings.SplitN(name, "-", 2)
+		require.Len(t, parts, 2, path)
+		prefix := prefixes[parts[0]]
+		require.NotEmpty(t, prefix, path)
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		err = kvstore.NewHashedKeyStore(kv, prefix).Store(parts[1], bytes.TrimSpace(data))
+		require.NoError(t, err)
+	}
+}
+
+func TestMigrationsRegistry(t *testing.T) {
+	for i, m := range Migrations {
+		require.Equal(t, i+1, m.Number)
+		require.NotEmpty(t, m.Description)
+		require.NotEmpty(t, m.Prefixes)
+		require.True(t, (m.Migrate == nil) != (m.Run == nil), "one of Migrate or Run must be set")
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	for _, version := range []string{"0.1.0"} {
+		t.Run(version, func(t *testing.T) {
+			kv := kvstore.NewMemStore()
+			loadFixtures(t, kv, version)
+			s := newTestPluginStore(kv)
+
+			applied, err := s.Migrate("test")
+			require.NoError(t, err)
+			require.Len(t, applied, len(Migrations))
+			schema, err := s.LoadSchemaVersion()
+			require.NoError(t, err)
+			require.Equal(t, &SchemaVersion{Migration: len(Migrations), PluginVersion: "test"}, schema)
+
+			u1, err := s.LoadUser("u1")
+			require.NoError(t, err)
+			require.Equal(t, IntMap{"webapp": 2}, u1.SkillLevels)
+			require.Equal(t, IntMap{}, u1.LastServed)
+			require.Equal(t, []Event{}, u1.Events)
+			require.Equal(t, 1, u1.Revision)
+			require.Equal(t, "test", u1.PluginVersion)
+
+			// already in the current shape, not touched
+			u2, err := s.LoadUser("u2")
+			require.NoError(t, err)
+			require.Equal(t, 0, u2.Revision)
+			require.Equal(t, version, u2.PluginVersion)
+			require.Len(t, u2.Events, 1)
+
+			r1, err := s.LoadRotation("r1")
+			require.NoError(t, err)
+			require.Equal(t, "solar-lottery", r1.Type)
+			require.Equal(t, 1, r1.Revision)
+			require.Equal(t, "test", r1.PluginVersion)
+			require.Equal(t, IDMap{"u1": "u1", "u2": "u2"}, r1.MattermostUserIDs)
+			require.Equal(t, Needs{NewNeed("webapp", 2, 1)}, r1.Needs)
+			require.True(t, r1.Autopilot.Fill)
+			require.Equal(t, int64(604800000000000), int64(r1.Autopilot.FillPrior))
+
+			shift, err := s.LoadShift("r1", 3)
+			require.NoError(t, err)
+			require.Equal(t, ShiftStatusStarted, shift.Status)
+			require.Equal(t, IDMap{"u2": "u2"}, shift.MattermostUserIDs)
+			shiftNumbers, err := s.ListShiftNumbers("r1")
+			require.NoError(t, err)
+			require.Equal(t, []int{3}, shiftNumbers)
+
+			// a second run has nothing to do
+			before := dumpKV(t, kv)
+			applied, err = s.Migrate("test2")
+			require.NoError(t, err)
+			require.Empty(t, applied)
+			require.Equal(t, before, dumpKV(t, kv))
+		})
+	}
+}
+
+func TestMigrateResume(t *testing.T) {
+	kv := kvstore.NewMemStore()
+	loadFixtures(t, kv, "0.1.0")
+	s := newTestPluginStore(kv)
+
+	// interrupted after the first migration had been applied to a record,
+	// but before the schema version was stored
+	_, err := migrateRecord(kv, hashKeyForTest(UserKeyPrefix, "u1"), Migrations[0], "test")
+	require.NoError(t, err)
+
+	applied, err := s.Migrate("test")
+	require.NoError(t, err)
+	require.Len(t, applied, len(Migrations))
+	u1, err := s.LoadUser("u1")
+	require.NoError(t, err)
+	require.Equal(t, 1, u1.Revision)
+
+	// interrupted after the first migration
+	kv = kvstore.NewMemStore()
+	loadFixtures(t, kv, "0.1.0")
+	s = newTestPluginStore(kv)
+	applied, err = migrate(kv, Migrations[:1], "test", &bot.NilLogger{})
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	r1, err := s.LoadRotation("r1")
+	require.NoError(t, err)
+	require.Equal(t, "", r1.Type)
+
+	applied, err = s.Migrate("test")
+	require.NoError(t, err)
+	require.Len(t, applied, len(Migrations)-1)
+	require.Equal(t, 2, applied[0].Number)
+	r1, err = s.LoadRotation("r1")
+	require.NoError(t, err)
+	require.Equal(t, "solar-lottery", r1.Type)
+}
+
+func TestMigrateConflict(t *testing.T) {
+	kv := kvstore.NewMemStore()
+	loadFixtures(t, kv, "0.1.0")
+	s := newTestPluginStore(kv)
+
+	loaded, err := s.LoadRotation("r1")
+	require.NoError(t, err)
+	_, err = s.Migrate("test")
+	require.NoError(t, err)
+
+	// loaded before it was migrated
+	loaded.Name = "Renamed"
+	err = s.StoreRotation(loaded)
+	require.True(t, IsConflict(err))
+}
+
+func TestMigrateIndexShifts(t *testing.T) {
+	kv := kvstore.NewMemStore()
+	loadFixtures(t, kv, "0.1.0")
+	s := newTestPluginStore(kv)
+
+	// archived rotations are indexed too, shifts already indexed are kept
+	archived := NewRotation("archived")
+	archived.RotationID = "r2"
+	archived.IsArchived = true
+	require.NoError(t, s.StoreRotation(archived))
+	for _, n := range []int{0, 7, 1234} {
+		err := kvstore.StoreJSON(kvstore.NewHashedKeyStore(kv, ShiftKeyPrefix), fmt.Sprintf("r2-%v", n), NewShift("2020-01-01", "2020-01-02", nil))
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.StoreShift("r2", 9, NewShift("2020-01-01", "2020-01-02", nil)))
+	// a shift of a deleted rotation
+	err := kvstore.StoreJSON(kvstore.NewHashedKeyStore(kv, ShiftKeyPrefix), "deleted-1", NewShift("2020-01-01", "2020-01-02", nil))
+	require.NoError(t, err)
+
+	_, err = s.Migrate("test")
+	require.NoError(t, err)
+	shiftNumbers, err := s.ListShiftNumbers("r1")
+	require.NoError(t, err)
+	require.Equal(t, []int{3}, shiftNumbers)
+	shiftNumbers, err = s.ListShiftNumbers("r2")
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 7, 9, 1234}, shiftNumbers)
+}
+
+func TestMigrateNewerSchema(t *testing.T) {
+	kv := kvstore.NewMemStore()
+	loadFixtures(t, kv, "0.1.0")
+	err := kvstore.StoreJSON(kv, SchemaVersionKey, &SchemaVersion{Migration: len(Migrations) + 1, PluginVersion: "9.9.9"})
+	require.NoError(t, err)
+
+	applied, err := newTestPluginStore(kv).Migrate("test")
+	require.NoError(t, err)
+	require.Empty(t, applied)
+	r1, err := newTestPluginStore(kv).LoadRotation("r1")
+	require.NoError(t, err)
+	require.Equal(t, "", r1.Type)
+}
+
+// hashKeyForTest returns the key under which the hashed key stores key.
+func hashKeyForTest(prefix, key string) string {
+	return fmt.Sprintf("%s%x", prefix, md5.Sum([]byte(key)))
+}
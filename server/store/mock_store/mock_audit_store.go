@@ -0,0 +1,78 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/mattermost-plugin-solar-lottery/server/store (interfaces: AuditStore)
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	store "github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	reflect "reflect"
+)
+
+// MockAuditStore is a mock of AuditStore interface
+type MockAuditStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditStoreMockRecorder
+}
+
+// MockAuditStoreMockRecorder is the mock recorder for MockAuditStore
+type MockAuditStoreMockRecorder struct {
+	mock *MockAuditStore
+}
+
+// NewMockAuditStore creates a new mock instance
+func NewMockAuditStore(ctrl *gomock.Controller) *MockAuditStore {
+	mock := &MockAuditStore{ctrl: ctrl}
+	mock.recorder = &MockAuditStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAuditStore) EXPECT() *MockAuditStoreMockRecorder {
+	return m.recorder
+}
+
+// AppendAuditEntry mocks base method
+func (m *MockAuditStore) AppendAuditEntry(arg0 string, arg1 *store.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendAuditEntry", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendAuditEntry indicates an expected call of AppendAuditEntry
+func (mr *MockAuditStoreMockRecorder) AppendAuditEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendAuditEntry", reflect.TypeOf((*MockAuditStore)(nil).AppendAuditEntry), arg0, arg1)
+}
+
+// LoadAuditLog mocks base method
+func (m *MockAuditStore) LoadAuditLog(arg0 string) (*store.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAuditLog", arg0)
+	ret0, _ := ret[0].(*store.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadAuditLog indicates an expected call of LoadAuditLog
+func (mr *MockAuditStoreMockRecorder) LoadAuditLog(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAuditLog", reflect.TypeOf((*MockAuditStore)(nil).LoadAuditLog), arg0)
+}
+
+// LoadAuditPage mocks base method
+func (m *MockAuditStore) LoadAuditPage(arg0 string, arg1 int) (*store.AuditPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAuditPage", arg0, arg1)
+	ret0, _ := ret[0].(*store.AuditPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadAuditPage indicates an expected call of LoadAuditPage
+func (mr *MockAuditStoreMockRecorder) LoadAuditPage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAuditPage", reflect.TypeOf((*MockAuditStore)(nil).LoadAuditPage), arg0, arg1)
+}
@@ -0,0 +1,91 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/mattermost-plugin-solar-lottery/server/store (interfaces: CalendarStore)
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockCalendarStore is a mock of CalendarStore interface
+type MockCalendarStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockCalendarStoreMockRecorder
+}
+
+// MockCalendarStoreMockRecorder is the mock recorder for MockCalendarStore
+type MockCalendarStoreMockRecorder struct {
+	mock *MockCalendarStore
+}
+
+// NewMockCalendarStore creates a new mock instance
+func NewMockCalendarStore(ctrl *gomock.Controller) *MockCalendarStore {
+	mock := &MockCalendarStore{ctrl: ctrl}
+	mock.recorder = &MockCalendarStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCalendarStore) EXPECT() *MockCalendarStoreMockRecorder {
+	return m.recorder
+}
+
+// DeleteCalendarToken mocks base method
+func (m *MockCalendarStore) DeleteCalendarToken(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCalendarToken", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCalendarToken indicates an expected call of DeleteCalendarToken
+func (mr *MockCalendarStoreMockRecorder) DeleteCalendarToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCalendarToken", reflect.TypeOf((*MockCalendarStore)(nil).DeleteCalendarToken), arg0)
+}
+
+// LoadCalendarToken mocks base method
+func (m *MockCalendarStore) LoadCalendarToken(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadCalendarToken", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadCalendarToken indicates an expected call of LoadCalendarToken
+func (mr *MockCalendarStoreMockRecorder) LoadCalendarToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadCalendarToken", reflect.TypeOf((*MockCalendarStore)(nil).LoadCalendarToken), arg0)
+}
+
+// LoadCalendarTokenUserID mocks base method
+func (m *MockCalendarStore) LoadCalendarTokenUserID(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadCalendarTokenUserID", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadCalendarTokenUserID indicates an expected call of LoadCalendarTokenUserID
+func (mr *MockCalendarStoreMockRecorder) LoadCalendarTokenUserID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadCalendarTokenUserID", reflect.TypeOf((*MockCalendarStore)(nil).LoadCalendarTokenUserID), arg0)
+}
+
+// StoreCalendarToken mocks base method
+func (m *MockCalendarStore) StoreCalendarToken(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreCalendarToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreCalendarToken indicates an expected call of StoreCalendarToken
+func (mr *MockCalendarStoreMockRecorder) StoreCalendarToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreCalendarToken", reflect.TypeOf((*MockCalendarStore)(nil).StoreCalendarToken), arg0, arg1)
+}
@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/mattermost-plugin-solar-lottery/server/store (interfaces: MigrationStore)
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	store "github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	reflect "reflect"
+)
+
+// MockMigrationStore is a mock of MigrationStore interface
+type MockMigrationStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockMigrationStoreMockRecorder
+}
+
+// MockMigrationStoreMockRecorder is the mock recorder for MockMigrationStore
+type MockMigrationStoreMockRecorder struct {
+	mock *MockMigrationStore
+}
+
+// NewMockMigrationStore creates a new mock instance
+func NewMockMigrationStore(ctrl *gomock.Controller) *MockMigrationStore {
+	mock := &MockMigrationStore{ctrl: ctrl}
+	mock.recorder = &MockMigrationStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockMigrationStore) EXPECT() *MockMigrationStoreMockRecorder {
+	return m.recorder
+}
+
+// LoadSchemaVersion mocks base method
+func (m *MockMigrationStore) LoadSchemaVersion() (*store.SchemaVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadSchemaVersion")
+	ret0, _ := ret[0].(*store.SchemaVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadSchemaVersion indicates an expected call of LoadSchemaVersion
+func (mr *MockMigrationStoreMockRecorder) LoadSchemaVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadSchemaVersion", reflect.TypeOf((*MockMigrationStore)(nil).LoadSchemaVersion))
+}
+
+// Migrate mocks base method
+func (m *MockMigrationStore) Migrate(arg0 string) ([]*store.Migration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Migrate", arg0)
+	ret0, _ := ret[0].([]*store.Migration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Migrate indicates an expected call of Migrate
+func (mr *MockMigrationStoreMockRecorder) Migrate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Migrate", reflect.TypeOf((*MockMigrationStore)(nil).Migrate), arg0)
+}
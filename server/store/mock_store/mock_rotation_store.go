@@ -47,6 +47,21 @@ func (mr *MockRotationStoreMockRecorder) DeleteRotation(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRotation", reflect.TypeOf((*MockRotationStore)(nil).DeleteRotation), arg0)
 }
 
+// LoadAllRotations mocks base method
+func (m *MockRotationStore) LoadAllRotations() ([]*store.Rotation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAllRotations")
+	ret0, _ := ret[0].([]*store.Rotation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadAllRotations indicates an expected call of LoadAllRotations
+func (mr *MockRotationStoreMockRecorder) LoadAllRotations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAllRotations", reflect.TypeOf((*MockRotationStore)(nil).LoadAllRotations))
+}
+
 // LoadKnownRotations mocks base method
 func (m *MockRotationStore) LoadKnownRotations() (store.IDMap, error) {
 	m.ctrl.T.Helper()
@@ -47,6 +47,50 @@ func (mr *MockShiftStoreMockRecorder) DeleteShift(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteShift", reflect.TypeOf((*MockShiftStore)(nil).DeleteShift), arg0, arg1)
 }
 
+// DeleteShiftNumbers mocks base method
+func (m *MockShiftStore) DeleteShiftNumbers(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteShiftNumbers", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteShiftNumbers indicates an expected call of DeleteShiftNumbers
+func (mr *MockShiftStoreMockRecorder) DeleteShiftNumbers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteShiftNumbers", reflect.TypeOf((*MockShiftStore)(nil).DeleteShiftNumbers), arg0)
+}
+
+// FindShiftNumbers mocks base method
+func (m *MockShiftStore) FindShiftNumbers(arg0 string) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindShiftNumbers", arg0)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindShiftNumbers indicates an expected call of FindShiftNumbers
+func (mr *MockShiftStoreMockRecorder) FindShiftNumbers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindShiftNumbers", reflect.TypeOf((*MockShiftStore)(nil).FindShiftNumbers), arg0)
+}
+
+// ListShiftNumbers mocks base method
+func (m *MockShiftStore) ListShiftNumbers(arg0 string) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShiftNumbers", arg0)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShiftNumbers indicates an expected call of ListShiftNumbers
+func (mr *MockShiftStoreMockRecorder) ListShiftNumbers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShiftNumbers", reflect.TypeOf((*MockShiftStore)(nil).ListShiftNumbers), arg0)
+}
+
 // LoadShift mocks base method
 func (m *MockShiftStore) LoadShift(arg0 string, arg1 int) (*store.Shift, error) {
 	m.ctrl.T.Helper()
@@ -75,3 +119,17 @@ func (mr *MockShiftStoreMockRecorder) StoreShift(arg0, arg1, arg2 interface{}) *
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreShift", reflect.TypeOf((*MockShiftStore)(nil).StoreShift), arg0, arg1, arg2)
 }
+
+// StoreShiftNumbers mocks base method
+func (m *MockShiftStore) StoreShiftNumbers(arg0 string, arg1 []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreShiftNumbers", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreShiftNumbers indicates an expected call of StoreShiftNumbers
+func (mr *MockShiftStoreMockRecorder) StoreShiftNumbers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreShiftNumbers", reflect.TypeOf((*MockShiftStore)(nil).StoreShiftNumbers), arg0, arg1)
+}
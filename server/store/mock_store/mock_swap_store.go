@@ -0,0 +1,63 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/mattermost/mattermost-plugin-solar-lottery/server/store (interfaces: SwapStore)
+
+// Package mock_store is a generated GoMock package.
+package mock_store
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	store "github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+	reflect "reflect"
+)
+
+// MockSwapStore is a mock of SwapStore interface
+type MockSwapStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockSwapStoreMockRecorder
+}
+
+// MockSwapStoreMockRecorder is the mock recorder for MockSwapStore
+type MockSwapStoreMockRecorder struct {
+	mock *MockSwapStore
+}
+
+// NewMockSwapStore creates a new mock instance
+func NewMockSwapStore(ctrl *gomock.Controller) *MockSwapStore {
+	mock := &MockSwapStore{ctrl: ctrl}
+	mock.recorder = &MockSwapStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSwapStore) EXPECT() *MockSwapStoreMockRecorder {
+	return m.recorder
+}
+
+// LoadSwap mocks base method
+func (m *MockSwapStore) LoadSwap(arg0 string) (*store.Swap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadSwap", arg0)
+	ret0, _ := ret[0].(*store.Swap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadSwap indicates an expected call of LoadSwap
+func (mr *MockSwapStoreMockRecorder) LoadSwap(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadSwap", reflect.TypeOf((*MockSwapStore)(nil).LoadSwap), arg0)
+}
+
+// StoreSwap mocks base method
+func (m *MockSwapStore) StoreSwap(arg0 *store.Swap) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreSwap", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreSwap indicates an expected call of StoreSwap
+func (mr *MockSwapStoreMockRecorder) StoreSwap(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreSwap", reflect.TypeOf((*MockSwapStore)(nil).StoreSwap), arg0)
+}
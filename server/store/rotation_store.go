@@ -14,12 +14,14 @@ type RotationStore interface {
 	LoadKnownRotations() (IDMap, error)
 	StoreKnownRotations(IDMap) error
 	LoadRotation(string) (*Rotation, error)
+	LoadAllRotations() ([]*Rotation, error)
 	DeleteRotation(rotationID string) error
 	StoreRotation(*Rotation) error
 }
 
 type Rotation struct {
 	PluginVersion string
+	Revision      int
 	RotationID    string
 	IsArchived    bool
 
@@ -35,6 +37,31 @@ type Rotation struct {
 	MattermostUserIDs IDMap `json:",omitempty"`
 	Needs             Needs `json:",omitempty"`
 
+	// Owners can manage the rotation, in addition to the plugin admins. It
+	// maps their IDs to their usernames.
+	Owners IDMap `json:",omitempty"`
+
+	// TimeZone is the IANA name of the time zone, and HandoffTime is the time
+	// of day (15:04) at which shifts start. The default is midnight UTC.
+	TimeZone    string `json:",omitempty"`
+	HandoffTime string `json:",omitempty"`
+
+	// Weighting is the name of the autofill weighting strategy, see
+	// solarlottery.Weighting.
+	Weighting string `json:",omitempty"`
+
+	// Queue is the order in which users are up next in a queue rotation. The
+	// users who served move to the back.
+	Queue []string `json:",omitempty"`
+
+	// Windows split each period into parts, each filled as its own shift, for
+	// follow-the-sun rotations.
+	Windows []*ShiftWindow `json:",omitempty"`
+
+	// Roles are the escalation tiers within each shift, in order, e.g.
+	// primary, secondary, shadow.
+	Roles []*ShiftRole `json:",omitempty"`
+
 	Autopilot RotationAutopilot `json:",omitempty"`
 }
 
@@ -45,6 +72,51 @@ type RotationAutopilot struct {
 	FillPrior   time.Duration `json:",omitempty"`
 	Notify      bool          `json:",omitempty"`
 	NotifyPrior time.Duration `json:",omitempty"`
+
+	// AckPrior is how long before the start of a shift its users must have
+	// acknowledged it, after which they are reminded daily. The admins are
+	// told about the users who still have not, EscalatePrior before the start.
+	AckPrior      time.Duration `json:",omitempty"`
+	EscalatePrior time.Duration `json:",omitempty"`
+}
+
+// ShiftWindow is a part of each rotation period, filled as its own shift. The
+// window lasts until the next one starts, the last one until the end of the
+// period.
+type ShiftWindow struct {
+	Name string
+
+	// Start is the offset from the start of the period, the first window must
+	// start at 0.
+	Start time.Duration
+
+	// Size and Needs override the rotation's, if set.
+	Size  int   `json:",omitempty"`
+	Needs Needs `json:",omitempty"`
+
+	// Region is the skill users must have to be filled into the window, if
+	// set.
+	Region string `json:",omitempty"`
+}
+
+func (window *ShiftWindow) Clone() *ShiftWindow {
+	newWindow := *window
+	newWindow.Needs = window.Needs.Clone()
+	return &newWindow
+}
+
+// ShiftRole is an escalation tier within a shift. When a rotation has roles,
+// its shifts are filled role by role, each with its own count and needs.
+type ShiftRole struct {
+	Name  string
+	Count int
+	Needs Needs `json:",omitempty"`
+}
+
+func (role *ShiftRole) Clone() *ShiftRole {
+	newRole := *role
+	newRole.Needs = role.Needs.Clone()
+	return &newRole
 }
 
 func NewRotation(name string) *Rotation {
@@ -59,7 +131,17 @@ func (rotation *Rotation) Clone(deep bool) *Rotation {
 	newRotation := *rotation
 	if deep {
 		newRotation.MattermostUserIDs = rotation.MattermostUserIDs.Clone()
+		newRotation.Owners = rotation.Owners.Clone()
 		newRotation.Needs = append(Needs{}, rotation.Needs...)
+		newRotation.Queue = append([]string{}, rotation.Queue...)
+		newRotation.Windows = nil
+		for _, window := range rotation.Windows {
+			newRotation.Windows = append(newRotation.Windows, window.Clone())
+		}
+		newRotation.Roles = nil
+		for _, role := range rotation.Roles {
+			newRotation.Roles = append(newRotation.Roles, role.Clone())
+		}
 	}
 	return &newRotation
 }
@@ -82,6 +164,30 @@ func (s *pluginStore) LoadRotation(rotationID string) (*Rotation, error) {
 	return rotation, nil
 }
 
+// LoadAllRotations loads every stored rotation, archived ones included, in
+// no particular order.
+func (s *pluginStore) LoadAllRotations() ([]*Rotation, error) {
+	keys, err := s.rotationKV.Keys()
+	if err != nil {
+		return nil, err
+	}
+	rotations := []*Rotation{}
+	for _, key := range keys {
+		// the keys are hashed, and so are loaded as they are from the basic
+		// store
+		rotation := NewRotation("")
+		err = kvstore.LoadJSON(s.basicKV, key, rotation)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rotations = append(rotations, rotation)
+	}
+	return rotations, nil
+}
+
 func (s *pluginStore) StoreKnownRotations(rotations IDMap) error {
 	err := kvstore.StoreJSON(s.basicKV, KnownRotationsKey, rotations)
 	if err != nil {
@@ -94,7 +200,7 @@ func (s *pluginStore) StoreKnownRotations(rotations IDMap) error {
 }
 
 func (s *pluginStore) StoreRotation(rotation *Rotation) error {
-	err := kvstore.StoreJSON(s.rotationKV, rotation.RotationID, rotation)
+	err := storeRevisionJSON(s.rotationKV, "rotation", rotation.RotationID, &rotation.Revision, rotation)
 	if err != nil {
 		return err
 	}
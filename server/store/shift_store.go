@@ -5,8 +5,11 @@ package store
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
 )
@@ -15,6 +18,27 @@ type ShiftStore interface {
 	LoadShift(rotationID string, shiftNumber int) (*Shift, error)
 	StoreShift(rotationID string, shiftNumber int, shift *Shift) error
 	DeleteShift(rotationID string, shiftNumber int) error
+
+	// ListShiftNumbers returns the numbers of the stored shifts of the
+	// rotation, in order. The index is maintained by StoreShift and
+	// DeleteShift; StoreShiftNumbers replaces it, e.g. to rebuild it.
+	ListShiftNumbers(rotationID string) ([]int, error)
+	StoreShiftNumbers(rotationID string, shiftNumbers []int) error
+	DeleteShiftNumbers(rotationID string) error
+
+	// FindShiftNumbers returns the numbers of the stored shifts of the
+	// rotation, in order, whether they are indexed or not.
+	FindShiftNumbers(rotationID string) ([]int, error)
+}
+
+// maxShiftIndexAttempts is how many times updating a shift index is retried
+// when it is concurrently modified.
+const maxShiftIndexAttempts = 5
+
+// ShiftIndex lists the numbers of the stored shifts of a rotation.
+type ShiftIndex struct {
+	Revision     int
+	ShiftNumbers []int
 }
 
 const (
@@ -25,6 +49,7 @@ const (
 
 type Shift struct {
 	PluginVersion string
+	Revision      int
 
 	// Mandatory attributes
 	Status string
@@ -34,12 +59,26 @@ type Shift struct {
 	// Optional
 	MattermostUserIDs IDMap          `json:",omitempty"`
 	Autopilot         ShiftAutopilot `json:",omitempty"`
+
+	// Roles maps the users in the shift to their roles, see ShiftRole.
+	Roles map[string]string `json:",omitempty"`
+
+	// Acknowledged maps the users who confirmed they are in the shift to when
+	// they did.
+	Acknowledged map[string]time.Time `json:",omitempty"`
 }
 
 type ShiftAutopilot struct {
-	Filled         time.Time `json:",omitempty"`
+	Filled time.Time `json:",omitempty"`
+	// Seed is the random seed the shift was filled with, use it with
+	// "/lotto rotation guess --seed" to replay the fill.
+	Seed           int64     `json:",omitempty"`
 	NotifiedStart  time.Time `json:",omitempty"`
 	NotifiedFinish time.Time `json:",omitempty"`
+	// AckReminded is when the users who have not acknowledged the shift were
+	// last reminded, and Escalated when the admins were told about them.
+	AckReminded time.Time `json:",omitempty"`
+	Escalated   time.Time `json:",omitempty"`
 }
 
 func NewShift(start, end string, mattermostUserIDs IDMap) *Shift {
@@ -66,13 +105,24 @@ func (s *pluginStore) LoadShift(rotationID string, shiftNumber int) (*Shift, err
 
 func (s *pluginStore) StoreShift(rotationID string, shiftNumber int, shift *Shift) error {
 	key := fmt.Sprintf("%v-%v", rotationID, shiftNumber)
-	err := kvstore.StoreJSON(s.shiftKV, key, shift)
+	err := storeRevisionJSON(s.shiftKV, "shift", key, &shift.Revision, shift)
 	if err != nil {
 		return err
 	}
 	s.Logger.With(bot.LogContext{
 		"Shift": shift,
 	}).Debugf("store: Stored shift %s %v", rotationID, shiftNumber)
+
+	err = s.updateShiftIndex(rotationID, func(numbers map[int]bool) bool {
+		if numbers[shiftNumber] {
+			return false
+		}
+		numbers[shiftNumber] = true
+		return true
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed to index shift %s %v", rotationID, shiftNumber)
+	}
 	return nil
 }
 
@@ -83,5 +133,98 @@ func (s *pluginStore) DeleteShift(rotationID string, shiftNumber int) error {
 		return err
 	}
 	s.Logger.Debugf("store: Deleted shift %s %v", rotationID, shiftNumber)
+
+	err = s.updateShiftIndex(rotationID, func(numbers map[int]bool) bool {
+		if !numbers[shiftNumber] {
+			return false
+		}
+		delete(numbers, shiftNumber)
+		return true
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed to remove shift %s %v from index", rotationID, shiftNumber)
+	}
 	return nil
 }
+
+func (s *pluginStore) ListShiftNumbers(rotationID string) ([]int, error) {
+	index := &ShiftIndex{}
+	err := kvstore.LoadJSON(s.shiftIndexKV, rotationID, index)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	if index.ShiftNumbers == nil {
+		return []int{}, nil
+	}
+	return index.ShiftNumbers, nil
+}
+
+func (s *pluginStore) StoreShiftNumbers(rotationID string, shiftNumbers []int) error {
+	err := s.updateShiftIndex(rotationID, func(numbers map[int]bool) bool {
+		for n := range numbers {
+			delete(numbers, n)
+		}
+		for _, n := range shiftNumbers {
+			numbers[n] = true
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	s.Logger.Debugf("store: Stored %v shift numbers for %s", len(shiftNumbers), rotationID)
+	return nil
+}
+
+func (s *pluginStore) FindShiftNumbers(rotationID string) ([]int, error) {
+	keys, err := s.shiftKV.Keys()
+	if err != nil {
+		return nil, err
+	}
+	shiftKeys := map[string]bool{}
+	for _, key := range keys {
+		shiftKeys[key] = true
+	}
+	return matchShiftNumbers(rotationID, shiftKeys), nil
+}
+
+func (s *pluginStore) DeleteShiftNumbers(rotationID string) error {
+	err := s.shiftIndexKV.Delete(rotationID)
+	if err != nil {
+		return err
+	}
+	s.Logger.Debugf("store: Deleted shift numbers for %s", rotationID)
+	return nil
+}
+
+// updateShiftIndex applies f to the shift numbers in the rotation's index,
+// and stores them if f returns true. It is retried if the index is modified
+// concurrently.
+func (s *pluginStore) updateShiftIndex(rotationID string, f func(numbers map[int]bool) bool) error {
+	var err error
+	for attempt := 0; attempt < maxShiftIndexAttempts; attempt++ {
+		index := &ShiftIndex{}
+		err = kvstore.LoadJSON(s.shiftIndexKV, rotationID, index)
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		numbers := map[int]bool{}
+		for _, n := range index.ShiftNumbers {
+			numbers[n] = true
+		}
+		if !f(numbers) {
+			return nil
+		}
+		index.ShiftNumbers = []int{}
+		for n := range numbers {
+			index.ShiftNumbers = append(index.ShiftNumbers, n)
+		}
+		sort.Ints(index.ShiftNumbers)
+
+		err = storeRevisionJSON(s.shiftIndexKV, "shift index", rotationID, &index.Revision, index)
+		if !IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
@@ -4,9 +4,11 @@
 package store
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/mattermost/mattermost-server/v5/plugin"
+	"github.com/pkg/errors"
 
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
 	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
@@ -16,37 +18,89 @@ const (
 	UserKeyPrefix     = "user_"
 	RotationKeyPrefix = "rotation_"
 	ShiftKeyPrefix    = "shift_"
+	LockKeyPrefix     = "lock_"
+	AuditKeyPrefix    = "audit_"
+	CalendarKeyPrefix = "ical_"
+	SwapKeyPrefix     = "swap_"
 
 	KnownSkillsKey    = "index_skills"
 	KnownRotationsKey = "index_rotations"
+
+	// ShiftIndexKeyPrefix is followed by the hashed rotation ID.
+	ShiftIndexKeyPrefix = "index_shifts_"
 )
 
 const OAuth2KeyExpiration = 15 * time.Minute
 
 var ErrNotFound = kvstore.ErrNotFound
 
+// ConflictError is returned when storing a record that has been modified by
+// someone else since it was loaded.
+type ConflictError struct {
+	Type string
+	ID   string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %s has been modified concurrently", e.Type, e.ID)
+}
+
+// IsConflict returns true if err is, or wraps, a ConflictError.
+func IsConflict(err error) bool {
+	_, ok := errors.Cause(err).(*ConflictError)
+	return ok
+}
+
+func storeRevisionJSON(kv kvstore.KVStore, recordType, key string, revision *int, v interface{}) error {
+	err := kvstore.StoreRevisionJSON(kv, key, revision, v)
+	if err == kvstore.ErrConflict {
+		return &ConflictError{
+			Type: recordType,
+			ID:   key,
+		}
+	}
+	return err
+}
+
 type Store interface {
 	UserStore
 	SkillsStore
 	RotationStore
 	ShiftStore
+	AuditStore
+	CalendarStore
+	SwapStore
+	MigrationStore
 }
 
 type pluginStore struct {
-	basicKV    kvstore.KVStore
-	userKV     kvstore.KVStore
-	rotationKV kvstore.KVStore
-	shiftKV    kvstore.KVStore
-	Logger     bot.Logger
+	basicKV      kvstore.KVStore
+	userKV       kvstore.KVStore
+	rotationKV   kvstore.KVStore
+	shiftKV      kvstore.KVStore
+	shiftIndexKV kvstore.KVStore
+	auditKV      kvstore.KVStore
+	calendarKV   kvstore.KVStore
+	swapKV       kvstore.KVStore
+	Logger       bot.Logger
 }
 
 func NewPluginStore(api plugin.API, logger bot.Logger) Store {
-	basicKV := kvstore.NewPluginStore(api)
+	return NewStore(kvstore.NewPluginStore(api), logger)
+}
+
+// NewStore returns a Store that keeps its records in kv, e.g. in a
+// kvstore.NewMemStore() for tests.
+func NewStore(kv kvstore.KVStore, logger bot.Logger) Store {
 	return &pluginStore{
-		basicKV:    basicKV,
-		userKV:     kvstore.NewHashedKeyStore(basicKV, UserKeyPrefix),
-		rotationKV: kvstore.NewHashedKeyStore(basicKV, RotationKeyPrefix),
-		shiftKV:    kvstore.NewHashedKeyStore(basicKV, ShiftKeyPrefix),
-		Logger:     logger,
+		basicKV:      kv,
+		userKV:       kvstore.NewHashedKeyStore(kv, UserKeyPrefix),
+		rotationKV:   kvstore.NewHashedKeyStore(kv, RotationKeyPrefix),
+		shiftKV:      kvstore.NewHashedKeyStore(kv, ShiftKeyPrefix),
+		shiftIndexKV: kvstore.NewHashedKeyStore(kv, ShiftIndexKeyPrefix),
+		auditKV:      kvstore.NewHashedKeyStore(kv, AuditKeyPrefix),
+		calendarKV:   kvstore.NewHashedKeyStore(kv, CalendarKeyPrefix),
+		swapKV:       kvstore.NewHashedKeyStore(kv, SwapKeyPrefix),
+		Logger:       logger,
 	}
 }
@@ -0,0 +1,380 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+// Package storetest has the conformance tests for the store.Store
+// implementations.
+package storetest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/store"
+)
+
+// TestStore runs the conformance tests on the Stores made by newStore. Each
+// test gets a new, empty store.
+func TestStore(t *testing.T, newStore func() store.Store) {
+	for name, test := range map[string]func(*testing.T, store.Store){
+		"Users":      testUsers,
+		"Rotations":  testRotations,
+		"Shifts":     testShifts,
+		"ShiftIndex": testShiftIndex,
+		"Skills":     testSkills,
+		"Audit":      testAudit,
+		"Calendar":   testCalendar,
+		"Swaps":      testSwaps,
+		"Migrations": testMigrations,
+	} {
+		t.Run(name, func(t *testing.T) {
+			test(t, newStore())
+		})
+	}
+}
+
+func testUsers(t *testing.T, s store.Store) {
+	_, err := s.LoadUser("u1")
+	require.Equal(t, store.ErrNotFound, err)
+	users, err := s.LoadAllUsers()
+	require.NoError(t, err)
+	require.Empty(t, users)
+
+	u1 := store.NewUser("u1")
+	u1.PluginVersion = "test"
+	u1.SkillLevels["webapp"] = 2
+	err = s.StoreUser(u1)
+	require.NoError(t, err)
+	require.Equal(t, 1, u1.Revision)
+	err = s.StoreUser(store.NewUser("u2"))
+	require.NoError(t, err)
+
+	loaded, err := s.LoadUser("u1")
+	require.NoError(t, err)
+	require.Equal(t, u1, loaded)
+
+	// a stale copy conflicts
+	stale := loaded.Clone()
+	stale.Revision = loaded.Revision
+	loaded.Status = store.StatusServing
+	err = s.StoreUser(loaded)
+	require.NoError(t, err)
+	err = s.StoreUser(stale)
+	require.True(t, store.IsConflict(err))
+	// so does a new one, with the same ID
+	err = s.StoreUser(store.NewUser("u1"))
+	require.True(t, store.IsConflict(err))
+
+	users, err = s.LoadAllUsers()
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	ids := []string{}
+	for _, user := range users {
+		ids = append(ids, user.MattermostUserID)
+	}
+	require.ElementsMatch(t, []string{"u1", "u2"}, ids)
+
+	err = s.DeleteUser("u1")
+	require.NoError(t, err)
+	_, err = s.LoadUser("u1")
+	require.Equal(t, store.ErrNotFound, err)
+	users, err = s.LoadAllUsers()
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+}
+
+func testRotations(t *testing.T, s store.Store) {
+	_, err := s.LoadKnownRotations()
+	require.Equal(t, store.ErrNotFound, err)
+	_, err = s.LoadRotation("r1")
+	require.Equal(t, store.ErrNotFound, err)
+	rotations, err := s.LoadAllRotations()
+	require.NoError(t, err)
+	require.Empty(t, rotations)
+
+	err = s.StoreKnownRotations(store.IDMap{"r1": store.NotEmpty})
+	require.NoError(t, err)
+	known, err := s.LoadKnownRotations()
+	require.NoError(t, err)
+	require.Equal(t, store.IDMap{"r1": store.NotEmpty}, known)
+
+	r1 := store.NewRotation("test")
+	r1.RotationID = "r1"
+	r1.Period = "1w"
+	r1.Start = "2020-01-06"
+	r1.MattermostUserIDs["u1"] = "u1"
+	r1.Needs = store.Needs{store.NewNeed("webapp", 2, 1)}
+	r1.Autopilot.FillPrior = 7 * 24 * time.Hour
+	err = s.StoreRotation(r1)
+	require.NoError(t, err)
+	require.Equal(t, 1, r1.Revision)
+
+	loaded, err := s.LoadRotation("r1")
+	require.NoError(t, err)
+	require.Equal(t, r1, loaded)
+
+	stale := loaded.Clone(true)
+	loaded.Size = 3
+	err = s.StoreRotation(loaded)
+	require.NoError(t, err)
+	err = s.StoreRotation(stale)
+	require.True(t, store.IsConflict(err))
+
+	// not in the known rotations
+	r2 := store.NewRotation("archived")
+	r2.RotationID = "r2"
+	r2.IsArchived = true
+	err = s.StoreRotation(r2)
+	require.NoError(t, err)
+	rotations, err = s.LoadAllRotations()
+	require.NoError(t, err)
+	ids := []string{}
+	for _, rotation := range rotations {
+		ids = append(ids, rotation.RotationID)
+	}
+	require.ElementsMatch(t, []string{"r1", "r2"}, ids)
+
+	err = s.DeleteRotation("r1")
+	require.NoError(t, err)
+	_, err = s.LoadRotation("r1")
+	require.Equal(t, store.ErrNotFound, err)
+	rotations, err = s.LoadAllRotations()
+	require.NoError(t, err)
+	require.Len(t, rotations, 1)
+}
+
+func testShifts(t *testing.T, s store.Store) {
+	_, err := s.LoadShift("r1", 1)
+	require.Equal(t, store.ErrNotFound, err)
+
+	shift := store.NewShift("2020-01-06", "2020-01-13", store.IDMap{"u1": "u1"})
+	err = s.StoreShift("r1", 1, shift)
+	require.NoError(t, err)
+	require.Equal(t, 1, shift.Revision)
+	// same number, another rotation
+	err = s.StoreShift("r2", 1, store.NewShift("2020-02-03", "2020-02-10", nil))
+	require.NoError(t, err)
+
+	loaded, err := s.LoadShift("r1", 1)
+	require.NoError(t, err)
+	require.Equal(t, shift, loaded)
+
+	stale := *loaded
+	loaded.Status = store.ShiftStatusStarted
+	err = s.StoreShift("r1", 1, loaded)
+	require.NoError(t, err)
+	err = s.StoreShift("r1", 1, &stale)
+	require.True(t, store.IsConflict(err))
+
+	err = s.DeleteShift("r1", 1)
+	require.NoError(t, err)
+	_, err = s.LoadShift("r1", 1)
+	require.Equal(t, store.ErrNotFound, err)
+	_, err = s.LoadShift("r2", 1)
+	require.NoError(t, err)
+}
+
+func testShiftIndex(t *testing.T, s store.Store) {
+	numbers, err := s.ListShiftNumbers("r1")
+	require.NoError(t, err)
+	require.Equal(t, []int{}, numbers)
+
+	for _, n := range []int{5, 1, 3} {
+		err = s.StoreShift("r1", n, store.NewShift("2020-01-06", "2020-01-13", nil))
+		require.NoError(t, err)
+	}
+	err = s.StoreShift("r2", 2, store.NewShift("2020-01-06", "2020-01-13", nil))
+	require.NoError(t, err)
+
+	// storing again does not change the index
+	shift, err := s.LoadShift("r1", 3)
+	require.NoError(t, err)
+	shift.Status = store.ShiftStatusStarted
+	err = s.StoreShift("r1", 3, shift)
+	require.NoError(t, err)
+
+	numbers, err = s.ListShiftNumbers("r1")
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 3, 5}, numbers)
+	numbers, err = s.ListShiftNumbers("r2")
+	require.NoError(t, err)
+	require.Equal(t, []int{2}, numbers)
+
+	err = s.DeleteShift("r1", 3)
+	require.NoError(t, err)
+	err = s.DeleteShift("r1", 7)
+	require.NoError(t, err)
+	numbers, err = s.ListShiftNumbers("r1")
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 5}, numbers)
+
+	err = s.StoreShiftNumbers("r1", []int{8, 0})
+	require.NoError(t, err)
+	numbers, err = s.ListShiftNumbers("r1")
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 8}, numbers)
+
+	err = s.DeleteShiftNumbers("r1")
+	require.NoError(t, err)
+	numbers, err = s.ListShiftNumbers("r1")
+	require.NoError(t, err)
+	require.Equal(t, []int{}, numbers)
+	numbers, err = s.ListShiftNumbers("r2")
+	require.NoError(t, err)
+	require.Equal(t, []int{2}, numbers)
+
+	// the stored shifts are found regardless of the index
+	err = s.StoreShift("r1", 250, store.NewShift("2020-01-06", "2020-01-13", nil))
+	require.NoError(t, err)
+	err = s.DeleteShiftNumbers("r1")
+	require.NoError(t, err)
+	numbers, err = s.FindShiftNumbers("r1")
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 5, 250}, numbers)
+	numbers, err = s.FindShiftNumbers("r3")
+	require.NoError(t, err)
+	require.Equal(t, []int{}, numbers)
+}
+
+func testSkills(t *testing.T, s store.Store) {
+	_, err := s.LoadKnownSkills()
+	require.Equal(t, store.ErrNotFound, err)
+
+	err = s.StoreKnownSkills(store.IDMap{"webapp": store.NotEmpty, "server": store.NotEmpty})
+	require.NoError(t, err)
+	skills, err := s.LoadKnownSkills()
+	require.NoError(t, err)
+	require.Equal(t, store.IDMap{"webapp": store.NotEmpty, "server": store.NotEmpty}, skills)
+
+	err = s.StoreKnownSkills(store.IDMap{"server": store.NotEmpty})
+	require.NoError(t, err)
+	skills, err = s.LoadKnownSkills()
+	require.NoError(t, err)
+	require.Equal(t, store.IDMap{"server": store.NotEmpty}, skills)
+}
+
+func testAudit(t *testing.T, s store.Store) {
+	logKey := store.RotationAuditLogKey("r1")
+	log, err := s.LoadAuditLog(logKey)
+	require.NoError(t, err)
+	require.Equal(t, 0, log.Pages)
+
+	start := time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC)
+	n := store.AuditPageSize + 3
+	for i := 0; i < n; i++ {
+		err = s.AppendAuditEntry(logKey, &store.AuditEntry{
+			Time:        start.Add(time.Duration(i) * time.Minute),
+			Action:      fmt.Sprintf("action %v", i),
+			RotationID:  "r1",
+			ShiftNumber: -1,
+		})
+		require.NoError(t, err)
+	}
+
+	log, err = s.LoadAuditLog(logKey)
+	require.NoError(t, err)
+	require.Equal(t, 2, log.Pages)
+	first, err := s.LoadAuditPage(logKey, 0)
+	require.NoError(t, err)
+	require.Len(t, first.Entries, store.AuditPageSize)
+	require.Equal(t, "action 0", first.Entries[0].Action)
+	require.True(t, start.Equal(first.Entries[0].Time))
+	last, err := s.LoadAuditPage(logKey, 1)
+	require.NoError(t, err)
+	require.Len(t, last.Entries, 3)
+	require.Equal(t, fmt.Sprintf("action %v", n-1), last.Entries[2].Action)
+
+	// logs are separate
+	log, err = s.LoadAuditLog(store.UserAuditLogKey("u1"))
+	require.NoError(t, err)
+	require.Equal(t, 0, log.Pages)
+}
+
+func testCalendar(t *testing.T, s store.Store) {
+	_, err := s.LoadCalendarToken("u1")
+	require.Equal(t, store.ErrNotFound, err)
+
+	err = s.StoreCalendarToken("u1", "token1")
+	require.NoError(t, err)
+	token, err := s.LoadCalendarToken("u1")
+	require.NoError(t, err)
+	require.Equal(t, "token1", token)
+	userID, err := s.LoadCalendarTokenUserID("token1")
+	require.NoError(t, err)
+	require.Equal(t, "u1", userID)
+
+	// replacing the token revokes the previous one
+	err = s.StoreCalendarToken("u1", "token2")
+	require.NoError(t, err)
+	_, err = s.LoadCalendarTokenUserID("token1")
+	require.Equal(t, store.ErrNotFound, err)
+	userID, err = s.LoadCalendarTokenUserID("token2")
+	require.NoError(t, err)
+	require.Equal(t, "u1", userID)
+
+	err = s.DeleteCalendarToken("u1")
+	require.NoError(t, err)
+	_, err = s.LoadCalendarToken("u1")
+	require.Equal(t, store.ErrNotFound, err)
+	_, err = s.LoadCalendarTokenUserID("token2")
+	require.Equal(t, store.ErrNotFound, err)
+}
+
+func testSwaps(t *testing.T, s store.Store) {
+	_, err := s.LoadSwap("s1")
+	require.Equal(t, store.ErrNotFound, err)
+
+	swap := &store.Swap{
+		SwapID:               "s1",
+		Status:               store.SwapStatusPending,
+		RotationID:           "r1",
+		ShiftNumber:          3,
+		TheirShiftNumber:     -1,
+		FromMattermostUserID: "u1",
+		WithMattermostUserID: "u2",
+		Requested:            time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, s.StoreSwap(swap))
+	loaded, err := s.LoadSwap("s1")
+	require.NoError(t, err)
+	require.Equal(t, swap, loaded)
+
+	// closed once, the stale copy can not be stored
+	stale, err := s.LoadSwap("s1")
+	require.NoError(t, err)
+	loaded.Status = store.SwapStatusAccepted
+	require.NoError(t, s.StoreSwap(loaded))
+	stale.Status = store.SwapStatusDeclined
+	require.True(t, store.IsConflict(s.StoreSwap(stale)))
+	loaded, err = s.LoadSwap("s1")
+	require.NoError(t, err)
+	require.Equal(t, store.SwapStatusAccepted, loaded.Status)
+}
+
+func testMigrations(t *testing.T, s store.Store) {
+	schema, err := s.LoadSchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, &store.SchemaVersion{}, schema)
+
+	// records stored by this version are already up to date
+	user := store.NewUser("u1")
+	user.PluginVersion = "test"
+	err = s.StoreUser(user)
+	require.NoError(t, err)
+
+	applied, err := s.Migrate("test")
+	require.NoError(t, err)
+	require.Len(t, applied, len(store.Migrations))
+	schema, err = s.LoadSchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, &store.SchemaVersion{Migration: len(store.Migrations), PluginVersion: "test"}, schema)
+	loaded, err := s.LoadUser("u1")
+	require.NoError(t, err)
+	require.Equal(t, user, loaded)
+
+	applied, err = s.Migrate("test")
+	require.NoError(t, err)
+	require.Empty(t, applied)
+}
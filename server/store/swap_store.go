@@ -0,0 +1,66 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package store
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/bot"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+// SwapStore keeps the shift swaps, so that each is accepted, declined or
+// withdrawn only once.
+type SwapStore interface {
+	LoadSwap(swapID string) (*Swap, error)
+	StoreSwap(swap *Swap) error
+}
+
+const (
+	SwapStatusPending   = "pending"
+	SwapStatusAccepted  = "accepted"
+	SwapStatusDeclined  = "declined"
+	SwapStatusWithdrawn = "withdrawn"
+)
+
+// Swap is a request from a user in a shift to another user to take their
+// place, see solarlottery.ShiftSwap.
+type Swap struct {
+	PluginVersion string
+	Revision      int
+
+	SwapID               string
+	Status               string
+	RotationID           string
+	ShiftNumber          int
+	TheirShiftNumber     int
+	FromMattermostUserID string
+	WithMattermostUserID string
+
+	Requested time.Time
+	// Closed is when the swap was accepted, declined or withdrawn.
+	Closed time.Time `json:",omitempty"`
+}
+
+func (s *pluginStore) LoadSwap(swapID string) (*Swap, error) {
+	swap := &Swap{}
+	err := kvstore.LoadJSON(s.swapKV, swapID, swap)
+	if err != nil {
+		return nil, err
+	}
+	return swap, nil
+}
+
+// StoreSwap returns a ConflictError if the swap has been stored by someone
+// else since it was loaded, e.g. accepted and declined at the same time.
+func (s *pluginStore) StoreSwap(swap *Swap) error {
+	err := storeRevisionJSON(s.swapKV, "swap", swap.SwapID, &swap.Revision, swap)
+	if err != nil {
+		return err
+	}
+	s.Logger.With(bot.LogContext{
+		"Swap": swap,
+	}).Debugf("store: Stored swap")
+	return nil
+}
@@ -12,6 +12,7 @@ type UserStore interface {
 	LoadUser(mattermostUserId string) (*User, error)
 	StoreUser(user *User) error
 	DeleteUser(mattermostUserId string) error
+	LoadAllUsers() ([]*User, error)
 }
 
 const (
@@ -22,6 +23,7 @@ const (
 
 type User struct {
 	PluginVersion    string `json:",omitempty"`
+	Revision         int    `json:",omitempty"`
 	MattermostUserID string
 
 	// Status is the user's current status
@@ -69,11 +71,11 @@ func NewUser(mattermostUserID string) *User {
 }
 
 func (user *User) Clone() *User {
-	clone := NewUser(user.MattermostUserID)
+	clone := *user
 	clone.SkillLevels = user.SkillLevels.Clone()
 	clone.LastServed = user.LastServed.Clone()
 	clone.Events = append([]Event{}, user.Events...)
-	return clone
+	return &clone
 }
 
 func (s *pluginStore) LoadUser(mattermostUserId string) (*User, error) {
@@ -86,7 +88,7 @@ func (s *pluginStore) LoadUser(mattermostUserId string) (*User, error) {
 }
 
 func (s *pluginStore) StoreUser(user *User) error {
-	err := kvstore.StoreJSON(s.userKV, user.MattermostUserID, user)
+	err := storeRevisionJSON(s.userKV, "user", user.MattermostUserID, &user.Revision, user)
 	if err != nil {
 		return err
 	}
@@ -106,3 +108,26 @@ func (s *pluginStore) DeleteUser(mattermostUserID string) error {
 	}).Debugf("store: Deleted user")
 	return nil
 }
+
+// LoadAllUsers loads every stored user, in no particular order.
+func (s *pluginStore) LoadAllUsers() ([]*User, error) {
+	keys, err := s.userKV.Keys()
+	if err != nil {
+		return nil, err
+	}
+	users := []*User{}
+	for _, key := range keys {
+		// the keys are hashed, and so are loaded as they are from the basic
+		// store
+		user := NewUser("")
+		err = kvstore.LoadJSON(s.basicKV, key, user)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
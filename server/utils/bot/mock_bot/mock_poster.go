@@ -52,6 +52,25 @@ func (mr *MockPosterMockRecorder) DM(arg0, arg1 interface{}, arg2 ...interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DM", reflect.TypeOf((*MockPoster)(nil).DM), varargs...)
 }
 
+// DMFile mocks base method
+func (m *MockPoster) DMFile(arg0, arg1 string, arg2 []byte, arg3 string, arg4 ...interface{}) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2, arg3}
+	for _, a := range arg4 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DMFile", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DMFile indicates an expected call of DMFile
+func (mr *MockPosterMockRecorder) DMFile(arg0, arg1, arg2, arg3 interface{}, arg4 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2, arg3}, arg4...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DMFile", reflect.TypeOf((*MockPoster)(nil).DMFile), varargs...)
+}
+
 // DMWithAttachments mocks base method
 func (m *MockPoster) DMWithAttachments(arg0 string, arg1 ...*model.SlackAttachment) error {
 	m.ctrl.T.Helper()
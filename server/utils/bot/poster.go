@@ -18,6 +18,9 @@ type Poster interface {
 	// Often used to include post actions.
 	DMWithAttachments(userID string, attachments ...*model.SlackAttachment) error
 
+	// DMFile posts a Direct Message with the data attached as a file.
+	DMFile(userID, filename string, data []byte, format string, args ...interface{}) error
+
 	// Ephemeral sends an ephemeral message to a user
 	Ephemeral(userID, channelID, format string, args ...interface{})
 }
@@ -37,6 +40,23 @@ func (bot *bot) DMWithAttachments(userID string, attachments ...*model.SlackAtta
 	return bot.dm(userID, &post)
 }
 
+// DMFile posts a Direct Message with the data attached as a file.
+func (bot *bot) DMFile(userID, filename string, data []byte, format string, args ...interface{}) error {
+	channel, appErr := bot.pluginAPI.GetDirectChannel(userID, bot.mattermostUserID)
+	if appErr != nil {
+		bot.pluginAPI.LogInfo("Couldn't get bot's DM channel", "user_id", userID)
+		return appErr
+	}
+	fileInfo, appErr := bot.pluginAPI.UploadFile(data, channel.Id, filename)
+	if appErr != nil {
+		return appErr
+	}
+	return bot.dm(userID, &model.Post{
+		Message: fmt.Sprintf(format, args...),
+		FileIds: []string{fileInfo.Id},
+	})
+}
+
 func (bot *bot) dm(userID string, post *model.Post) error {
 	channel, err := bot.pluginAPI.GetDirectChannel(userID, bot.mattermostUserID)
 	if err != nil {
@@ -0,0 +1,130 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+// Package ical writes iCalendar (RFC 5545) feeds.
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+const (
+	// ContentType is the MIME type of the iCalendar feeds.
+	ContentType = "text/calendar; charset=utf-8"
+
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+
+	// maxLineOctets is the longest a content line can be, excluding the line
+	// break. Longer lines are folded.
+	maxLineOctets = 75
+)
+
+// Calendar is a VCALENDAR with its events.
+type Calendar struct {
+	// ProductID identifies the product that created the calendar.
+	ProductID string
+	// Name is shown by the calendar apps that support X-WR-CALNAME.
+	Name   string
+	Events []*Event
+}
+
+// Event is a VEVENT. All-day events only use the dates of Start and End, End
+// being exclusive.
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Summary     string
+	Description string
+	// Tentative is set for the events that may still change.
+	Tentative bool
+
+	// Recurrence, Exceptions and RecurrenceID are only read by Parse, see
+	// Expand.
+	Recurrence   *Recurrence
+	Exceptions   []time.Time
+	RecurrenceID time.Time
+}
+
+// Encode returns the calendar, stamped with now.
+func (c *Calendar) Encode(now time.Time) []byte {
+	buf := &bytes.Buffer{}
+	writeLine(buf, "BEGIN:VCALENDAR")
+	writeLine(buf, "VERSION:2.0")
+	writeLine(buf, "PRODID:"+c.ProductID)
+	writeLine(buf, "CALSCALE:GREGORIAN")
+	writeLine(buf, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(buf, "X-WR-CALNAME:"+EscapeText(c.Name))
+	}
+	for _, event := range c.Events {
+		event.encode(buf, now)
+	}
+	writeLine(buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+func (event *Event) encode(buf *bytes.Buffer, now time.Time) {
+	writeLine(buf, "BEGIN:VEVENT")
+	writeLine(buf, "UID:"+event.UID)
+	writeLine(buf, "DTSTAMP:"+now.UTC().Format(dateTimeFormat))
+	if event.AllDay {
+		writeLine(buf, "DTSTART;VALUE=DATE:"+event.Start.Format(dateFormat))
+		writeLine(buf, "DTEND;VALUE=DATE:"+event.End.Format(dateFormat))
+	} else {
+		writeLine(buf, "DTSTART:"+event.Start.UTC().Format(dateTimeFormat))
+		writeLine(buf, "DTEND:"+event.End.UTC().Format(dateTimeFormat))
+	}
+	writeLine(buf, "SUMMARY:"+EscapeText(event.Summary))
+	if event.Description != "" {
+		writeLine(buf, "DESCRIPTION:"+EscapeText(event.Description))
+	}
+	if event.Tentative {
+		writeLine(buf, "STATUS:TENTATIVE")
+	} else {
+		writeLine(buf, "STATUS:CONFIRMED")
+	}
+	writeLine(buf, "TRANSP:OPAQUE")
+	writeLine(buf, "END:VEVENT")
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// EscapeText escapes a TEXT property value.
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeLine writes a content line terminated by CRLF, folding it into
+// continuation lines that start with a space so that no line is longer than
+// 75 octets. Lines are only folded between UTF-8 characters.
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !isRuneStart(line[i]) {
+			i--
+		}
+		buf.WriteString(line[:i])
+		buf.WriteString("\r\n ")
+		line = line[i:]
+		// the leading space counts towards the continuation line length
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeText(t *testing.T) {
+	for in, expected := range map[string]string{
+		"plain":          "plain",
+		"a, b; c":        `a\, b\; c`,
+		`back\slash`:     `back\\slash`,
+		"two\nlines":     `two\nlines`,
+		"crlf\r\nlines":  `crlf\nlines`,
+		"":               "",
+		"unicode ☀ text": "unicode ☀ text",
+	} {
+		t.Run(in, func(t *testing.T) {
+			require.Equal(t, expected, EscapeText(in))
+		})
+	}
+}
+
+func TestWriteLine(t *testing.T) {
+	for name, line := range map[string]string{
+		"short":     "SUMMARY:short",
+		"exact":     "SUMMARY:" + strings.Repeat("x", 75-len("SUMMARY:")),
+		"long":      "DESCRIPTION:" + strings.Repeat("abcdefghij", 30),
+		"multibyte": "SUMMARY:" + strings.Repeat("☀", 60),
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			writeLine(buf, line)
+			out := buf.String()
+			require.True(t, strings.HasSuffix(out, "\r\n"))
+
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			unfolded := lines[0]
+			for i, l := range lines {
+				require.True(t, len(l) <= 75, "line %v is %v octets", i, len(l))
+				if i > 0 {
+					require.True(t, strings.HasPrefix(l, " "))
+					unfolded += l[1:]
+				}
+			}
+			require.Equal(t, line, unfolded)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	pst := time.FixedZone("PST", -8*3600)
+	c := &Calendar{
+		ProductID: "-//test//EN",
+		Name:      "Test, rotation",
+		Events: []*Event{
+			{
+				UID:       "rotation-1@test",
+				Start:     time.Date(2020, 1, 6, 9, 0, 0, 0, pst),
+				End:       time.Date(2020, 1, 13, 9, 0, 0, 0, pst),
+				Summary:   "Shift 1",
+				Tentative: true,
+			},
+			{
+				UID:     "personal-1@test",
+				Start:   time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+				End:     time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC),
+				AllDay:  true,
+				Summary: "Unavailable",
+			},
+		},
+	}
+
+	require.Equal(t, strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//test//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Test\, rotation`,
+		"BEGIN:VEVENT",
+		"UID:rotation-1@test",
+		"DTSTAMP:20200102T030405Z",
+		"DTSTART:20200106T170000Z",
+		"DTEND:20200113T170000Z",
+		"SUMMARY:Shift 1",
+		"STATUS:TENTATIVE",
+		"TRANSP:OPAQUE",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:personal-1@test",
+		"DTSTAMP:20200102T030405Z",
+		"DTSTART;VALUE=DATE:20200201",
+		"DTEND;VALUE=DATE:20200203",
+		"SUMMARY:Unavailable",
+		"STATUS:CONFIRMED",
+		"TRANSP:OPAQUE",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n"), string(c.Encode(now)))
+}
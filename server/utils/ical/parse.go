@@ -0,0 +1,278 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package ical
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Skipped is an event that Parse could not read, because its recurrence rule
+// is not supported.
+type Skipped struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	Reason  string
+}
+
+// Parse reads the VEVENTs of an iCalendar stream. Cancelled and free
+// (transparent) events are left out, and so are the events with an
+// unsupported recurrence rule, which are returned as skipped. Times with an
+// unknown TZID are read as UTC.
+func Parse(r io.Reader) ([]*Event, []*Skipped, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var events []*Event
+	var skipped []*Skipped
+	var event *Event
+	// nested counts the components nested in the current VEVENT, e.g.
+	// VALARM, whose properties are ignored.
+	nested := 0
+	cancelled, free := false, false
+	skipReason := ""
+	for i, line := range lines {
+		name, params, value, err := parseLine(line)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "line %v", i+1)
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT") && event == nil:
+			event = &Event{}
+			nested = 0
+			cancelled, free = false, false
+			skipReason = ""
+			continue
+		case name == "BEGIN" && event != nil:
+			nested++
+			continue
+		case name == "END" && event != nil && nested > 0:
+			nested--
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT") && event != nil:
+			if event.Start.IsZero() {
+				return nil, nil, errors.Errorf("event %q has no DTSTART", event.UID)
+			}
+			if event.End.IsZero() {
+				event.End = event.Start
+				if event.AllDay {
+					event.End = event.Start.AddDate(0, 0, 1)
+				}
+			}
+			switch {
+			case cancelled || free:
+			case skipReason != "":
+				skipped = append(skipped, &Skipped{
+					UID:     event.UID,
+					Summary: event.Summary,
+					Start:   event.Start,
+					Reason:  skipReason,
+				})
+			default:
+				events = append(events, event)
+			}
+			event = nil
+			continue
+		}
+		if event == nil || nested > 0 {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			event.UID = value
+		case "SUMMARY":
+			event.Summary = unescapeText(value)
+		case "DESCRIPTION":
+			event.Description = unescapeText(value)
+		case "STATUS":
+			cancelled = strings.EqualFold(value, "CANCELLED")
+			event.Tentative = strings.EqualFold(value, "TENTATIVE")
+		case "DTSTART":
+			event.Start, event.AllDay, err = parseTime(params, value)
+		case "DTEND":
+			event.End, _, err = parseTime(params, value)
+		case "DURATION":
+			var d duration
+			d, err = parseDuration(value)
+			if err == nil && !event.Start.IsZero() {
+				event.End = d.addTo(event.Start)
+			}
+		case "TRANSP":
+			free = strings.EqualFold(value, "TRANSPARENT")
+		case "RRULE":
+			event.Recurrence, err = parseRecurrence(value)
+			if err != nil {
+				skipReason = err.Error()
+				err = nil
+			}
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				var t time.Time
+				t, _, err = parseTime(params, v)
+				if err != nil {
+					break
+				}
+				event.Exceptions = append(event.Exceptions, t)
+			}
+		case "RECURRENCE-ID":
+			event.RecurrenceID, _, err = parseTime(params, value)
+		}
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "line %v: invalid %s", i+1, name)
+		}
+	}
+	return events, skipped, nil
+}
+
+// unfold reads the content lines, joining the folded ones. Lines broken by a
+// bare LF are accepted too.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read calendar")
+	}
+	return lines, nil
+}
+
+// parseLine splits a content line into its upper-cased name, its parameters
+// and its value. Parameter values may be quoted, and contain ':' and ';'.
+func parseLine(line string) (string, map[string]string, string, error) {
+	inQuotes := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if c == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", errors.Errorf("invalid content line %q", line)
+	}
+
+	value := line[colon+1:]
+	parts := splitUnquoted(line[:colon], ';')
+	name := strings.ToUpper(parts[0])
+	params := map[string]string{}
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return name, params, value, nil
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	inQuotes := false
+	begin := 0
+	for i, c := range s {
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == sep && !inQuotes:
+			parts = append(parts, s[begin:i])
+			begin = i + 1
+		}
+	}
+	return append(parts, s[begin:])
+}
+
+var textUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, `;`,
+	`\,`, `,`,
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+// parseTime reads a DATE or DATE-TIME value. DATE values, and floating times,
+// are returned in UTC.
+func parseTime(params map[string]string, value string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if params["VALUE"] == "DATE" || len(value) == len(dateFormat) {
+		t, err := time.ParseInLocation(dateFormat, value, time.UTC)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.ParseInLocation(dateTimeFormat, value, time.UTC)
+		return t, false, err
+	}
+
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation(localDateTimeFormat, value, loc)
+	return t, false, err
+}
+
+const localDateTimeFormat = "20060102T150405"
+
+// duration is a DURATION value. Days and weeks are nominal, they keep the
+// time of day across daylight saving changes.
+type duration struct {
+	days  int
+	exact time.Duration
+}
+
+func (d duration) addTo(t time.Time) time.Time {
+	return t.AddDate(0, 0, d.days).Add(d.exact)
+}
+
+var durationRE = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+func parseDuration(value string) (duration, error) {
+	m := durationRE.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return duration{}, errors.Errorf("invalid duration %q", value)
+	}
+	n := func(s string) int {
+		i, _ := strconv.Atoi(s)
+		return i
+	}
+	d := duration{
+		days:  n(m[2])*7 + n(m[3]),
+		exact: time.Duration(n(m[4]))*time.Hour + time.Duration(n(m[5]))*time.Minute + time.Duration(n(m[6]))*time.Second,
+	}
+	if m[1] == "-" {
+		d.days, d.exact = -d.days, -d.exact
+	}
+	return d, nil
+}
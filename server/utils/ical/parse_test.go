@@ -0,0 +1,186 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//test//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:pto\r\n" +
+	"DTSTART;VALUE=DATE:20200203\r\n" +
+	"DTEND;VALUE=DATE:20200206\r\n" +
+	"SUMMARY:PTO\\, skiing\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"DESCRIPTION:ignored\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:weekly\r\n" +
+	"DTSTART;TZID=America/New_York:20200106T090000\r\n" +
+	"DURATION:PT8H\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,FR;COUNT=5\r\n" +
+	"EXDATE;TZID=America/New_York:20200110T090000\r\n" +
+	"SUMMARY:Out of\r\n" +
+	"  office\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:weekly\r\n" +
+	"RECURRENCE-ID;TZID=America/New_York:20200113T090000\r\n" +
+	"DTSTART;TZID=America/New_York:20200114T090000\r\n" +
+	"DTEND;TZID=America/New_York:20200114T170000\r\n" +
+	"SUMMARY:Moved\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:cancelled\r\n" +
+	"DTSTART:20200101T000000Z\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:free\r\n" +
+	"DTSTART:20200102T000000Z\r\n" +
+	"TRANSP:TRANSPARENT\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:monthly\r\n" +
+	"DTSTART;VALUE=DATE:20200115\r\n" +
+	"RRULE:FREQ=MONTHLY;BYMONTHDAY=15,30\r\n" +
+	"SUMMARY:Payday\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	events, skipped, err := Parse(strings.NewReader(testCalendar))
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, []*Skipped{{
+		UID:     "monthly",
+		Summary: "Payday",
+		Start:   time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC),
+		Reason:  "unsupported recurrence rule part BYMONTHDAY",
+	}}, skipped)
+
+	pto := events[0]
+	require.Equal(t, "PTO, skiing", pto.Summary)
+	require.True(t, pto.AllDay)
+	require.Equal(t, time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC), pto.Start)
+	require.Equal(t, time.Date(2020, 2, 6, 0, 0, 0, 0, time.UTC), pto.End)
+
+	weekly := events[1]
+	require.Equal(t, "Out of office", weekly.Summary)
+	require.Equal(t, "America/New_York", weekly.Start.Location().String())
+	require.Equal(t, 8*time.Hour, weekly.End.Sub(weekly.Start))
+	require.Equal(t, &Recurrence{
+		Frequency: Weekly,
+		Interval:  1,
+		Count:     5,
+		ByDay:     []time.Weekday{time.Monday, time.Friday},
+		WeekStart: time.Monday,
+	}, weekly.Recurrence)
+	require.Len(t, weekly.Exceptions, 1)
+	require.False(t, events[2].RecurrenceID.IsZero())
+}
+
+func TestExpand(t *testing.T) {
+	events, _, err := Parse(strings.NewReader(testCalendar))
+	require.NoError(t, err)
+
+	var starts []string
+	for _, event := range Expand(events, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		starts = append(starts, event.Start.Format("2006-01-02 15:04")+" "+event.Summary)
+	}
+	// COUNT=5 is Jan 6, 10, 13, 17 and 20; the 10th is excluded, the 13th
+	// moved to the 14th.
+	require.Equal(t, []string{
+		"2020-01-06 09:00 Out of office",
+		"2020-01-14 09:00 Moved",
+		"2020-01-17 09:00 Out of office",
+		"2020-01-20 09:00 Out of office",
+		"2020-02-03 00:00 PTO, skiing",
+	}, starts)
+}
+
+func TestOccurrences(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	for name, tc := range map[string]struct {
+		rule     string
+		start    time.Time
+		from     time.Time
+		expected []string
+	}{
+		"daily interval": {
+			rule:     "FREQ=DAILY;INTERVAL=2;COUNT=3",
+			start:    day(2020, 1, 1),
+			expected: []string{"2020-01-01", "2020-01-03", "2020-01-05"},
+		},
+		"weekly until": {
+			rule:     "FREQ=WEEKLY;UNTIL=20200115",
+			start:    day(2020, 1, 1),
+			expected: []string{"2020-01-01", "2020-01-08", "2020-01-15"},
+		},
+		"biweekly by day": {
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=4",
+			start:    day(2020, 1, 7),
+			expected: []string{"2020-01-07", "2020-01-09", "2020-01-21", "2020-01-23"},
+		},
+		"monthly skips short months": {
+			rule:     "FREQ=MONTHLY;COUNT=3",
+			start:    day(2020, 1, 31),
+			expected: []string{"2020-01-31", "2020-03-31", "2020-05-31"},
+		},
+		"yearly leap day": {
+			rule:     "FREQ=YEARLY;COUNT=2",
+			start:    day(2020, 2, 29),
+			expected: []string{"2020-02-29", "2024-02-29"},
+		},
+		"from is after the start, count still applies": {
+			rule:     "FREQ=DAILY;COUNT=5",
+			start:    day(2020, 1, 1),
+			from:     day(2020, 1, 4),
+			expected: []string{"2020-01-04", "2020-01-05"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rule, err := parseRecurrence(tc.rule)
+			require.NoError(t, err)
+			event := &Event{
+				Start:      tc.start,
+				End:        tc.start.AddDate(0, 0, 1),
+				AllDay:     true,
+				Recurrence: rule,
+			}
+			var starts []string
+			for _, occurrence := range Expand([]*Event{event}, tc.from, day(2030, 1, 1)) {
+				starts = append(starts, occurrence.Start.Format("2006-01-02"))
+			}
+			require.Equal(t, tc.expected, starts)
+		})
+	}
+}
+
+func TestParseRecurrenceUnsupported(t *testing.T) {
+	for _, rule := range []string{
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=1",
+		"FREQ=YEARLY;BYMONTH=3",
+		"FREQ=MONTHLY;BYDAY=MO;BYSETPOS=1",
+		"FREQ=MONTHLY;BYDAY=MO",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+	} {
+		_, err := parseRecurrence(rule)
+		require.Error(t, err, rule)
+	}
+}
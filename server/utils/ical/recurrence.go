@@ -0,0 +1,272 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package ical
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Frequencies of the supported recurrence rules.
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+const (
+	// maxOccurrences bounds how many occurrences of a single recurring
+	// event are expanded.
+	maxOccurrences = 1000
+	// maxIterations bounds the periods a recurrence is iterated over, for
+	// the rules whose periods may have no occurrences.
+	maxIterations = 100000
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Recurrence is an RRULE. Only the FREQ, INTERVAL, COUNT, UNTIL and WKST
+// parts are supported, and BYDAY with plain weekdays in WEEKLY rules.
+type Recurrence struct {
+	Frequency string
+	Interval  int
+	Count     int
+	Until     time.Time
+	ByDay     []time.Weekday
+	WeekStart time.Weekday
+}
+
+func parseRecurrence(value string) (*Recurrence, error) {
+	r := &Recurrence{
+		Interval:  1,
+		WeekStart: time.Monday,
+	}
+	var err error
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("invalid recurrence rule part %q", part)
+		}
+		k, v := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		switch k {
+		case "FREQ":
+			switch v {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Frequency = v
+			default:
+				return nil, errors.Errorf("unsupported recurrence frequency %s", v)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(v)
+			if err != nil || r.Interval < 1 {
+				return nil, errors.Errorf("invalid recurrence interval %q", v)
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(v)
+			if err != nil || r.Count < 1 {
+				return nil, errors.Errorf("invalid recurrence count %q", v)
+			}
+		case "UNTIL":
+			var allDay bool
+			r.Until, allDay, err = parseTime(nil, v)
+			if err != nil {
+				return nil, errors.Errorf("invalid recurrence end %q", v)
+			}
+			if allDay {
+				// the whole day is included
+				r.Until = r.Until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+		case "WKST":
+			day, ok := weekdays[v]
+			if !ok {
+				return nil, errors.Errorf("invalid week start %q", v)
+			}
+			r.WeekStart = day
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				day, ok := weekdays[d]
+				if !ok {
+					return nil, errors.Errorf("unsupported recurrence day %q", d)
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		default:
+			return nil, errors.Errorf("unsupported recurrence rule part %s", k)
+		}
+	}
+	if r.Frequency == "" {
+		return nil, errors.New("recurrence rule has no frequency")
+	}
+	if len(r.ByDay) > 0 && r.Frequency != Weekly {
+		return nil, errors.Errorf("BYDAY is only supported in %s recurrence rules", Weekly)
+	}
+	return r, nil
+}
+
+// Expand returns the occurrences of the events that overlap [from, until),
+// sorted by their start. The occurrences of a recurring event that are
+// excluded by EXDATE, or replaced by an event with the same UID and a
+// RECURRENCE-ID, are skipped.
+func Expand(events []*Event, from, until time.Time) []*Event {
+	replaced := map[string][]time.Time{}
+	for _, event := range events {
+		if !event.RecurrenceID.IsZero() {
+			replaced[event.UID] = append(replaced[event.UID], event.RecurrenceID)
+		}
+	}
+
+	var out []*Event
+	for _, event := range events {
+		if event.Recurrence == nil || !event.RecurrenceID.IsZero() {
+			if event.overlaps(from, until) {
+				out = append(out, event)
+			}
+			continue
+		}
+		skip := func(start time.Time) bool {
+			return event.matches(start, event.Exceptions) || event.matches(start, replaced[event.UID])
+		}
+		out = append(out, event.occurrences(from, until, skip)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+func (event *Event) overlaps(from, until time.Time) bool {
+	if !event.Start.Before(until) {
+		return false
+	}
+	if event.End.After(event.Start) {
+		return event.End.After(from)
+	}
+	return !event.Start.Before(from)
+}
+
+// matches returns true if start is one of the times, the same day for the
+// all-day events.
+func (event *Event) matches(start time.Time, times []time.Time) bool {
+	for _, t := range times {
+		if event.AllDay {
+			y1, m1, d1 := start.Date()
+			y2, m2, d2 := t.Date()
+			if y1 == y2 && m1 == m2 && d1 == d2 {
+				return true
+			}
+		} else if t.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// occurrences expands a recurring event. The skipped occurrences still count
+// towards COUNT.
+func (event *Event) occurrences(from, until time.Time, skip func(time.Time) bool) []*Event {
+	rule := event.Recurrence
+	length := event.End.Sub(event.Start)
+	days := int(length.Round(24*time.Hour) / (24 * time.Hour))
+
+	var out []*Event
+	generated := 0
+	// emit adds the occurrence starting at start, and returns false once
+	// the recurrence is over.
+	emit := func(start time.Time) bool {
+		if !start.Before(until) || (!rule.Until.IsZero() && start.After(rule.Until)) {
+			return false
+		}
+		if rule.Count > 0 && generated >= rule.Count {
+			return false
+		}
+		generated++
+		if skip(start) {
+			return true
+		}
+
+		occurrence := *event
+		occurrence.Recurrence = nil
+		occurrence.Exceptions = nil
+		occurrence.Start = start
+		occurrence.End = start.Add(length)
+		if event.AllDay {
+			occurrence.End = start.AddDate(0, 0, days)
+		}
+		if occurrence.overlaps(from, until) {
+			out = append(out, &occurrence)
+		}
+		return len(out) < maxOccurrences
+	}
+
+	// the days of the week, in order from the week start
+	var offsets []int
+	weekBegin := event.Start
+	if rule.Frequency == Weekly && len(rule.ByDay) > 0 {
+		weekBegin = event.Start.AddDate(0, 0, -weekdayOffset(event.Start.Weekday(), rule.WeekStart))
+		for _, day := range rule.ByDay {
+			offsets = append(offsets, weekdayOffset(day, rule.WeekStart))
+		}
+		sort.Ints(offsets)
+	}
+
+	for k := 0; k < maxIterations; k++ {
+		n := k * rule.Interval
+		switch rule.Frequency {
+		case Daily:
+			if !emit(event.Start.AddDate(0, 0, n)) {
+				return out
+			}
+
+		case Weekly:
+			if len(offsets) == 0 {
+				if !emit(event.Start.AddDate(0, 0, 7*n)) {
+					return out
+				}
+				continue
+			}
+			for _, offset := range offsets {
+				start := weekBegin.AddDate(0, 0, 7*n+offset)
+				if start.Before(event.Start) {
+					continue
+				}
+				if !emit(start) {
+					return out
+				}
+			}
+
+		case Monthly, Yearly:
+			start := event.Start.AddDate(0, n, 0)
+			if rule.Frequency == Yearly {
+				start = event.Start.AddDate(n, 0, 0)
+			}
+			// skip the months that do not have the day, e.g. the 31st
+			if start.Day() != event.Start.Day() {
+				if !start.Before(until) {
+					return out
+				}
+				continue
+			}
+			if !emit(start) {
+				return out
+			}
+		}
+	}
+	return out
+}
+
+// weekdayOffset returns how many days day is after the week start.
+func weekdayOffset(day, weekStart time.Weekday) int {
+	return (int(day) - int(weekStart) + 7) % 7
+}
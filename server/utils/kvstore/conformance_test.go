@@ -0,0 +1,21 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package kvstore_test
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore/kvstoretest"
+)
+
+func TestMemStore(t *testing.T) {
+	kvstoretest.TestKVStore(t, kvstore.NewMemStore)
+}
+
+func TestPluginStore(t *testing.T) {
+	kvstoretest.TestKVStore(t, func() kvstore.KVStore {
+		return kvstore.NewPluginStore(kvstoretest.NewPluginAPI())
+	})
+}
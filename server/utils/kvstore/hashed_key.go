@@ -39,6 +39,14 @@ func (s *hashedKeyStore) Delete(key string) error {
 	return s.store.Delete(hashKey(s.prefix, key))
 }
 
+func (s *hashedKeyStore) CompareAndSet(key string, oldValue, newValue []byte) (bool, error) {
+	return s.store.CompareAndSet(hashKey(s.prefix, key), oldValue, newValue)
+}
+
+func (s *hashedKeyStore) CompareAndDelete(key string, oldValue []byte) (bool, error) {
+	return s.store.CompareAndDelete(hashKey(s.prefix, key), oldValue)
+}
+
 func (s *hashedKeyStore) Keys() ([]string, error) {
 	all, err := s.store.Keys()
 	if err != nil {
@@ -54,6 +62,12 @@ func (s *hashedKeyStore) Keys() ([]string, error) {
 	return matched, nil
 }
 
+// HashKey returns the key under which a NewHashedKeyStore with the prefix
+// stores hashableKey.
+func HashKey(prefix, hashableKey string) string {
+	return hashKey(prefix, hashableKey)
+}
+
 func hashKey(prefix, hashableKey string) string {
 	if hashableKey == "" {
 		return prefix
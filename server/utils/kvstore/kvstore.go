@@ -14,6 +14,15 @@ type KVStore interface {
 	StoreTTL(key string, data []byte, ttlSeconds int64) error
 	Delete(key string) error
 	Keys() ([]string, error)
+
+	// CompareAndSet stores newValue only if the current value matches
+	// oldValue. A nil oldValue requires that the key does not exist. Returns
+	// false if the value was not stored.
+	CompareAndSet(key string, oldValue, newValue []byte) (bool, error)
+
+	// CompareAndDelete deletes the key only if the current value matches
+	// oldValue. Returns false if the key was not deleted.
+	CompareAndDelete(key string, oldValue []byte) (bool, error)
 }
 
 var ErrNotFound = errors.New("not found")
@@ -0,0 +1,164 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+// Package kvstoretest has the conformance tests for the kvstore.KVStore
+// implementations, and a fake plugin.API to test the plugin stores with.
+package kvstoretest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+// TestKVStore runs the conformance tests on the KVStores made by newKV. Each
+// test gets a new, empty store.
+func TestKVStore(t *testing.T, newKV func() kvstore.KVStore) {
+	for name, test := range map[string]func(*testing.T, kvstore.KVStore){
+		"LoadStoreDelete":  testLoadStoreDelete,
+		"CompareAndSet":    testCompareAndSet,
+		"CompareAndDelete": testCompareAndDelete,
+		"Keys":             testKeys,
+		"JSON":             testJSON,
+	} {
+		t.Run(name, func(t *testing.T) {
+			test(t, newKV())
+		})
+	}
+}
+
+func testLoadStoreDelete(t *testing.T, kv kvstore.KVStore) {
+	_, err := kv.Load("key")
+	require.Equal(t, kvstore.ErrNotFound, err)
+
+	value := []byte("value")
+	err = kv.Store("key", value)
+	require.NoError(t, err)
+	// the stored value is not affected by changes to the caller's buffer
+	value[0] = 'V'
+	data, err := kv.Load("key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), data)
+
+	err = kv.Store("key", []byte("updated"))
+	require.NoError(t, err)
+	data, err = kv.Load("key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("updated"), data)
+
+	err = kv.StoreTTL("other", []byte("expiring"), 60)
+	require.NoError(t, err)
+	data, err = kv.Load("other")
+	require.NoError(t, err)
+	require.Equal(t, []byte("expiring"), data)
+
+	err = kv.Delete("key")
+	require.NoError(t, err)
+	_, err = kv.Load("key")
+	require.Equal(t, kvstore.ErrNotFound, err)
+	// deleting a missing key is not an error
+	err = kv.Delete("key")
+	require.NoError(t, err)
+	data, err = kv.Load("other")
+	require.NoError(t, err)
+	require.Equal(t, []byte("expiring"), data)
+}
+
+func testCompareAndSet(t *testing.T, kv kvstore.KVStore) {
+	// nil requires the key to not exist
+	ok, err := kv.CompareAndSet("key", nil, []byte("first"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = kv.CompareAndSet("key", nil, []byte("second"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = kv.CompareAndSet("key", []byte("wrong"), []byte("second"))
+	require.NoError(t, err)
+	require.False(t, ok)
+	data, err := kv.Load("key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("first"), data)
+
+	ok, err = kv.CompareAndSet("key", []byte("first"), []byte("second"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	data, err = kv.Load("key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("second"), data)
+
+	ok, err = kv.CompareAndSet("missing", []byte("any"), []byte("value"))
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, err = kv.Load("missing")
+	require.Equal(t, kvstore.ErrNotFound, err)
+}
+
+func testCompareAndDelete(t *testing.T, kv kvstore.KVStore) {
+	ok, err := kv.CompareAndDelete("key", []byte("value"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	err = kv.Store("key", []byte("value"))
+	require.NoError(t, err)
+	ok, err = kv.CompareAndDelete("key", []byte("wrong"))
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, err = kv.Load("key")
+	require.NoError(t, err)
+
+	ok, err = kv.CompareAndDelete("key", []byte("value"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = kv.Load("key")
+	require.Equal(t, kvstore.ErrNotFound, err)
+}
+
+func testKeys(t *testing.T, kv kvstore.KVStore) {
+	keys, err := kv.Keys()
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	// more than a page of the plugin API's KVList
+	expected := []string{}
+	for i := 0; i < 250; i++ {
+		key := fmt.Sprintf("key-%03d", i)
+		expected = append(expected, key)
+		err = kv.Store(key, []byte("value"))
+		require.NoError(t, err)
+	}
+	keys, err = kv.Keys()
+	require.NoError(t, err)
+	require.ElementsMatch(t, expected, keys)
+
+	err = kv.Delete("key-100")
+	require.NoError(t, err)
+	keys, err = kv.Keys()
+	require.NoError(t, err)
+	require.Len(t, keys, 249)
+	require.NotContains(t, keys, "key-100")
+}
+
+func testJSON(t *testing.T, kv kvstore.KVStore) {
+	type record struct {
+		Revision int
+		Value    string
+	}
+	err := kvstore.LoadJSON(kv, "key", &record{})
+	require.Equal(t, kvstore.ErrNotFound, err)
+
+	r := record{Value: "first"}
+	err = kvstore.StoreRevisionJSON(kv, "key", &r.Revision, &r)
+	require.NoError(t, err)
+	loaded := record{}
+	err = kvstore.LoadJSON(kv, "key", &loaded)
+	require.NoError(t, err)
+	require.Equal(t, record{Revision: 1, Value: "first"}, loaded)
+
+	stale := record{Value: "stale"}
+	err = kvstore.StoreRevisionJSON(kv, "key", &stale.Revision, &stale)
+	require.Equal(t, kvstore.ErrConflict, err)
+}
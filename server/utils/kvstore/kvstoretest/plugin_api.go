@@ -0,0 +1,96 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package kvstoretest
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin"
+
+	"github.com/mattermost/mattermost-plugin-solar-lottery/server/utils/kvstore"
+)
+
+// PluginAPI is a fake plugin.API that implements the KV methods in memory.
+// Calling any other method panics.
+type PluginAPI struct {
+	plugin.API
+	kv kvstore.KVStore
+}
+
+var _ plugin.API = (*PluginAPI)(nil)
+
+func NewPluginAPI() *PluginAPI {
+	return &PluginAPI{
+		kv: kvstore.NewMemStore(),
+	}
+}
+
+func (api *PluginAPI) KVGet(key string) ([]byte, *model.AppError) {
+	data, err := api.kv.Load(key)
+	if err == kvstore.ErrNotFound {
+		return nil, nil
+	}
+	return data, appError("KVGet", err)
+}
+
+func (api *PluginAPI) KVSet(key string, value []byte) *model.AppError {
+	return appError("KVSet", api.kv.Store(key, value))
+}
+
+func (api *PluginAPI) KVSetWithExpiry(key string, value []byte, expireInSeconds int64) *model.AppError {
+	return appError("KVSetWithExpiry", api.kv.StoreTTL(key, value, expireInSeconds))
+}
+
+func (api *PluginAPI) KVDelete(key string) *model.AppError {
+	return appError("KVDelete", api.kv.Delete(key))
+}
+
+func (api *PluginAPI) KVDeleteAll() *model.AppError {
+	keys, err := api.kv.Keys()
+	if err != nil {
+		return appError("KVDeleteAll", err)
+	}
+	for _, key := range keys {
+		err = api.kv.Delete(key)
+		if err != nil {
+			return appError("KVDeleteAll", err)
+		}
+	}
+	return nil
+}
+
+func (api *PluginAPI) KVCompareAndSet(key string, oldValue, newValue []byte) (bool, *model.AppError) {
+	ok, err := api.kv.CompareAndSet(key, oldValue, newValue)
+	return ok, appError("KVCompareAndSet", err)
+}
+
+func (api *PluginAPI) KVCompareAndDelete(key string, oldValue []byte) (bool, *model.AppError) {
+	ok, err := api.kv.CompareAndDelete(key, oldValue)
+	return ok, appError("KVCompareAndDelete", err)
+}
+
+// KVList returns a page of the keys, in order.
+func (api *PluginAPI) KVList(page, perPage int) ([]string, *model.AppError) {
+	keys, err := api.kv.Keys()
+	if err != nil {
+		return nil, appError("KVList", err)
+	}
+	start := page * perPage
+	if start >= len(keys) {
+		return []string{}, nil
+	}
+	end := start + perPage
+	if end > len(keys) {
+		end = len(keys)
+	}
+	return keys[start:end], nil
+}
+
+func appError(where string, err error) *model.AppError {
+	if err == nil {
+		return nil
+	}
+	return model.NewAppError(where, "kvstoretest.error", nil, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package kvstore
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+// memStore is an in-memory KVStore, safe for concurrent use. It is for tests,
+// and ignores TTLs.
+type memStore struct {
+	lock sync.Mutex
+	data map[string][]byte
+}
+
+var _ KVStore = (*memStore)(nil)
+
+func NewMemStore() KVStore {
+	return &memStore{
+		data: map[string][]byte{},
+	}
+}
+
+func (s *memStore) Load(key string) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.data[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte{}, data...), nil
+}
+
+func (s *memStore) Store(key string, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.data[key] = append([]byte{}, data...)
+	return nil
+}
+
+func (s *memStore) StoreTTL(key string, data []byte, ttlSeconds int64) error {
+	return s.Store(key, data)
+}
+
+func (s *memStore) Delete(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+func (s *memStore) CompareAndSet(key string, oldValue, newValue []byte) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	current, ok := s.data[key]
+	if (oldValue == nil && ok) || (oldValue != nil && (!ok || !bytes.Equal(current, oldValue))) {
+		return false, nil
+	}
+	s.data[key] = append([]byte{}, newValue...)
+	return true, nil
+}
+
+func (s *memStore) CompareAndDelete(key string, oldValue []byte) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	current, ok := s.data[key]
+	if !ok || !bytes.Equal(current, oldValue) {
+		return false, nil
+	}
+	delete(s.data, key)
+	return true, nil
+}
+
+// Keys returns the keys in order.
+func (s *memStore) Keys() ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	keys := []string{}
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
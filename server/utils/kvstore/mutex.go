@@ -0,0 +1,92 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package kvstore
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// Mutex is a cluster-wide lock, built on the compare-and-set of a KVStore. A
+// lock that has not been released before its TTL expires is considered
+// abandoned (the server holding it may have crashed), and can be taken over.
+type Mutex struct {
+	kv  KVStore
+	key string
+	ttl time.Duration
+	now func() time.Time
+
+	// value is what this instance stored, set while it holds the lock.
+	value []byte
+}
+
+type mutexValue struct {
+	Holder  string
+	Expires time.Time
+}
+
+func NewMutex(kv KVStore, key string, ttl time.Duration) *Mutex {
+	return &Mutex{
+		kv:  kv,
+		key: key,
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// TryLock attempts to acquire the lock without waiting, returns false if it is
+// held by someone else.
+func (m *Mutex) TryLock() (bool, error) {
+	newValue, err := json.Marshal(mutexValue{
+		Holder:  model.NewId(),
+		Expires: m.now().Add(m.ttl),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	locked, err := m.kv.CompareAndSet(m.key, nil, newValue)
+	if err != nil {
+		return false, err
+	}
+	if !locked {
+		// Check if the existing lock has expired, and take it over if so.
+		var prevValue []byte
+		prevValue, err = m.kv.Load(m.key)
+		switch err {
+		case nil:
+			prev := mutexValue{}
+			err = json.Unmarshal(prevValue, &prev)
+			if err == nil && m.now().Before(prev.Expires) {
+				return false, nil
+			}
+		case ErrNotFound:
+			// Released since the first attempt, try again.
+			prevValue = nil
+		default:
+			return false, err
+		}
+
+		locked, err = m.kv.CompareAndSet(m.key, prevValue, newValue)
+		if err != nil || !locked {
+			return false, err
+		}
+	}
+
+	m.value = newValue
+	return true, nil
+}
+
+// Unlock releases the lock, if it is still held by this instance.
+func (m *Mutex) Unlock() error {
+	if m.value == nil {
+		return nil
+	}
+	value := m.value
+	m.value = nil
+	_, err := m.kv.CompareAndDelete(m.key, value)
+	return err
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package kvstore
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutexTwoNodes(t *testing.T) {
+	kv := NewMemStore()
+	node1 := NewMutex(kv, "test-lock", time.Minute)
+	node2 := NewMutex(kv, "test-lock", time.Minute)
+
+	locked, err := node1.TryLock()
+	require.NoError(t, err)
+	require.True(t, locked)
+
+	locked, err = node2.TryLock()
+	require.NoError(t, err)
+	require.False(t, locked)
+
+	// Unlocking a lock held by another node is a no-op.
+	err = node2.Unlock()
+	require.NoError(t, err)
+	locked, err = node2.TryLock()
+	require.NoError(t, err)
+	require.False(t, locked)
+
+	err = node1.Unlock()
+	require.NoError(t, err)
+	locked, err = node2.TryLock()
+	require.NoError(t, err)
+	require.True(t, locked)
+}
+
+func TestMutexExpired(t *testing.T) {
+	kv := NewMemStore()
+	now := time.Date(2020, 1, 10, 12, 0, 0, 0, time.UTC)
+	node1 := NewMutex(kv, "test-lock", time.Minute)
+	node1.now = func() time.Time { return now }
+	node2 := NewMutex(kv, "test-lock", time.Minute)
+	node2.now = func() time.Time { return now.Add(30 * time.Second) }
+
+	locked, err := node1.TryLock()
+	require.NoError(t, err)
+	require.True(t, locked)
+	locked, err = node2.TryLock()
+	require.NoError(t, err)
+	require.False(t, locked)
+
+	// node1 "crashes", node2 takes over after the TTL.
+	node2.now = func() time.Time { return now.Add(2 * time.Minute) }
+	locked, err = node2.TryLock()
+	require.NoError(t, err)
+	require.True(t, locked)
+
+	// node1 coming back must not release node2's lock.
+	err = node1.Unlock()
+	require.NoError(t, err)
+	node1.now = node2.now
+	locked, err = node1.TryLock()
+	require.NoError(t, err)
+	require.False(t, locked)
+}
+
+func TestMutexCompetingNodes(t *testing.T) {
+	const (
+		numNodes = 2
+		numTries = 500
+	)
+	kv := NewMemStore()
+	var inside, entered int32
+	wg := sync.WaitGroup{}
+	for n := 0; n < numNodes; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := NewMutex(kv, "test-lock", time.Minute)
+			for i := 0; i < numTries; i++ {
+				locked, err := m.TryLock()
+				require.NoError(t, err)
+				if !locked {
+					continue
+				}
+				require.Equal(t, int32(1), atomic.AddInt32(&inside, 1))
+				atomic.AddInt32(&entered, 1)
+				atomic.AddInt32(&inside, -1)
+				require.NoError(t, m.Unlock())
+			}
+		}()
+	}
+	wg.Wait()
+	require.NotZero(t, entered)
+}
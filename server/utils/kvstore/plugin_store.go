@@ -70,6 +70,22 @@ func (s *pluginStore) Delete(key string) error {
 	return nil
 }
 
+func (s *pluginStore) CompareAndSet(key string, oldValue, newValue []byte) (bool, error) {
+	ok, appErr := s.api.KVCompareAndSet(key, oldValue, newValue)
+	if appErr != nil {
+		return false, errors.WithMessagef(appErr, "failed plugin KVCompareAndSet %q", key)
+	}
+	return ok, nil
+}
+
+func (s *pluginStore) CompareAndDelete(key string, oldValue []byte) (bool, error) {
+	ok, appErr := s.api.KVCompareAndDelete(key, oldValue)
+	if appErr != nil {
+		return false, errors.WithMessagef(appErr, "failed plugin KVCompareAndDelete %q", key)
+	}
+	return ok, nil
+}
+
 const listPerPage = 100
 
 func (s *pluginStore) Keys() ([]string, error) {
@@ -79,10 +95,10 @@ func (s *pluginStore) Keys() ([]string, error) {
 		if appErr != nil {
 			return nil, appErr
 		}
+		keys = append(keys, moreKeys...)
 		if len(moreKeys) < listPerPage {
 			break
 		}
-		keys = append(keys, moreKeys...)
 	}
 	return keys, nil
 }
@@ -0,0 +1,57 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package kvstore
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrConflict is returned by StoreRevisionJSON when the stored value has been
+// modified since it was loaded.
+var ErrConflict = errors.New("conflict")
+
+// StoreRevisionJSON stores v as JSON, only if the revision stored under key is
+// still the same as *revision, i.e. nobody else has stored it since it was
+// loaded. v is expected to serialize *revision as its "Revision" field. On
+// success, *revision is incremented.
+func StoreRevisionJSON(s KVStore, key string, revision *int, v interface{}) error {
+	prevData, err := s.Load(key)
+	switch err {
+	case nil:
+		prev := struct{ Revision int }{}
+		err = json.Unmarshal(prevData, &prev)
+		if err != nil {
+			return err
+		}
+		if prev.Revision != *revision {
+			return ErrConflict
+		}
+	case ErrNotFound:
+		if *revision != 0 {
+			// Deleted since it was loaded.
+			return ErrConflict
+		}
+		prevData = nil
+	default:
+		return err
+	}
+
+	*revision++
+	data, err := json.Marshal(v)
+	if err != nil {
+		*revision--
+		return err
+	}
+	stored, err := s.CompareAndSet(key, prevData, data)
+	if err != nil {
+		*revision--
+		return err
+	}
+	if !stored {
+		*revision--
+		return ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package kvstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRecord struct {
+	Revision int
+	Value    string
+}
+
+func TestStoreRevisionJSON(t *testing.T) {
+	kv := NewMemStore()
+
+	r1 := testRecord{Value: "first"}
+	err := StoreRevisionJSON(kv, "key", &r1.Revision, &r1)
+	require.NoError(t, err)
+	require.Equal(t, 1, r1.Revision)
+
+	// A second new record under the same key conflicts.
+	conflicting := testRecord{Value: "conflicting"}
+	err = StoreRevisionJSON(kv, "key", &conflicting.Revision, &conflicting)
+	require.Equal(t, ErrConflict, err)
+	require.Equal(t, 0, conflicting.Revision)
+
+	// Two concurrent updates of the same revision, the second one loses.
+	r2 := testRecord{}
+	err = LoadJSON(kv, "key", &r2)
+	require.NoError(t, err)
+	r1.Value = "updated 1"
+	r2.Value = "updated 2"
+	err = StoreRevisionJSON(kv, "key", &r1.Revision, &r1)
+	require.NoError(t, err)
+	require.Equal(t, 2, r1.Revision)
+	err = StoreRevisionJSON(kv, "key", &r2.Revision, &r2)
+	require.Equal(t, ErrConflict, err)
+	require.Equal(t, 1, r2.Revision)
+
+	// After reloading, the update succeeds.
+	err = LoadJSON(kv, "key", &r2)
+	require.NoError(t, err)
+	require.Equal(t, "updated 1", r2.Value)
+	r2.Value = "updated 2"
+	err = StoreRevisionJSON(kv, "key", &r2.Revision, &r2)
+	require.NoError(t, err)
+	require.Equal(t, 3, r2.Revision)
+
+	// Storing a record that has been deleted since it was loaded conflicts.
+	require.NoError(t, kv.Delete("key"))
+	err = StoreRevisionJSON(kv, "key", &r2.Revision, &r2)
+	require.Equal(t, ErrConflict, err)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ContextSignature is the key of the signature in a signed context.
+const ContextSignature = "signature"
+
+// ContextSelectedOption is set by the server in the context of a select
+// action, and so is not signed.
+const ContextSelectedOption = "selected_option"
+
+// SignContext adds an HMAC signature of the context, so that a context posted
+// back to the plugin can be checked with VerifyContext.
+func SignContext(secret string, context map[string]interface{}) error {
+	signature, err := contextSignature(secret, context)
+	if err != nil {
+		return err
+	}
+	context[ContextSignature] = signature
+	return nil
+}
+
+// VerifyContext returns an error unless the context was signed with the
+// secret, and has not been modified since.
+func VerifyContext(secret string, context map[string]interface{}) error {
+	signature, _ := context[ContextSignature].(string)
+	if signature == "" {
+		return errors.New("context is not signed")
+	}
+	expected, err := contextSignature(secret, context)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errors.New("invalid context signature")
+	}
+	return nil
+}
+
+// contextSignature signs the JSON encoding of the context, which has its keys
+// sorted, and numbers encoded the same whether they are ints or float64s
+// decoded from JSON.
+func contextSignature(secret string, context map[string]interface{}) (string, error) {
+	if secret == "" {
+		return "", errors.New("signing secret is not configured")
+	}
+	signed := map[string]interface{}{}
+	for k, v := range context {
+		if k != ContextSignature && k != ContextSelectedOption {
+			signed[k] = v
+		}
+	}
+	data, err := json.Marshal(signed)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(data)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
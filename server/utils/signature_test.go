@@ -0,0 +1,37 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License for license information.
+
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignContext(t *testing.T) {
+	context := map[string]interface{}{
+		"action": "leave",
+		"shift":  12,
+	}
+	require.NoError(t, SignContext("secret", context))
+
+	// The context is posted back as JSON, with a selected option added.
+	data, err := json.Marshal(context)
+	require.NoError(t, err)
+	posted := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &posted))
+	posted[ContextSelectedOption] = "some-user-ID"
+	require.NoError(t, VerifyContext("secret", posted))
+
+	require.Error(t, VerifyContext("other-secret", posted))
+	require.Error(t, VerifyContext("", posted))
+
+	posted["shift"] = 13
+	require.Error(t, VerifyContext("secret", posted))
+
+	delete(posted, ContextSignature)
+	posted["shift"] = 12
+	require.Error(t, VerifyContext("secret", posted))
+}